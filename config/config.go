@@ -1,12 +1,53 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port   string `mapstructure:"PORT"`
-	DBConn string `mapstructure:"DB_CONN"`
+	Port                    string `mapstructure:"PORT"`
+	DBConn                  string `mapstructure:"DB_CONN"`
+	PointsEarnUnit          int    `mapstructure:"POINTS_EARN_UNIT"`
+	PointValue              int    `mapstructure:"POINT_VALUE"`
+	StoreName               string `mapstructure:"STORE_NAME"`
+	StoreCode               string `mapstructure:"STORE_CODE"`
+	InvoiceFormat           string `mapstructure:"INVOICE_FORMAT"`
+	InvoiceDigits           int    `mapstructure:"INVOICE_DIGITS"`
+	OverrideApprovalPercent int    `mapstructure:"OVERRIDE_APPROVAL_PERCENT"`
+	MultiTenant             bool   `mapstructure:"MULTI_TENANT"`
+	TenantBaseDomain        string `mapstructure:"TENANT_BASE_DOMAIN"`
+	TenantPoolMaxConns      int    `mapstructure:"TENANT_POOL_MAX_CONNS"`
+	TenantPoolIdleTimeout   int    `mapstructure:"TENANT_POOL_IDLE_TIMEOUT"`
+	AdminToken              string `mapstructure:"ADMIN_TOKEN"`
+	PINLookupKey            string `mapstructure:"PIN_LOOKUP_KEY"`
+	LowStockAlerts          string `mapstructure:"LOW_STOCK_ALERTS"`
+	LowStockWebhookURL      string `mapstructure:"LOW_STOCK_WEBHOOK_URL"`
+	SMTPAddr                string `mapstructure:"SMTP_ADDR"`
+	AlertEmailFrom          string `mapstructure:"ALERT_EMAIL_FROM"`
+	AlertEmailTo            string `mapstructure:"ALERT_EMAIL_TO"`
+	ReorderVelocityDays     int    `mapstructure:"REORDER_VELOCITY_DAYS"`
+	ReorderCoverDays        int    `mapstructure:"REORDER_COVER_DAYS"`
+	RupiahRounding          string `mapstructure:"RUPIAH_ROUNDING"`
+	ScaleWeightPrefixes     string `mapstructure:"SCALE_WEIGHT_PREFIXES"`
+	ScalePricePrefixes      string `mapstructure:"SCALE_PRICE_PREFIXES"`
+	ScalePLUDigits          int    `mapstructure:"SCALE_PLU_DIGITS"`
+	PriceScheduleInterval   int    `mapstructure:"PRICE_SCHEDULE_INTERVAL"`
+	ImageStorage            string `mapstructure:"IMAGE_STORAGE"`
+	ImageDir                string `mapstructure:"IMAGE_DIR"`
+	ImageMaxBytes           int    `mapstructure:"IMAGE_MAX_BYTES"`
+	ThumbnailSize           int    `mapstructure:"THUMBNAIL_SIZE"`
+	S3Endpoint              string `mapstructure:"S3_ENDPOINT"`
+	S3Bucket                string `mapstructure:"S3_BUCKET"`
+	S3Region                string `mapstructure:"S3_REGION"`
+	S3AccessKey             string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey             string `mapstructure:"S3_SECRET_KEY"`
+	StoreTimezone           string `mapstructure:"STORE_TIMEZONE"`
+	BusinessDayCutoff       string `mapstructure:"BUSINESS_DAY_CUTOFF"`
+	TaxRatePercent          int    `mapstructure:"TAX_RATE_PERCENT"`
+	SalesRollupInterval     int    `mapstructure:"SALES_ROLLUP_INTERVAL"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -15,6 +56,44 @@ func LoadConfig() (*Config, error) {
 	viper.AutomaticEnv()
 
 	viper.SetDefault("PORT", "5001")
+	viper.SetDefault("POINTS_EARN_UNIT", 1000)
+	viper.SetDefault("POINT_VALUE", 10)
+	viper.SetDefault("STORE_NAME", "Kasir API")
+	viper.SetDefault("STORE_CODE", "MAIN")
+	viper.SetDefault("INVOICE_FORMAT", "INV/{STORE}/{YYYY}/{MM}/{DD}/{SEQ}")
+	viper.SetDefault("INVOICE_DIGITS", 4)
+	viper.SetDefault("OVERRIDE_APPROVAL_PERCENT", 10)
+	viper.SetDefault("MULTI_TENANT", false)
+	viper.SetDefault("TENANT_BASE_DOMAIN", "")
+	viper.SetDefault("TENANT_POOL_MAX_CONNS", 5)
+	viper.SetDefault("TENANT_POOL_IDLE_TIMEOUT", 600)
+	viper.SetDefault("ADMIN_TOKEN", "")
+	viper.SetDefault("PIN_LOOKUP_KEY", "")
+	viper.SetDefault("LOW_STOCK_ALERTS", "log")
+	viper.SetDefault("LOW_STOCK_WEBHOOK_URL", "")
+	viper.SetDefault("SMTP_ADDR", "localhost:25")
+	viper.SetDefault("ALERT_EMAIL_FROM", "kasir@localhost")
+	viper.SetDefault("ALERT_EMAIL_TO", "")
+	viper.SetDefault("REORDER_VELOCITY_DAYS", 30)
+	viper.SetDefault("REORDER_COVER_DAYS", 14)
+	viper.SetDefault("RUPIAH_ROUNDING", "half_up")
+	viper.SetDefault("SCALE_WEIGHT_PREFIXES", "20,21,22,23,24")
+	viper.SetDefault("SCALE_PRICE_PREFIXES", "25,26,27,28,29")
+	viper.SetDefault("SCALE_PLU_DIGITS", 5)
+	viper.SetDefault("PRICE_SCHEDULE_INTERVAL", 60)
+	viper.SetDefault("IMAGE_STORAGE", "local")
+	viper.SetDefault("IMAGE_DIR", "uploads")
+	viper.SetDefault("IMAGE_MAX_BYTES", 5<<20)
+	viper.SetDefault("THUMBNAIL_SIZE", 256)
+	viper.SetDefault("S3_ENDPOINT", "")
+	viper.SetDefault("S3_BUCKET", "")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_ACCESS_KEY", "")
+	viper.SetDefault("S3_SECRET_KEY", "")
+	viper.SetDefault("STORE_TIMEZONE", "Asia/Jakarta")
+	viper.SetDefault("BUSINESS_DAY_CUTOFF", "00:00")
+	viper.SetDefault("TAX_RATE_PERCENT", 0)
+	viper.SetDefault("SALES_ROLLUP_INTERVAL", 3600)
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
@@ -24,6 +103,39 @@ func LoadConfig() (*Config, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
+
+// Validate rejects settings the server cannot run with.
+func (c *Config) Validate() error {
+	if !strings.Contains(c.InvoiceFormat, "{SEQ}") {
+		return fmt.Errorf("INVOICE_FORMAT must contain {SEQ}")
+	}
+	// A day or month without what it belongs to comes round again, and the
+	// invoice numbers with it.
+	hasYear := strings.Contains(c.InvoiceFormat, "{YYYY}") || strings.Contains(c.InvoiceFormat, "{YY}")
+	hasMonth := strings.Contains(c.InvoiceFormat, "{MM}")
+	if strings.Contains(c.InvoiceFormat, "{DD}") && (!hasMonth || !hasYear) {
+		return fmt.Errorf("INVOICE_FORMAT with {DD} must also contain {MM} and {YYYY} or {YY}")
+	}
+	if hasMonth && !hasYear {
+		return fmt.Errorf("INVOICE_FORMAT with {MM} must also contain {YYYY} or {YY}")
+	}
+	// Without a key the lookup is a plain hash a PIN is read back from.
+	if len(c.PINLookupKey) < 16 {
+		return fmt.Errorf("PIN_LOOKUP_KEY must be a secret of at least 16 characters")
+	}
+	if c.MultiTenant && c.TenantPoolMaxConns < 1 {
+		return fmt.Errorf("TENANT_POOL_MAX_CONNS must be at least 1")
+	}
+	switch c.RupiahRounding {
+	case "half_up", "half_even", "down", "up":
+	default:
+		return fmt.Errorf("RUPIAH_ROUNDING must be half_up, half_even, down or up, got %q", c.RupiahRounding)
+	}
+	return nil
+}
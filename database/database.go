@@ -3,6 +3,8 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -24,3 +26,24 @@ func InitDB(connStr string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// WithTimeZone returns connStr with the session TimeZone set to timezone, so
+// CURRENT_DATE, DATE() and the timestamps the driver returns follow the store
+// rather than the database server.
+func WithTimeZone(connStr, timezone string) string {
+	if timezone == "" {
+		return connStr
+	}
+
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err == nil {
+			q := u.Query()
+			q.Set("timezone", timezone)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+
+	return fmt.Sprintf("%s timezone='%s'", connStr, timezone)
+}
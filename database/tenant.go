@@ -0,0 +1,142 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultTenantID = 1
+
+// WithTenant returns connStr with the app.tenant_id session setting that the
+// row level security policies in init.sql filter on.
+func WithTenant(connStr string, tenantID int) string {
+	setting := fmt.Sprintf("-c app.tenant_id=%d", tenantID)
+
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err == nil {
+			q := u.Query()
+			if existing := q.Get("options"); existing != "" {
+				setting = existing + " " + setting
+			}
+			q.Set("options", setting)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+
+	return fmt.Sprintf("%s options='%s'", connStr, setting)
+}
+
+// TenantPool keeps a connection pool per tenant. Each holds at most maxConns
+// connections and drops those idle for idleTimeout, and a tenant's pool is
+// closed once the tenant has made no request for idleTimeout, so tenants that
+// stop using the API hold nothing open.
+type TenantPool struct {
+	connStr     string
+	maxConns    int
+	idleTimeout time.Duration
+	onEvict     func(tenantID int)
+
+	mu  sync.Mutex
+	dbs map[int]*tenantDB
+}
+
+type tenantDB struct {
+	db       *sql.DB
+	lastUsed time.Time
+}
+
+func NewTenantPool(connStr string, maxConns int, idleTimeout time.Duration) *TenantPool {
+	return &TenantPool{connStr: connStr, maxConns: maxConns, idleTimeout: idleTimeout, dbs: make(map[int]*tenantDB)}
+}
+
+// OnEvict sets fn to be called with each tenant whose pool is closed for
+// being idle, so whatever was built on it can be let go as well.
+func (p *TenantPool) OnEvict(fn func(tenantID int)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onEvict = fn
+}
+
+// Get returns the tenant's pool, opening it if needed, and marks it used.
+// Pools of tenants idle for longer than idleTimeout are closed on the way.
+func (p *TenantPool) Get(tenantID int) (*sql.DB, error) {
+	p.mu.Lock()
+	now := time.Now()
+	evicted := p.evictIdle(now)
+	onEvict := p.onEvict
+	db, err := p.get(tenantID, now)
+	p.mu.Unlock()
+
+	if onEvict != nil {
+		for _, id := range evicted {
+			onEvict(id)
+		}
+	}
+	return db, err
+}
+
+// Lookup returns the tenant's pool if it is open, without marking it used, for
+// background jobs that should not keep idle tenants connected.
+func (p *TenantPool) Lookup(tenantID int) (*sql.DB, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, ok := p.dbs[tenantID]
+	if !ok {
+		return nil, false
+	}
+	return t.db, true
+}
+
+func (p *TenantPool) get(tenantID int, now time.Time) (*sql.DB, error) {
+	if t, ok := p.dbs[tenantID]; ok {
+		t.lastUsed = now
+		return t.db, nil
+	}
+
+	db, err := InitDB(WithTenant(p.connStr, tenantID))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(p.maxConns)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxIdleTime(p.idleTimeout)
+
+	p.dbs[tenantID] = &tenantDB{db: db, lastUsed: now}
+	return db, nil
+}
+
+// evictIdle closes the pools not used since idleTimeout before now that have
+// no query running, and returns their tenants.
+func (p *TenantPool) evictIdle(now time.Time) []int {
+	if p.idleTimeout <= 0 {
+		return nil
+	}
+
+	var evicted []int
+	for id, t := range p.dbs {
+		if now.Sub(t.lastUsed) < p.idleTimeout || t.db.Stats().InUse > 0 {
+			continue
+		}
+		t.db.Close()
+		delete(p.dbs, id)
+		evicted = append(evicted, id)
+	}
+	return evicted
+}
+
+func (p *TenantPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, t := range p.dbs {
+		t.db.Close()
+		delete(p.dbs, id)
+	}
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	w       *csv.Writer
+	table   Table
+	started bool
+}
+
+func newCSVWriter(w io.Writer, table Table) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), table: table}
+}
+
+func (c *csvWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+
+	header := make([]string, len(c.table.Columns))
+	for i, col := range c.table.Columns {
+		header[i] = col.Name
+	}
+	return c.w.Write(header)
+}
+
+func (c *csvWriter) Row(values ...any) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i], _ = cell(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
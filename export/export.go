@@ -0,0 +1,112 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+var Formats = []string{FormatCSV, FormatXLSX, FormatPDF}
+
+// Column is one column of an exported table. Width is a hint in characters
+// used to lay out XLSX and PDF output; zero picks a default.
+type Column struct {
+	Name  string
+	Width int
+}
+
+// Table describes an export. Title and Notes only appear in formats meant
+// for people (the PDF title block and the XLSX sheet name); CSV carries just
+// the column header and rows.
+type Table struct {
+	Title   string
+	Notes   []string
+	Columns []Column
+}
+
+// Writer streams the rows of a table. Values may be strings, integers,
+// floats, times, time pointers or nil. Nothing reaches the underlying writer
+// before the first Row or Close, so a caller can still answer with an error
+// if producing the first row fails.
+type Writer interface {
+	Row(values ...any) error
+	Close() error
+}
+
+func New(format string, w io.Writer, table Table) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, table), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, table), nil
+	case FormatPDF:
+		return newPDFWriter(w, table), nil
+	default:
+		return nil, fmt.Errorf("format must be one of %s", strings.Join(Formats, ", "))
+	}
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+const defaultColumnWidth = 12
+
+func (c Column) width() int {
+	if c.Width > 0 {
+		return c.Width
+	}
+	return max(defaultColumnWidth, len(c.Name)+2)
+}
+
+// cell formats a value for output, reporting whether it is a number.
+func cell(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, false
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05"), false
+	case *time.Time:
+		if v == nil {
+			return "", false
+		}
+		return v.Format("2006-01-02 15:04:05"), false
+	case *int:
+		if v == nil {
+			return "", false
+		}
+		return strconv.Itoa(*v), true
+	case *float64:
+		if v == nil {
+			return "", false
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(v), false
+	}
+}
@@ -0,0 +1,254 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// A4 portrait in points, typeset in Courier so columns line up by character
+// count without font metrics.
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 36.0
+	pdfFontSize   = 8.0
+	pdfLineHeight = 11.0
+	pdfCharWidth  = 0.6 * pdfFontSize
+	pdfTitleSize  = 13.0
+)
+
+// Object numbers fixed up front; pages and their content streams follow.
+const (
+	pdfCatalogID = 1
+	pdfPagesID   = 2
+	pdfFontID    = 3
+	pdfBoldID    = 4
+)
+
+// pdfWriter lays the table out over as many pages as it needs. Each page is
+// written out as soon as it is full, so only the current page and the object
+// offsets for the cross-reference table are kept in memory.
+type pdfWriter struct {
+	out     *countingWriter
+	table   Table
+	widths  []int
+	offsets map[int]int64
+	nextID  int
+	pages   []int
+	page    bytes.Buffer
+	y       float64
+	started bool
+}
+
+func newPDFWriter(w io.Writer, table Table) *pdfWriter {
+	return &pdfWriter{
+		out:     &countingWriter{w: w},
+		table:   table,
+		widths:  fitWidths(table.Columns, pdfPageWidth-2*pdfMargin),
+		offsets: map[int]int64{},
+		nextID:  pdfBoldID + 1,
+	}
+}
+
+// fitWidths scales the columns' width hints, in characters, down to fit a
+// line lineWidth points wide, keeping a one character gap between columns.
+func fitWidths(columns []Column, lineWidth float64) []int {
+	available := int(lineWidth / pdfCharWidth)
+	widths := make([]int, len(columns))
+	total := 0
+	for i, col := range columns {
+		widths[i] = col.width()
+		total += widths[i] + 1
+	}
+	if total <= available {
+		return widths
+	}
+	for i := range widths {
+		widths[i] = max(4, widths[i]*available/total)
+	}
+	return widths
+}
+
+func (p *pdfWriter) start() error {
+	if p.started {
+		return nil
+	}
+	p.started = true
+
+	if _, err := io.WriteString(p.out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"); err != nil {
+		return err
+	}
+	if err := p.object(pdfFontID, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"); err != nil {
+		return err
+	}
+	if err := p.object(pdfBoldID, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>"); err != nil {
+		return err
+	}
+	p.newPage()
+	return nil
+}
+
+func (p *pdfWriter) object(id int, body string) error {
+	p.offsets[id] = p.out.n
+	_, err := fmt.Fprintf(p.out, "%d 0 obj\n%s\nendobj\n", id, body)
+	return err
+}
+
+func (p *pdfWriter) newPage() {
+	p.page.Reset()
+	p.y = pdfPageHeight - pdfMargin
+
+	if len(p.pages) == 0 {
+		p.y -= pdfTitleSize
+		p.text(pdfBoldID, pdfTitleSize, pdfMargin, p.y, p.table.Title)
+		p.y -= pdfLineHeight / 2
+		for _, note := range p.table.Notes {
+			p.y -= pdfLineHeight
+			p.text(pdfFontID, pdfFontSize, pdfMargin, p.y, note)
+		}
+		p.y -= pdfLineHeight
+	}
+
+	header := make([]any, len(p.table.Columns))
+	for i, col := range p.table.Columns {
+		header[i] = col.Name
+	}
+	p.line(pdfBoldID, header)
+	fmt.Fprintf(&p.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, p.y-3, pdfPageWidth-pdfMargin, p.y-3)
+	p.y -= 3
+}
+
+// line writes one table row, right-aligning numbers within their column.
+func (p *pdfWriter) line(font int, values []any) {
+	p.y -= pdfLineHeight
+	x := pdfMargin
+	for i, width := range p.widths {
+		var text string
+		numeric := false
+		if i < len(values) {
+			text, numeric = cell(values[i])
+		}
+		if utf8.RuneCountInString(text) > width {
+			text = string([]rune(text)[:width-1]) + "~"
+		}
+		offset := 0.0
+		if numeric {
+			offset = float64(width-utf8.RuneCountInString(text)) * pdfCharWidth
+		}
+		if text != "" {
+			p.text(font, pdfFontSize, x+offset, p.y, text)
+		}
+		x += float64(width+1) * pdfCharWidth
+	}
+}
+
+func (p *pdfWriter) text(font int, size, x, y float64, s string) {
+	fmt.Fprintf(&p.page, "BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+func (p *pdfWriter) Row(values ...any) error {
+	if err := p.start(); err != nil {
+		return err
+	}
+	if p.y-pdfLineHeight < pdfMargin+pdfLineHeight {
+		if err := p.flushPage(); err != nil {
+			return err
+		}
+		p.newPage()
+	}
+	p.line(pdfFontID, values)
+	return nil
+}
+
+func (p *pdfWriter) flushPage() error {
+	p.text(pdfFontID, pdfFontSize, pdfMargin, pdfMargin/2, fmt.Sprintf("Page %d", len(p.pages)+1))
+
+	contentID := p.nextID
+	pageID := p.nextID + 1
+	p.nextID += 2
+
+	p.offsets[contentID] = p.out.n
+	if _, err := fmt.Fprintf(p.out, "%d 0 obj\n<< /Length %d >>\nstream\n", contentID, p.page.Len()); err != nil {
+		return err
+	}
+	if _, err := p.page.WriteTo(p.out); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(p.out, "endstream\nendobj\n"); err != nil {
+		return err
+	}
+
+	err := p.object(pageID, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F%d %d 0 R /F%d %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesID, pdfPageWidth, pdfPageHeight, pdfFontID, pdfFontID, pdfBoldID, pdfBoldID, contentID,
+	))
+	if err != nil {
+		return err
+	}
+	p.pages = append(p.pages, pageID)
+	return nil
+}
+
+func (p *pdfWriter) Close() error {
+	if err := p.start(); err != nil {
+		return err
+	}
+	if err := p.flushPage(); err != nil {
+		return err
+	}
+
+	kids := make([]string, len(p.pages))
+	for i, id := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	if err := p.object(pdfPagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages))); err != nil {
+		return err
+	}
+	if err := p.object(pdfCatalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesID)); err != nil {
+		return err
+	}
+
+	xref := p.out.n
+	var b strings.Builder
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", p.nextID)
+	for id := 1; id < p.nextID; id++ {
+		fmt.Fprintf(&b, "%010d 00000 n \n", p.offsets[id])
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.nextID, pdfCatalogID, xref)
+	_, err := io.WriteString(p.out, b.String())
+	return err
+}
+
+// pdfString encodes s for a literal string in a WinAnsi font. Characters
+// outside Latin-1 have no glyph there and print as '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CustomerHandler struct {
+	service           *services.CustomerService
+	receivableService *services.ReceivableService
+}
+
+func NewCustomerHandler(service *services.CustomerService, receivableService *services.ReceivableService) *CustomerHandler {
+	return &CustomerHandler{service: service, receivableService: receivableService}
+}
+
+func (h *CustomerHandler) HandleCustomers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getAll(w, r)
+	case http.MethodPost:
+		h.create(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *CustomerHandler) HandleCustomer(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/customers/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	if len(parts) == 2 {
+		switch {
+		case parts[1] == "transactions" && r.Method == http.MethodGet:
+			h.getTransactions(w, r, id)
+		case parts[1] == "points" && r.Method == http.MethodGet:
+			h.getPointsLedger(w, r, id)
+		case parts[1] == "receivables" && r.Method == http.MethodGet:
+			h.getReceivables(w, r, id)
+		case parts[1] == "repayments" && r.Method == http.MethodPost:
+			h.repay(w, r, id)
+		case parts[1] == "transactions", parts[1] == "points", parts[1] == "receivables", parts[1] == "repayments":
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		default:
+			writeError(w, http.StatusNotFound, "Customer endpoint not found")
+		}
+		return
+	}
+	if len(parts) > 2 {
+		writeError(w, http.StatusNotFound, "Customer endpoint not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getByID(w, r, id)
+	case http.MethodPut:
+		h.update(w, r, id)
+	case http.MethodDelete:
+		h.delete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *CustomerHandler) HandleTiers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	tiers, err := h.service.GetTiers()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tiers)
+}
+
+func (h *CustomerHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	search := r.URL.Query().Get("search")
+
+	customers, err := h.service.GetAll(search)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customers)
+}
+
+func (h *CustomerHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	customer, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if customer == nil {
+		writeError(w, http.StatusNotFound, "Customer not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) create(w http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+	if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if customer.Name == "" {
+		writeError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	if err := h.service.Create(&customer); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, customer)
+}
+
+func (h *CustomerHandler) update(w http.ResponseWriter, r *http.Request, id int) {
+	var customer models.Customer
+	if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	customer.ID = id
+	if err := h.service.Update(&customer); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Customer not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.Delete(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Customer not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Customer deleted successfully"})
+}
+
+func (h *CustomerHandler) getTransactions(w http.ResponseWriter, r *http.Request, id int) {
+	transactions, err := h.service.GetTransactions(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transactions)
+}
+
+func (h *CustomerHandler) getPointsLedger(w http.ResponseWriter, r *http.Request, id int) {
+	entries, err := h.service.GetPointsLedger(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (h *CustomerHandler) getReceivables(w http.ResponseWriter, r *http.Request, id int) {
+	openOnly := r.URL.Query().Get("status") == "open"
+
+	receivables, err := h.receivableService.GetAll(&id, openOnly)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, receivables)
+}
+
+func (h *CustomerHandler) repay(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.RepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	payments, err := h.receivableService.RepayCustomer(id, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, payments)
+}
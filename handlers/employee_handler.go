@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type EmployeeHandler struct {
+	service *services.EmployeeService
+}
+
+func NewEmployeeHandler(service *services.EmployeeService) *EmployeeHandler {
+	return &EmployeeHandler{service: service}
+}
+
+func (h *EmployeeHandler) HandleEmployees(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getAll(w, r)
+	case http.MethodPost:
+		h.create(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *EmployeeHandler) HandleEmployee(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/employees/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getByID(w, r, id)
+	case http.MethodPut:
+		h.update(w, r, id)
+	case http.MethodDelete:
+		h.delete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *EmployeeHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	employees, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, employees)
+}
+
+func (h *EmployeeHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	employee, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if employee == nil {
+		writeError(w, http.StatusNotFound, "Employee not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, employee)
+}
+
+func (h *EmployeeHandler) create(w http.ResponseWriter, r *http.Request) {
+	var employee models.Employee
+	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.Create(&employee); err != nil {
+		writeEmployeeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, employee)
+}
+
+func (h *EmployeeHandler) update(w http.ResponseWriter, r *http.Request, id int) {
+	var employee models.Employee
+	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	employee.ID = id
+	if err := h.service.Update(&employee); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Employee not found")
+			return
+		}
+		writeEmployeeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, employee)
+}
+
+func (h *EmployeeHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	var req struct {
+		ManagerPIN string `json:"manager_pin"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.Delete(id, req.ManagerPIN); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Employee not found")
+			return
+		}
+		if strings.Contains(err.Error(), "manager PIN") {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Employee deactivated successfully"})
+}
+
+// writeEmployeeError answers a missing or wrong manager PIN with 403 and
+// anything else with 400.
+func writeEmployeeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "manager PIN") {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type InventoryHandler struct {
+	service *services.InventoryService
+}
+
+func NewInventoryHandler(service *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service: service}
+}
+
+func (h *InventoryHandler) HandleInventory(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/inventory/")
+	if r.Method == http.MethodPost {
+		switch path {
+		case "batches":
+			h.receiveBatch(w, r)
+		case "receipts", "stocktakes":
+			h.changeStock(w, r, path)
+		default:
+			writeError(w, http.StatusNotFound, "Inventory endpoint not found")
+		}
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	storeID, err := optionalIntParam(r, "store_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid store_id")
+		return
+	}
+
+	switch path {
+	case "low-stock", "reorder-suggestions":
+		h.getStockLevels(w, path, storeID)
+	case "batches":
+		h.getBatches(w, r, storeID)
+	case "expiring":
+		h.getExpiring(w, r, storeID)
+	default:
+		writeError(w, http.StatusNotFound, "Inventory endpoint not found")
+	}
+}
+
+func (h *InventoryHandler) getStockLevels(w http.ResponseWriter, path string, storeID *int) {
+	var items []models.LowStockItem
+	var err error
+	if path == "low-stock" {
+		items, err = h.service.GetLowStock(storeID)
+	} else {
+		items, err = h.service.GetReorderSuggestions(storeID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (h *InventoryHandler) getBatches(w http.ResponseWriter, r *http.Request, storeID *int) {
+	productID, err := optionalIntParam(r, "product_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product_id")
+		return
+	}
+
+	batches, err := h.service.GetBatches(productID, storeID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, batches)
+}
+
+func (h *InventoryHandler) getExpiring(w http.ResponseWriter, r *http.Request, storeID *int) {
+	days, err := optionalIntParam(r, "days")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid days")
+		return
+	}
+
+	n := 7
+	if days != nil {
+		n = *days
+	}
+
+	batches, err := h.service.GetExpiring(storeID, n)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, batches)
+}
+
+func (h *InventoryHandler) receiveBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.ReceiveBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	batch, err := h.service.ReceiveBatch(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, batch)
+}
+
+func (h *InventoryHandler) changeStock(w http.ResponseWriter, r *http.Request, path string) {
+	var req models.StockChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var change *models.StockChange
+	var err error
+	if path == "receipts" {
+		change, err = h.service.ReceiveStock(&req)
+	} else {
+		change, err = h.service.CountStock(&req)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, change)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PriceListHandler struct {
+	service *services.PriceListService
+}
+
+func NewPriceListHandler(service *services.PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+func (h *PriceListHandler) HandlePriceLists(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getAll(w, r)
+	case http.MethodPost:
+		h.create(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *PriceListHandler) HandlePriceList(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/price-lists/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	if len(parts) > 1 {
+		if parts[1] != "items" || len(parts) > 2 {
+			writeError(w, http.StatusNotFound, "Price list endpoint not found")
+			return
+		}
+		if r.Method != http.MethodPut {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.setItems(w, r, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getByID(w, r, id)
+	case http.MethodPut:
+		h.update(w, r, id)
+	case http.MethodDelete:
+		h.delete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *PriceListHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lists)
+}
+
+func (h *PriceListHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	list, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if list == nil {
+		writeError(w, http.StatusNotFound, "Price list not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *PriceListHandler) create(w http.ResponseWriter, r *http.Request) {
+	var list models.PriceList
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.Create(&list); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, list)
+}
+
+func (h *PriceListHandler) update(w http.ResponseWriter, r *http.Request, id int) {
+	var list models.PriceList
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	list.ID = id
+	if err := h.service.Update(&list); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Price list not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *PriceListHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.Delete(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Price list not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Price list deleted successfully"})
+}
+
+func (h *PriceListHandler) setItems(w http.ResponseWriter, r *http.Request, id int) {
+	var items []models.PriceListItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.SetItems(id, items); err != nil {
+		if strings.Contains(err.Error(), "price list not found") {
+			writeError(w, http.StatusNotFound, "Price list not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
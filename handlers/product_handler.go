@@ -3,18 +3,23 @@ package handlers
 import (
 	"andre_kasir_api/models"
 	"andre_kasir_api/services"
+	"andre_kasir_api/storage"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 )
 
 type ProductHandler struct {
 	service *services.ProductService
+	images  *services.ProductImageService
 }
 
-func NewProductHandler(service *services.ProductService) *ProductHandler {
-	return &ProductHandler{service: service}
+func NewProductHandler(service *services.ProductService, images *services.ProductImageService) *ProductHandler {
+	return &ProductHandler{service: service, images: images}
 }
 
 func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request) {
@@ -29,13 +34,29 @@ func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *ProductHandler) HandleProduct(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/produk/")
-	id, err := strconv.Atoi(idStr)
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/produk/"), "/")
+	id, err := strconv.Atoi(parts[0])
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
+	if len(parts) > 1 {
+		switch {
+		case len(parts) > 3:
+			writeError(w, http.StatusNotFound, "Product endpoint not found")
+		case parts[1] == "units":
+			h.handleUnits(w, r, id, parts[2:])
+		case parts[1] == "prices":
+			h.handlePrices(w, r, id, parts[2:])
+		case parts[1] == "image":
+			h.handleImage(w, r, id, parts[2:])
+		default:
+			writeError(w, http.StatusNotFound, "Product endpoint not found")
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getByID(w, r, id)
@@ -91,14 +112,25 @@ func (h *ProductHandler) create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProductHandler) update(w http.ResponseWriter, r *http.Request, id int) {
-	var product models.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+	product, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if product == nil {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	// Fields the body leaves out keep their stored values, so clients that
+	// predate a field do not reset it.
+	if err := json.NewDecoder(r.Body).Decode(product); err != nil {
 		writeError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	product.ID = id
-	if err := h.service.Update(&product); err != nil {
+	if err := h.service.Update(product); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			writeError(w, http.StatusNotFound, "Product not found")
 			return
@@ -111,6 +143,12 @@ func (h *ProductHandler) update(w http.ResponseWriter, r *http.Request, id int)
 }
 
 func (h *ProductHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	img, err := h.images.Get(id)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	if err := h.service.Delete(id); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			writeError(w, http.StatusNotFound, "Product not found")
@@ -120,9 +158,260 @@ func (h *ProductHandler) delete(w http.ResponseWriter, r *http.Request, id int)
 		return
 	}
 
+	h.images.RemoveStored(img)
 	writeJSON(w, http.StatusOK, map[string]string{"message": "Product deleted successfully"})
 }
 
+func (h *ProductHandler) handleUnits(w http.ResponseWriter, r *http.Request, productID int, rest []string) {
+	if len(rest) == 0 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.getUnits(w, r, productID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.setUnit(w, r, productID, rest[0])
+	case http.MethodDelete:
+		h.removeUnit(w, r, productID, rest[0])
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *ProductHandler) getUnits(w http.ResponseWriter, r *http.Request, productID int) {
+	units, err := h.service.GetUnits(productID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, units)
+}
+
+func (h *ProductHandler) setUnit(w http.ResponseWriter, r *http.Request, productID int, name string) {
+	var unit models.ProductUnit
+	if err := json.NewDecoder(r.Body).Decode(&unit); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	unit.ProductID = productID
+	unit.Unit = name
+	if err := h.service.SetUnit(&unit); err != nil {
+		if strings.Contains(err.Error(), "product not found") {
+			writeError(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, unit)
+}
+
+func (h *ProductHandler) removeUnit(w http.ResponseWriter, r *http.Request, productID int, name string) {
+	if err := h.service.RemoveUnit(productID, name); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Product unit not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Product unit removed successfully"})
+}
+
+func (h *ProductHandler) handlePrices(w http.ResponseWriter, r *http.Request, productID int, rest []string) {
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.getPrices(w, r, productID)
+		case http.MethodPost:
+			h.schedulePrice(w, r, productID)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	priceID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid price ID")
+		return
+	}
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	h.cancelPrice(w, r, productID, priceID)
+}
+
+func (h *ProductHandler) getPrices(w http.ResponseWriter, r *http.Request, productID int) {
+	prices, err := h.service.GetPrices(productID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prices)
+}
+
+func (h *ProductHandler) schedulePrice(w http.ResponseWriter, r *http.Request, productID int) {
+	var price models.ProductPrice
+	if err := json.NewDecoder(r.Body).Decode(&price); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	price.ProductID = productID
+	if err := h.service.SchedulePrice(&price); err != nil {
+		if strings.Contains(err.Error(), "product not found") {
+			writeError(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, price)
+}
+
+func (h *ProductHandler) cancelPrice(w http.ResponseWriter, r *http.Request, productID, priceID int) {
+	if err := h.service.CancelPrice(productID, priceID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Scheduled price not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Scheduled price cancelled successfully"})
+}
+
+func (h *ProductHandler) handleImage(w http.ResponseWriter, r *http.Request, productID int, rest []string) {
+	thumbnail := len(rest) == 1 && rest[0] == "thumbnail"
+	if len(rest) > 0 && !thumbnail {
+		writeError(w, http.StatusNotFound, "Product endpoint not found")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet:
+		h.getImage(w, r, productID, thumbnail)
+	case r.Method == http.MethodPut && !thumbnail:
+		h.uploadImage(w, r, productID)
+	case r.Method == http.MethodDelete && !thumbnail:
+		h.deleteImage(w, r, productID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *ProductHandler) getImage(w http.ResponseWriter, r *http.Request, productID int, thumbnail bool) {
+	img, err := h.images.Get(productID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if img == nil {
+		writeError(w, http.StatusNotFound, "Product image not found")
+		return
+	}
+
+	key := img.Key
+	if thumbnail {
+		key = img.ThumbnailKey
+	}
+	etag := `"` + path.Base(key) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("Last-Modified", img.UpdatedAt.UTC().Format(http.TimeFormat))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data, contentType, err := h.images.Read(img, thumbnail)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Product image not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h *ProductHandler) uploadImage(w http.ResponseWriter, r *http.Request, productID int) {
+	maxBytes := int64(h.images.MaxBytes())
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+1<<20)
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid multipart form or image too large")
+		return
+	}
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "image file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read image")
+		return
+	}
+
+	img, err := h.images.Upload(productID, data)
+	if err != nil {
+		if strings.Contains(err.Error(), "product not found") {
+			writeError(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		if strings.Contains(err.Error(), "exceeds") {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, img)
+}
+
+func (h *ProductHandler) deleteImage(w http.ResponseWriter, r *http.Request, productID int) {
+	if err := h.images.Delete(productID); err != nil {
+		if strings.Contains(err.Error(), "product not found") {
+			writeError(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Product image not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Product image removed successfully"})
+}
+
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -135,3 +424,16 @@ func writeError(w http.ResponseWriter, status int, message string) {
 		"code":  status,
 	})
 }
+
+func optionalIntParam(r *http.Request, name string) (*int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
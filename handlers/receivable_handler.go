@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ReceivableHandler struct {
+	service *services.ReceivableService
+}
+
+func NewReceivableHandler(service *services.ReceivableService) *ReceivableHandler {
+	return &ReceivableHandler{service: service}
+}
+
+func (h *ReceivableHandler) HandleReceivables(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var customerID *int
+	if idStr := r.URL.Query().Get("customer_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid customer_id")
+			return
+		}
+		customerID = &id
+	}
+	openOnly := r.URL.Query().Get("status") == "open"
+
+	receivables, err := h.service.GetAll(customerID, openOnly)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, receivables)
+}
+
+func (h *ReceivableHandler) HandleReceivable(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/receivables/")
+	if path == "aging" {
+		h.getAging(w, r)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid receivable ID")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.getByID(w, r, id)
+	case len(parts) == 2 && parts[1] == "payments" && r.Method == http.MethodGet:
+		h.getPayments(w, r, id)
+	case len(parts) == 2 && parts[1] == "payments" && r.Method == http.MethodPost:
+		h.addPayment(w, r, id)
+	case len(parts) > 2 || (len(parts) == 2 && parts[1] != "payments"):
+		writeError(w, http.StatusNotFound, "Receivable endpoint not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *ReceivableHandler) getAging(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	report, err := h.service.GetAging()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *ReceivableHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	receivable, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if receivable == nil {
+		writeError(w, http.StatusNotFound, "Receivable not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, receivable)
+}
+
+func (h *ReceivableHandler) getPayments(w http.ResponseWriter, r *http.Request, id int) {
+	payments, err := h.service.GetPayments(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, payments)
+}
+
+func (h *ReceivableHandler) addPayment(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.RepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	payment, err := h.service.AddPayment(id, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Receivable not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, payment)
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"andre_kasir_api/export"
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// exportFormat reads the format parameter. An empty result means the report
+// is answered as JSON.
+func exportFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" || format == "json" {
+		return "", nil
+	}
+	if !slices.Contains(export.Formats, format) {
+		return "", fmt.Errorf("format must be one of json, %s", strings.Join(export.Formats, ", "))
+	}
+	return format, nil
+}
+
+// exportResponse only sends the download headers with the first byte, so
+// the response is still free for a JSON error until then.
+type exportResponse struct {
+	w        http.ResponseWriter
+	format   string
+	filename string
+	sent     bool
+}
+
+func (e *exportResponse) Write(b []byte) (int, error) {
+	if !e.sent {
+		e.sent = true
+		e.w.Header().Set("Content-Type", export.ContentType(e.format))
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, e.filename, e.format))
+		e.w.WriteHeader(http.StatusOK)
+	}
+	return e.w.Write(b)
+}
+
+// writeExport streams a report as a file download, with rows producing the
+// table through add. If rows fails before anything was sent the client gets
+// the usual JSON error; after that the connection is aborted so a truncated
+// file is not mistaken for a complete one.
+func writeExport(w http.ResponseWriter, format, filename string, table export.Table, rows func(add func(values ...any) error) error) {
+	resp := &exportResponse{w: w, format: format, filename: filename}
+	out, err := export.New(format, resp, table)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = rows(out.Row)
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		if resp.sent {
+			panic(http.ErrAbortHandler)
+		}
+		writeReportError(w, err)
+	}
+}
+
+func reportNotes(storeID *int, notes ...string) []string {
+	if storeID != nil {
+		notes = append(notes, fmt.Sprintf("Store: %d", *storeID))
+	}
+	return append(notes, "Generated: "+time.Now().Format("2006-01-02 15:04"))
+}
+
+func periodNote(startDate, endDate time.Time) string {
+	return fmt.Sprintf("Period: %s to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+}
+
+func exportSalesReport(w http.ResponseWriter, format, filename, title string, storeID *int, report *models.SalesReport, notes ...string) {
+	if report.ProdukTerlaris != nil {
+		notes = append(notes, fmt.Sprintf("Best seller: %s (%s sold)", report.ProdukTerlaris.Nama, formatQuantity(report.ProdukTerlaris.QtyTerjual)))
+	}
+	if c := report.Comparison; c != nil {
+		notes = append(notes, fmt.Sprintf("Compared with %s to %s: revenue %s, transactions %s, average basket %s",
+			c.Previous.StartDate, c.Previous.EndDate, formatDelta(c.Revenue), formatDelta(c.Transactions), formatDelta(c.AverageBasket)))
+	}
+	table := export.Table{
+		Title: title,
+		Notes: reportNotes(storeID, notes...),
+		Columns: []export.Column{
+			{Name: "Store", Width: 30},
+			{Name: "Transactions"},
+			{Name: "Revenue", Width: 14},
+		},
+	}
+
+	writeExport(w, format, filename, table, func(add func(values ...any) error) error {
+		for _, store := range report.PerStore {
+			if err := add(store.StoreName, store.TotalTransaksi, store.TotalRevenue); err != nil {
+				return err
+			}
+		}
+		return add("Total", report.TotalTransaksi, report.TotalRevenue)
+	})
+}
+
+func formatDelta(d models.SalesDelta) string {
+	if d.ChangePercent == nil {
+		return fmt.Sprintf("%+d", d.Change)
+	}
+	return fmt.Sprintf("%+d (%+.1f%%)", d.Change, *d.ChangePercent)
+}
+
+func formatQuantity(q float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", q), "0"), ".")
+}
+
+func (h *ReportHandler) exportBreakdown(w http.ResponseWriter, format string, startDate, endDate time.Time, storeID *int, groupBy string) {
+	table := export.Table{
+		Title: "Sales by " + strings.ReplaceAll(groupBy, "_", " "),
+		Notes: reportNotes(storeID, periodNote(startDate, endDate)),
+		Columns: []export.Column{
+			{Name: "Key"},
+			{Name: "Label", Width: 30},
+			{Name: "Revenue", Width: 14},
+			{Name: "Quantity"},
+			{Name: "Transactions"},
+			{Name: "Average basket", Width: 14},
+		},
+	}
+	filename := fmt.Sprintf("sales-by-%s-%s-%s", groupBy, startDate.Format("20060102"), endDate.Format("20060102"))
+
+	writeExport(w, format, filename, table, func(add func(values ...any) error) error {
+		return h.service.StreamSalesBreakdown(startDate, endDate, storeID, groupBy, func(item models.SalesBreakdownItem) error {
+			return add(item.Key, item.Label, item.Revenue, item.Quantity, item.TransactionCount, item.AverageBasket)
+		})
+	})
+}
+
+func exportRanking(w http.ResponseWriter, format string, storeID *int, ranking *models.ProductRanking) {
+	table := export.Table{
+		Title: fmt.Sprintf("%s products by %s", strings.ToUpper(ranking.Order[:1])+ranking.Order[1:], ranking.By),
+		Notes: reportNotes(storeID, fmt.Sprintf("Period: %s to %s", ranking.StartDate, ranking.EndDate)),
+		Columns: []export.Column{
+			{Name: "Rank", Width: 6},
+			{Name: "Product ID", Width: 10},
+			{Name: "Product", Width: 30},
+			{Name: "Quantity"},
+			{Name: "Revenue", Width: 14},
+			{Name: "Transactions"},
+		},
+	}
+	filename := fmt.Sprintf("products-%s-%s-%s-%s", ranking.Order, ranking.By,
+		strings.ReplaceAll(ranking.StartDate, "-", ""), strings.ReplaceAll(ranking.EndDate, "-", ""))
+
+	writeExport(w, format, filename, table, func(add func(values ...any) error) error {
+		for _, p := range ranking.Products {
+			if err := add(p.Rank, p.ProductID, p.Name, p.Quantity, p.Revenue, p.TransactionCount); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (h *ReportHandler) exportDeadStock(w http.ResponseWriter, format string, days, storeID *int) {
+	report, err := h.service.NewDeadStockReport(days)
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	table := export.Table{
+		Title: "Dead stock",
+		Notes: reportNotes(storeID, fmt.Sprintf("Not sold since: %s (%d days)", report.Since.Format("2006-01-02"), report.Days)),
+		Columns: []export.Column{
+			{Name: "Product ID", Width: 10},
+			{Name: "Product", Width: 30},
+			{Name: "Stock"},
+			{Name: "Price"},
+			{Name: "Stock value", Width: 14},
+			{Name: "Last sold", Width: 19},
+		},
+	}
+	filename := fmt.Sprintf("dead-stock-%dd", report.Days)
+
+	writeExport(w, format, filename, table, func(add func(values ...any) error) error {
+		err := h.service.StreamDeadStock(report, storeID, func(item models.DeadStockItem) error {
+			return add(item.ProductID, item.Name, item.Stock, item.Price, item.StockValue, item.LastSoldAt)
+		})
+		if err != nil {
+			return err
+		}
+		return add(nil, "Total", nil, nil, report.TotalValue, nil)
+	})
+}
+
+func (h *ReportHandler) exportInventoryValuation(w http.ResponseWriter, format string, asOf *time.Time, storeID *int, groupBy string) {
+	valuation, err := h.service.NewInventoryValuation(asOf, storeID, groupBy)
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	table := export.Table{
+		Title: "Inventory valuation by " + valuation.GroupBy,
+		Notes: reportNotes(storeID, "As of: "+valuation.AsOf.Format("2006-01-02 15:04")),
+		Columns: []export.Column{
+			{Name: strings.ToUpper(valuation.GroupBy[:1]) + valuation.GroupBy[1:], Width: 20},
+			{Name: "Product ID", Width: 10},
+			{Name: "Product", Width: 30},
+			{Name: "Quantity"},
+			{Name: "Unit cost"},
+			{Name: "Value", Width: 14},
+		},
+	}
+	filename := "inventory-valuation-" + valuation.AsOf.Format("20060102")
+
+	writeExport(w, format, filename, table, func(add func(values ...any) error) error {
+		var group models.InventoryValuationGroup
+		started := false
+		subtotal := func() error {
+			return add(group.Label, nil, "Subtotal", formatQuantity(group.Quantity), nil, group.Value)
+		}
+
+		err := h.service.StreamInventoryValuation(valuation, func(item models.InventoryValuationItem) error {
+			key, label := services.ValuationGroup(valuation.GroupBy, item)
+			if started && key != group.Key {
+				if err := subtotal(); err != nil {
+					return err
+				}
+			}
+			if !started || key != group.Key {
+				group = models.InventoryValuationGroup{Key: key, Label: label}
+				started = true
+			}
+			group.Quantity += item.Quantity
+			group.Value += item.Value
+			return add(label, item.ProductID, item.Name, formatQuantity(item.Quantity), item.UnitCost, item.Value)
+		})
+		if err != nil {
+			return err
+		}
+		if started {
+			if err := subtotal(); err != nil {
+				return err
+			}
+		}
+		return add(nil, nil, "Total", nil, nil, valuation.TotalValue)
+	})
+}
+
+func exportForecast(w http.ResponseWriter, format string, forecast *models.DemandForecast) {
+	table := export.Table{
+		Title: "Demand forecast",
+		Notes: reportNotes(forecast.StoreID,
+			fmt.Sprintf("Forecast: %s to %s (%d days)", forecast.StartDate, forecast.EndDate, forecast.Days),
+			fmt.Sprintf("History: %d weeks", forecast.HistoryWeeks)),
+		Columns: []export.Column{
+			{Name: "Product ID", Width: 10},
+			{Name: "Product", Width: 30},
+			{Name: "Stock"},
+			{Name: "Moving average", Width: 14},
+			{Name: "Forecast per day", Width: 16},
+			{Name: "Forecast total", Width: 14},
+			{Name: "Days of cover", Width: 13},
+			{Name: "Suggested order", Width: 15},
+		},
+	}
+	filename := "forecast-" + strings.ReplaceAll(forecast.StartDate, "-", "")
+
+	writeExport(w, format, filename, table, func(add func(values ...any) error) error {
+		for _, p := range forecast.Products {
+			if err := add(p.ProductID, p.Name, p.Stock, p.MovingAverage, p.ForecastDaily, p.ForecastTotal, p.DaysOfCover, p.SuggestedQty); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func exportBasket(w http.ResponseWriter, format string, basket *models.BasketAnalysis) {
+	notes := []string{
+		fmt.Sprintf("Period: %s to %s", basket.StartDate, basket.EndDate),
+		fmt.Sprintf("Transactions: %d", basket.Transactions),
+		fmt.Sprintf("Bought together at least: %d times", basket.MinCount),
+	}
+	if basket.ProductID != nil {
+		notes = append(notes, fmt.Sprintf("Product: %d", *basket.ProductID))
+	}
+	table := export.Table{
+		Title: "Products bought together",
+		Notes: reportNotes(basket.StoreID, notes...),
+		Columns: []export.Column{
+			{Name: "Product ID", Width: 10},
+			{Name: "Product", Width: 30},
+			{Name: "With product ID", Width: 15},
+			{Name: "With product", Width: 30},
+			{Name: "Together"},
+			{Name: "Support"},
+			{Name: "Confidence"},
+			{Name: "Reverse confidence", Width: 18},
+			{Name: "Lift"},
+		},
+	}
+	filename := fmt.Sprintf("basket-%s-%s", strings.ReplaceAll(basket.StartDate, "-", ""), strings.ReplaceAll(basket.EndDate, "-", ""))
+
+	writeExport(w, format, filename, table, func(add func(values ...any) error) error {
+		for _, p := range basket.Pairs {
+			if err := add(p.ProductID, p.ProductName, p.WithProductID, p.WithProductName, p.Transactions,
+				p.Support, p.Confidence, p.ReverseConfidence, p.Lift); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"andre_kasir_api/services"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,38 +25,202 @@ func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 
 	path := r.URL.Path
 
+	storeID, err := optionalIntParam(r, "store_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid store_id")
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	if path == "/api/report/hari-ini" {
-		h.getDailyReport(w, r)
+		h.getDailyReport(w, storeID, format)
 		return
 	}
 
-	if path == "/api/report" {
-		startDateStr := r.URL.Query().Get("start_date")
-		endDateStr := r.URL.Query().Get("end_date")
+	if path == "/api/report/breakdown" {
+		startDate, endDate, ok := reportDateRange(w, r)
+		if !ok {
+			return
+		}
 
-		if startDateStr == "" || endDateStr == "" {
-			writeError(w, http.StatusBadRequest, "start_date and end_date are required")
+		if format != "" {
+			h.exportBreakdown(w, format, startDate, endDate, storeID, r.URL.Query().Get("group_by"))
 			return
 		}
 
-		startDate, err := time.Parse("2006-01-02", startDateStr)
+		breakdown, err := h.service.GetSalesBreakdown(startDate, endDate, storeID, r.URL.Query().Get("group_by"))
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "Invalid start_date format (YYYY-MM-DD)")
+			writeReportError(w, err)
 			return
 		}
 
-		endDate, err := time.Parse("2006-01-02", endDateStr)
+		writeJSON(w, http.StatusOK, breakdown)
+		return
+	}
+
+	if path == "/api/report/products" {
+		startDate, endDate, ok := reportDateRange(w, r)
+		if !ok {
+			return
+		}
+		limit, err := optionalIntParam(r, "limit")
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "Invalid end_date format (YYYY-MM-DD)")
+			writeError(w, http.StatusBadRequest, "Invalid limit")
 			return
 		}
 
-		report, err := h.service.GetReportByDateRange(startDate, endDate)
+		ranking, err := h.service.RankProducts(startDate, endDate, storeID, r.URL.Query().Get("by"), r.URL.Query().Get("order"), limit)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, err.Error())
+			writeReportError(w, err)
+			return
+		}
+
+		if format != "" {
+			exportRanking(w, format, storeID, ranking)
+			return
+		}
+		writeJSON(w, http.StatusOK, ranking)
+		return
+	}
+
+	if path == "/api/report/basket" {
+		startDate, endDate, ok := reportDateRange(w, r)
+		if !ok {
+			return
+		}
+		productID, err := optionalIntParam(r, "product_id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid product_id")
+			return
+		}
+		minCount, err := optionalIntParam(r, "min_count")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid min_count")
+			return
+		}
+		limit, err := optionalIntParam(r, "limit")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+
+		basket, err := h.service.GetBasketAnalysis(startDate, endDate, storeID, productID, minCount, limit)
+		if err != nil {
+			writeReportError(w, err)
+			return
+		}
+
+		if format != "" {
+			exportBasket(w, format, basket)
+			return
+		}
+		writeJSON(w, http.StatusOK, basket)
+		return
+	}
+
+	if path == "/api/report/dead-stock" {
+		days, err := optionalIntParam(r, "days")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+
+		if format != "" {
+			h.exportDeadStock(w, format, days, storeID)
+			return
+		}
+
+		report, err := h.service.GetDeadStock(days, storeID)
+		if err != nil {
+			writeReportError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, report)
+		return
+	}
+
+	if path == "/api/report/forecast" {
+		days, err := optionalIntParam(r, "days")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		weeks, err := optionalIntParam(r, "weeks")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid weeks")
+			return
+		}
+		productID, err := optionalIntParam(r, "product_id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid product_id")
+			return
+		}
+
+		forecast, err := h.service.GetDemandForecast(days, weeks, storeID, productID)
+		if err != nil {
+			writeReportError(w, err)
+			return
+		}
+
+		if format != "" {
+			exportForecast(w, format, forecast)
+			return
+		}
+		writeJSON(w, http.StatusOK, forecast)
+		return
+	}
+
+	if path == "/api/report/inventory" {
+		var asOf *time.Time
+		if s := r.URL.Query().Get("as_of"); s != "" {
+			date, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "Invalid as_of format (YYYY-MM-DD)")
+				return
+			}
+			asOf = &date
+		}
+		groupBy := r.URL.Query().Get("group_by")
+
+		if format != "" {
+			h.exportInventoryValuation(w, format, asOf, storeID, groupBy)
+			return
+		}
+
+		valuation, err := h.service.GetInventoryValuation(asOf, storeID, groupBy)
+		if err != nil {
+			writeReportError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, valuation)
+		return
+	}
+
+	if path == "/api/report" {
+		startDate, endDate, ok := reportDateRange(w, r)
+		if !ok {
 			return
 		}
 
+		groupByStore := r.URL.Query().Get("group_by") == "store"
+		report, err := h.service.GetReportByDateRange(startDate, endDate, storeID, groupByStore, r.URL.Query().Get("compare_to"))
+		if err != nil {
+			writeReportError(w, err)
+			return
+		}
+
+		if format != "" {
+			filename := fmt.Sprintf("sales-%s-%s", startDate.Format("20060102"), endDate.Format("20060102"))
+			exportSalesReport(w, format, filename, "Sales report", storeID, report, periodNote(startDate, endDate))
+			return
+		}
 		writeJSON(w, http.StatusOK, report)
 		return
 	}
@@ -61,12 +228,125 @@ func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 	writeError(w, http.StatusNotFound, "Report endpoint not found")
 }
 
-func (h *ReportHandler) getDailyReport(w http.ResponseWriter, r *http.Request) {
-	report, err := h.service.GetDailyReport()
+// HandleRegister serves X reports (/api/report/x) and Z reports
+// (/api/report/z and /api/report/z/{id}).
+func (h *ReportHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	storeID, err := optionalIntParam(r, "store_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid store_id")
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/api/report/x":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		report, err := h.service.GetXReport(storeID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+
+	case path == "/api/report/z" && r.Method == http.MethodGet:
+		limit, err := optionalIntParam(r, "limit")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		reports, err := h.service.GetZReports(storeID, limit)
+		if err != nil {
+			writeReportError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, reports)
+
+	case path == "/api/report/z" && r.Method == http.MethodPost:
+		report, err := h.service.CloseZReport(storeID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, report)
+
+	case path == "/api/report/z":
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+
+	case strings.HasPrefix(path, "/api/report/z/"):
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Z reports cannot be changed")
+			return
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(path, "/api/report/z/"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid Z report ID")
+			return
+		}
+		report, err := h.service.GetZReport(id)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				writeError(w, http.StatusNotFound, "Z report not found")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+
+	default:
+		writeError(w, http.StatusNotFound, "Report endpoint not found")
+	}
+}
+
+// reportDateRange reads the required start_date and end_date parameters,
+// writing a 400 response when either is missing or malformed.
+func reportDateRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	startDateStr := r.URL.Query().Get("start_date")
+	endDateStr := r.URL.Query().Get("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		writeError(w, http.StatusBadRequest, "start_date and end_date are required")
+		return time.Time{}, time.Time{}, false
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid start_date format (YYYY-MM-DD)")
+		return time.Time{}, time.Time{}, false
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid end_date format (YYYY-MM-DD)")
+		return time.Time{}, time.Time{}, false
+	}
+
+	return startDate, endDate, true
+}
+
+// writeReportError answers invalid report parameters with 400 and anything
+// else with 500.
+func writeReportError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "must") {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func (h *ReportHandler) getDailyReport(w http.ResponseWriter, storeID *int, format string) {
+	report, err := h.service.GetDailyReport(storeID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if format != "" {
+		exportSalesReport(w, format, "sales-today", "Sales report - today", storeID, report)
+		return
+	}
 	writeJSON(w, http.StatusOK, report)
 }
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StoreHandler struct {
+	service *services.StoreService
+}
+
+func NewStoreHandler(service *services.StoreService) *StoreHandler {
+	return &StoreHandler{service: service}
+}
+
+func (h *StoreHandler) HandleStores(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getAll(w, r)
+	case http.MethodPost:
+		h.create(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *StoreHandler) HandleStore(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/stores/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid store ID")
+		return
+	}
+
+	if len(parts) > 1 {
+		if parts[1] != "products" || len(parts) > 3 {
+			writeError(w, http.StatusNotFound, "Store endpoint not found")
+			return
+		}
+		h.handleProducts(w, r, id, parts[2:])
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getByID(w, r, id)
+	case http.MethodPut:
+		h.update(w, r, id)
+	case http.MethodDelete:
+		h.delete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *StoreHandler) handleProducts(w http.ResponseWriter, r *http.Request, storeID int, rest []string) {
+	if len(rest) == 0 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.getProducts(w, r, storeID)
+		return
+	}
+
+	productID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.setProduct(w, r, storeID, productID)
+	case http.MethodDelete:
+		h.removeProduct(w, r, storeID, productID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *StoreHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	stores, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stores)
+}
+
+func (h *StoreHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	store, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if store == nil {
+		writeError(w, http.StatusNotFound, "Store not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, store)
+}
+
+func (h *StoreHandler) create(w http.ResponseWriter, r *http.Request) {
+	var store models.Store
+	if err := json.NewDecoder(r.Body).Decode(&store); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.Create(&store); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, store)
+}
+
+func (h *StoreHandler) update(w http.ResponseWriter, r *http.Request, id int) {
+	var store models.Store
+	if err := json.NewDecoder(r.Body).Decode(&store); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	store.ID = id
+	if err := h.service.Update(&store); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Store not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, store)
+}
+
+func (h *StoreHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.Delete(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Store not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Store deactivated successfully"})
+}
+
+func (h *StoreHandler) getProducts(w http.ResponseWriter, r *http.Request, storeID int) {
+	products, err := h.service.GetProducts(storeID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, products)
+}
+
+func (h *StoreHandler) setProduct(w http.ResponseWriter, r *http.Request, storeID, productID int) {
+	var product models.StoreProduct
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	product.StoreID = storeID
+	product.ProductID = productID
+	if err := h.service.SetProduct(&product); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (h *StoreHandler) removeProduct(w http.ResponseWriter, r *http.Request, storeID, productID int) {
+	if err := h.service.RemoveProduct(storeID, productID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Store product not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Product removed from store successfully"})
+}
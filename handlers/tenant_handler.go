@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type TenantHandler struct {
+	service    *services.TenantService
+	adminToken string
+}
+
+func NewTenantHandler(service *services.TenantService, adminToken string) *TenantHandler {
+	return &TenantHandler{service: service, adminToken: adminToken}
+}
+
+func (h *TenantHandler) HandleTenants(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getAll(w, r)
+	case http.MethodPost:
+		h.provision(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *TenantHandler) HandleTenant(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/tenants/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid tenant ID")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.getByID(w, r, id)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		h.deactivate(w, r, id)
+	case len(parts) == 2 && parts[1] == "token" && r.Method == http.MethodPost:
+		h.rotateToken(w, r, id)
+	case len(parts) > 2 || (len(parts) == 2 && parts[1] != "token"):
+		writeError(w, http.StatusNotFound, "Tenant endpoint not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *TenantHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if h.adminToken == "" {
+		writeError(w, http.StatusForbidden, "Tenant administration is disabled")
+		return false
+	}
+
+	token := r.Header.Get("X-Admin-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		writeError(w, http.StatusUnauthorized, "Invalid admin token")
+		return false
+	}
+
+	return true
+}
+
+func (h *TenantHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tenants)
+}
+
+func (h *TenantHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	tenant, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if tenant == nil {
+		writeError(w, http.StatusNotFound, "Tenant not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tenant)
+}
+
+func (h *TenantHandler) provision(w http.ResponseWriter, r *http.Request) {
+	var req models.ProvisionTenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	creds, err := h.service.Provision(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, creds)
+}
+
+func (h *TenantHandler) rotateToken(w http.ResponseWriter, r *http.Request, id int) {
+	creds, err := h.service.RotateToken(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Tenant not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, creds)
+}
+
+func (h *TenantHandler) deactivate(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.service.SetActive(id, false); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Tenant not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Tenant deactivated successfully"})
+}
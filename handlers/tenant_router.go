@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"andre_kasir_api/services"
+	"database/sql"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TenantRouter resolves the tenant of each request and hands it to that
+// tenant's own handler tree, built by build on the database open returns and
+// kept for as long as open keeps returning the same one.
+type TenantRouter struct {
+	service    *services.TenantService
+	baseDomain string
+	open       func(tenantID int) (*sql.DB, error)
+	build      func(db *sql.DB) http.Handler
+
+	mu       sync.Mutex
+	handlers map[int]tenantHandler
+}
+
+type tenantHandler struct {
+	db      *sql.DB
+	handler http.Handler
+}
+
+func NewTenantRouter(service *services.TenantService, baseDomain string, open func(tenantID int) (*sql.DB, error), build func(db *sql.DB) http.Handler) *TenantRouter {
+	return &TenantRouter{
+		service:    service,
+		baseDomain: baseDomain,
+		open:       open,
+		build:      build,
+		handlers:   make(map[int]tenantHandler),
+	}
+}
+
+// Forget drops the tenant's handler tree, once its database is closed.
+func (t *TenantRouter) Forget(tenantID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.handlers, tenantID)
+}
+
+func (t *TenantRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenant, err := t.service.Resolve(tenantToken(r), r.Host, t.baseDomain)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "required"), strings.Contains(msg, "invalid tenant token"):
+			writeError(w, http.StatusUnauthorized, msg)
+		case strings.Contains(msg, "not found"):
+			writeError(w, http.StatusNotFound, msg)
+		case strings.Contains(msg, "inactive"), strings.Contains(msg, "does not match"):
+			writeError(w, http.StatusForbidden, msg)
+		default:
+			writeError(w, http.StatusInternalServerError, msg)
+		}
+		return
+	}
+
+	db, err := t.open(tenant.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	t.handler(tenant.ID, db).ServeHTTP(w, r)
+}
+
+func (t *TenantRouter) handler(tenantID int, db *sql.DB) http.Handler {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if h, ok := t.handlers[tenantID]; ok && h.db == db {
+		return h.handler
+	}
+
+	h := tenantHandler{db: db, handler: t.build(db)}
+	t.handlers[tenantID] = h
+	return h.handler
+}
+
+func tenantToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return r.Header.Get("X-Tenant-Token")
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TransactionHandler struct {
+	service *services.TransactionService
+}
+
+func NewTransactionHandler(service *services.TransactionService) *TransactionHandler {
+	return &TransactionHandler{service: service}
+}
+
+func (h *TransactionHandler) HandleTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.TransactionFilter{InvoiceNumber: query.Get("invoice")}
+
+	storeID, err := optionalIntParam(r, "store_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid store_id")
+		return
+	}
+	filter.StoreID = storeID
+
+	if startDateStr := query.Get("start_date"); startDateStr != "" {
+		startDate, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid start_date format (YYYY-MM-DD)")
+			return
+		}
+		filter.StartDate = &startDate
+	}
+
+	if endDateStr := query.Get("end_date"); endDateStr != "" {
+		endDate, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid end_date format (YYYY-MM-DD)")
+			return
+		}
+		filter.EndDate = &endDate
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	transactions, err := h.service.GetAll(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transactions)
+}
+
+func (h *TransactionHandler) HandleTransaction(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/transactions/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "refund":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.refund(w, r, id)
+	case len(parts) == 2 && parts[1] == "void":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.void(w, r, id)
+	case r.Method != http.MethodGet:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	case len(parts) == 1:
+		h.getByID(w, r, id)
+	case len(parts) == 2 && parts[1] == "receipt":
+		h.getReceipt(w, r, id)
+	default:
+		writeError(w, http.StatusNotFound, "Transaction endpoint not found")
+	}
+}
+
+func (h *TransactionHandler) refund(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	refund, err := h.service.Refund(id, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "transaction not found") {
+			writeError(w, http.StatusNotFound, "Transaction not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, refund)
+}
+
+func (h *TransactionHandler) void(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.VoidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	refund, err := h.service.Void(id, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "transaction not found") {
+			writeError(w, http.StatusNotFound, "Transaction not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, refund)
+}
+
+func (h *TransactionHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	transaction, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if transaction == nil {
+		writeError(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transaction)
+}
+
+func (h *TransactionHandler) getReceipt(w http.ResponseWriter, r *http.Request, id int) {
+	receipt, err := h.service.GetReceipt(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if receipt == "" {
+		writeError(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(receipt))
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type TransferHandler struct {
+	service *services.TransferService
+}
+
+func NewTransferHandler(service *services.TransferService) *TransferHandler {
+	return &TransferHandler{service: service}
+}
+
+func (h *TransferHandler) HandleTransfers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getAll(w, r)
+	case http.MethodPost:
+		h.dispatch(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *TransferHandler) HandleTransfer(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/transfers/")
+	if path == "in-transit" {
+		h.getInTransit(w, r)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid transfer ID")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.getByID(w, r, id)
+	case len(parts) == 2 && parts[1] == "receive" && r.Method == http.MethodPost:
+		h.receive(w, r, id)
+	case len(parts) > 2 || (len(parts) == 2 && parts[1] != "receive"):
+		writeError(w, http.StatusNotFound, "Transfer endpoint not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *TransferHandler) HandleMovements(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	productID, err := optionalIntParam(r, "product_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product_id")
+		return
+	}
+	storeID, err := optionalIntParam(r, "store_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid store_id")
+		return
+	}
+	limit, err := optionalIntParam(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	var n int
+	if limit != nil {
+		n = *limit
+	}
+
+	movements, err := h.service.GetMovements(productID, storeID, n)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, movements)
+}
+
+func (h *TransferHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	transfers, err := h.service.GetAll(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfers)
+}
+
+func (h *TransferHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	transfer, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if transfer == nil {
+		writeError(w, http.StatusNotFound, "Transfer not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfer)
+}
+
+func (h *TransferHandler) getInTransit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	stock, err := h.service.GetInTransit()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stock)
+}
+
+func (h *TransferHandler) dispatch(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	transfer, err := h.service.Dispatch(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, transfer)
+}
+
+func (h *TransferHandler) receive(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.ReceiveTransferRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	transfer, err := h.service.Receive(id, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "transfer not found") {
+			writeError(w, http.StatusNotFound, "Transfer not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfer)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type VoucherHandler struct {
+	service *services.VoucherService
+}
+
+func NewVoucherHandler(service *services.VoucherService) *VoucherHandler {
+	return &VoucherHandler{service: service}
+}
+
+func (h *VoucherHandler) HandleVouchers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getAll(w, r)
+	case http.MethodPost:
+		h.generate(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *VoucherHandler) HandleVoucher(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimPrefix(r.URL.Path, "/api/vouchers/")
+	if code == "" || strings.Contains(code, "/") {
+		writeError(w, http.StatusBadRequest, "Invalid voucher code")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getByCode(w, r, code)
+	case http.MethodDelete:
+		h.deactivate(w, r, code)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *VoucherHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	vouchers, err := h.service.GetAll(r.URL.Query().Get("type"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, vouchers)
+}
+
+func (h *VoucherHandler) getByCode(w http.ResponseWriter, r *http.Request, code string) {
+	voucher, err := h.service.GetByCode(code)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if voucher == nil {
+		writeError(w, http.StatusNotFound, "Voucher not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, voucher)
+}
+
+func (h *VoucherHandler) generate(w http.ResponseWriter, r *http.Request) {
+	var req models.GenerateVouchersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	vouchers, err := h.service.Generate(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, vouchers)
+}
+
+func (h *VoucherHandler) deactivate(w http.ResponseWriter, r *http.Request, code string) {
+	if err := h.service.Deactivate(code); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "Voucher not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Voucher deactivated successfully"})
+}
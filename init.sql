@@ -17,7 +17,7 @@ CREATE TABLE IF NOT EXISTS products (
 CREATE TABLE IF NOT EXISTS transactions (
     id SERIAL PRIMARY KEY,
     total_amount INT NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE TABLE IF NOT EXISTS transaction_details (
@@ -28,9 +28,496 @@ CREATE TABLE IF NOT EXISTS transaction_details (
     subtotal INT NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS member_tiers (
+    name VARCHAR(50) PRIMARY KEY,
+    multiplier_percent INT NOT NULL DEFAULT 100
+);
+
+INSERT INTO member_tiers (name, multiplier_percent) VALUES
+    ('regular', 100),
+    ('silver', 150),
+    ('gold', 200)
+ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(50) UNIQUE,
+    tier VARCHAR(50) NOT NULL DEFAULT 'regular' REFERENCES member_tiers(name),
+    points INT NOT NULL DEFAULT 0 CHECK (points >= 0),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INT REFERENCES customers(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS change_amount INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    method VARCHAR(50) NOT NULL,
+    amount INT NOT NULL,
+    reference VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS customer_points_ledger (
+    id SERIAL PRIMARY KEY,
+    customer_id INT REFERENCES customers(id) ON DELETE CASCADE,
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    points INT NOT NULL,
+    balance INT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS credit_limit INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS receivables (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    paid_amount INT NOT NULL DEFAULT 0 CHECK (paid_amount >= 0 AND paid_amount <= amount),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS receivable_payments (
+    id SERIAL PRIMARY KEY,
+    receivable_id INT NOT NULL REFERENCES receivables(id) ON DELETE CASCADE,
+    amount INT NOT NULL CHECK (amount > 0),
+    method VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS vouchers (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('voucher', 'gift_card')),
+    initial_value INT NOT NULL CHECK (initial_value > 0),
+    balance INT NOT NULL CHECK (balance >= 0),
+    max_uses INT CHECK (max_uses > 0),
+    uses INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS voucher_redemptions (
+    id SERIAL PRIMARY KEY,
+    voucher_id INT NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS invoice_number VARCHAR(100) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    store_code VARCHAR(50) NOT NULL,
+    business_date DATE NOT NULL,
+    last_number INT NOT NULL,
+    PRIMARY KEY (store_code, business_date)
+);
+
+CREATE TABLE IF NOT EXISTS employees (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'cashier' CHECK (role IN ('cashier', 'manager')),
+    pin_salt VARCHAR(64) NOT NULL,
+    pin_hash VARCHAR(64) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS pin_lookup VARCHAR(64);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS original_price INT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS override_reason TEXT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS approved_by INT REFERENCES employees(id);
+
+CREATE TABLE IF NOT EXISTS stores (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS store_products (
+    store_id INT NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    price INT CHECK (price >= 0),
+    PRIMARY KEY (store_id, product_id)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS store_id INT REFERENCES stores(id);
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    store_id INT REFERENCES stores(id),
+    quantity INT NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    reference_type VARCHAR(50),
+    reference_id INT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id SERIAL PRIMARY KEY,
+    from_store_id INT NOT NULL REFERENCES stores(id),
+    to_store_id INT NOT NULL REFERENCES stores(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'dispatched' CHECK (status IN ('dispatched', 'received')),
+    has_discrepancy BOOLEAN NOT NULL DEFAULT FALSE,
+    notes TEXT,
+    dispatched_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    received_at TIMESTAMPTZ,
+    CHECK (from_store_id <> to_store_id)
+);
+
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+    id SERIAL PRIMARY KEY,
+    transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity_sent INT NOT NULL CHECK (quantity_sent > 0),
+    quantity_received INT CHECK (quantity_received >= 0),
+    discrepancy_note TEXT
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_stock INT NOT NULL DEFAULT 0 CHECK (min_stock >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_qty INT NOT NULL DEFAULT 0 CHECK (reorder_qty >= 0);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS track_expiry BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS product_batches (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    store_id INT REFERENCES stores(id),
+    batch_code VARCHAR(100) NOT NULL,
+    expiry_date DATE,
+    quantity INT NOT NULL CHECK (quantity >= 0),
+    received_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS transaction_detail_batches (
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    batch_id INT NOT NULL REFERENCES product_batches(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (transaction_detail_id, batch_id)
+);
+
+CREATE TABLE IF NOT EXISTS stock_transfer_item_batches (
+    transfer_item_id INT NOT NULL REFERENCES stock_transfer_items(id) ON DELETE CASCADE,
+    batch_id INT NOT NULL REFERENCES product_batches(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (transfer_item_id, batch_id)
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS base_unit VARCHAR(20) NOT NULL DEFAULT 'pcs';
+
+CREATE TABLE IF NOT EXISTS product_units (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    unit VARCHAR(20) NOT NULL,
+    factor INT NOT NULL CHECK (factor > 0),
+    price INT CHECK (price >= 0),
+    barcode VARCHAR(100),
+    PRIMARY KEY (product_id, unit)
+);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit VARCHAR(20);
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS base_quantity INT;
+UPDATE transaction_details SET base_quantity = quantity WHERE base_quantity IS NULL;
+ALTER TABLE transaction_details ALTER COLUMN base_quantity SET NOT NULL;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS quantity_precision INT NOT NULL DEFAULT 0 CHECK (quantity_precision BETWEEN 0 AND 3);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS plu VARCHAR(10);
+ALTER TABLE products ALTER COLUMN stock TYPE NUMERIC(14,3);
+ALTER TABLE products ALTER COLUMN min_stock TYPE NUMERIC(14,3);
+ALTER TABLE products ALTER COLUMN reorder_qty TYPE NUMERIC(14,3);
+ALTER TABLE store_products ALTER COLUMN stock TYPE NUMERIC(14,3);
+ALTER TABLE transaction_details ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE transaction_details ALTER COLUMN base_quantity TYPE NUMERIC(14,3);
+ALTER TABLE stock_movements ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE stock_transfer_items ALTER COLUMN quantity_sent TYPE NUMERIC(14,3);
+ALTER TABLE stock_transfer_items ALTER COLUMN quantity_received TYPE NUMERIC(14,3);
+ALTER TABLE product_batches ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE transaction_detail_batches ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE stock_transfer_item_batches ALTER COLUMN quantity TYPE NUMERIC(14,3);
+
+CREATE TABLE IF NOT EXISTS product_prices (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price INT NOT NULL CHECK (price >= 0),
+    effective_from TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id),
+    reason TEXT NOT NULL,
+    total_amount INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id SERIAL PRIMARY KEY,
+    refund_id INT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity NUMERIC(14,3) NOT NULL CHECK (quantity > 0),
+    base_quantity NUMERIC(14,3) NOT NULL,
+    unit_price INT NOT NULL,
+    amount INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS price_lists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    audience VARCHAR(20) NOT NULL DEFAULT 'all' CHECK (audience IN ('all', 'member', 'staff')),
+    member_tier VARCHAR(50) REFERENCES member_tiers(name),
+    priority INT NOT NULL DEFAULT 0,
+    start_time TIME,
+    end_time TIME,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((start_time IS NULL) = (end_time IS NULL))
+);
+
+CREATE TABLE IF NOT EXISTS price_list_items (
+    price_list_id INT NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    min_quantity NUMERIC(14,3) NOT NULL DEFAULT 0 CHECK (min_quantity >= 0),
+    price INT NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (price_list_id, product_id, min_quantity)
+);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_list_id INT REFERENCES price_lists(id) ON DELETE SET NULL;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_list VARCHAR(100);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_key VARCHAR(255);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_type VARCHAR(50);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS thumbnail_key VARCHAR(255);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_updated_at TIMESTAMPTZ;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS cashier_id INT REFERENCES employees(id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voided_at TIMESTAMPTZ;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voided_by INT REFERENCES employees(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS void_reason TEXT;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS kind VARCHAR(10) NOT NULL DEFAULT 'refund' CHECK (kind IN ('refund', 'void'));
+
+CREATE TABLE IF NOT EXISTS z_reports (
+    id SERIAL PRIMARY KEY,
+    store_id INT REFERENCES stores(id),
+    number INT NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    transaction_count INT NOT NULL,
+    gross_sales INT NOT NULL,
+    discounts INT NOT NULL,
+    net_sales INT NOT NULL,
+    refund_count INT NOT NULL,
+    refunds INT NOT NULL,
+    void_count INT NOT NULL,
+    voids INT NOT NULL,
+    total_revenue INT NOT NULL,
+    tax_rate_percent INT NOT NULL,
+    tax INT NOT NULL,
+    first_invoice VARCHAR(100),
+    last_invoice VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS z_report_payments (
+    z_report_id INT NOT NULL REFERENCES z_reports(id),
+    method VARCHAR(50) NOT NULL,
+    transaction_count INT NOT NULL,
+    amount INT NOT NULL,
+    PRIMARY KEY (z_report_id, method)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS z_report_id INT REFERENCES z_reports(id) DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS z_report_id INT REFERENCES z_reports(id) DEFERRABLE INITIALLY DEFERRED;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price INT NOT NULL DEFAULT 0 CHECK (cost_price >= 0);
+
+CREATE TABLE IF NOT EXISTS product_costs (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    cost_price INT NOT NULL CHECK (cost_price >= 0),
+    effective_from TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Closed business days rolled up from transactions: one row per day, store
+-- and product, plus one row per day and store with no product holding the
+-- store's totals, since a sale of several products counts once there.
+CREATE TABLE IF NOT EXISTS daily_sales_summary (
+    business_date DATE NOT NULL,
+    store_id INT REFERENCES stores(id),
+    product_id INT REFERENCES products(id),
+    quantity NUMERIC(14,3) NOT NULL DEFAULT 0,
+    revenue BIGINT NOT NULL,
+    transaction_count INT NOT NULL
+);
+
+-- How far daily_sales_summary reaches, and the time zone and cutoff its days
+-- were cut with; a summary cut differently is ignored until rebuilt.
+CREATE TABLE IF NOT EXISTS sales_summary_state (
+    rolled_up_through DATE NOT NULL,
+    store_timezone VARCHAR(64) NOT NULL,
+    cutoff_minutes INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Summaries from before version 2 still counted voided sales and ignored
+-- refunds; the next rollup rebuilds them.
+ALTER TABLE sales_summary_state ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- Databases created before timestamps carried a time zone: existing values are
+-- read in the session TimeZone, so run this with PGTZ set to the zone the
+-- server was writing in.
+DO $$
+DECLARE
+    c RECORD;
+BEGIN
+    FOR c IN
+        SELECT table_name, column_name FROM information_schema.columns
+        WHERE table_schema = current_schema() AND data_type = 'timestamp without time zone'
+    LOOP
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ', c.table_name, c.column_name);
+    END LOOP;
+END $$;
+
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tenant 1 owns every row created before multi-tenant mode; its token must be rotated before use.
+INSERT INTO tenants (id, slug, name, token_hash) VALUES (1, 'default', 'Default', md5(random()::TEXT))
+ON CONFLICT (id) DO NOTHING;
+SELECT setval('tenants_id_seq', GREATEST((SELECT MAX(id) FROM tenants), 1));
+
+-- The API connects with app.tenant_id set per tenant pool; row level security scopes every query.
+CREATE OR REPLACE FUNCTION current_tenant_id() RETURNS INT AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')::INT
+$$ LANGUAGE SQL STABLE;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'categories', 'products', 'transactions', 'transaction_details', 'customers',
+        'transaction_payments', 'customer_points_ledger', 'receivables', 'receivable_payments',
+        'vouchers', 'voucher_redemptions', 'invoice_sequences', 'employees', 'stores',
+        'store_products', 'stock_movements', 'stock_transfers', 'stock_transfer_items',
+        'product_batches', 'transaction_detail_batches', 'stock_transfer_item_batches', 'product_units',
+        'product_prices', 'refunds', 'refund_items', 'price_lists', 'price_list_items',
+        'z_reports', 'z_report_payments', 'daily_sales_summary', 'sales_summary_state',
+        'product_costs'
+    ] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id)', t);
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET DEFAULT current_tenant_id()', t);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I (tenant_id)', 'idx_' || t || '_tenant_id', t);
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (tenant_id = current_tenant_id()) WITH CHECK (tenant_id = current_tenant_id())', t);
+    END LOOP;
+END $$;
+
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_phone_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_phone ON customers(tenant_id, phone);
+ALTER TABLE vouchers DROP CONSTRAINT IF EXISTS vouchers_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vouchers_tenant_code ON vouchers(tenant_id, code);
+ALTER TABLE stores DROP CONSTRAINT IF EXISTS stores_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stores_tenant_code ON stores(tenant_id, code);
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_invoice_number_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_tenant_invoice_number ON transactions(tenant_id, invoice_number);
+ALTER TABLE invoice_sequences DROP CONSTRAINT IF EXISTS invoice_sequences_pkey;
+ALTER TABLE invoice_sequences ADD PRIMARY KEY (tenant_id, store_code, business_date);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_units_tenant_barcode ON product_units(tenant_id, barcode);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_tenant_plu ON products(tenant_id, plu);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_lists_tenant_name ON price_lists(tenant_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_z_reports_tenant_store_number ON z_reports(tenant_id, COALESCE(store_id, 0), number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_daily_sales_summary_tenant_key ON daily_sales_summary(tenant_id, business_date, COALESCE(store_id, 0), COALESCE(product_id, 0));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sales_summary_state_tenant ON sales_summary_state(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_employees_tenant_pin_lookup ON employees(tenant_id, pin_lookup);
+
+-- Seed the price history with each product's current price so sales made
+-- before history was kept still resolve to a price.
+INSERT INTO product_prices (tenant_id, product_id, price, effective_from, applied_at)
+SELECT p.tenant_id, p.id, p.price, TIMESTAMPTZ 'epoch', CURRENT_TIMESTAMP
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM product_prices pp WHERE pp.product_id = p.id);
+
+INSERT INTO product_costs (tenant_id, product_id, cost_price, effective_from)
+SELECT p.tenant_id, p.id, p.cost_price, TIMESTAMPTZ 'epoch'
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM product_costs pc WHERE pc.product_id = p.id);
+
+CREATE INDEX IF NOT EXISTS idx_product_batches_product_id ON product_batches(product_id, store_id, expiry_date);
+CREATE INDEX IF NOT EXISTS idx_product_prices_product_id ON product_prices(product_id, effective_from);
+CREATE INDEX IF NOT EXISTS idx_product_prices_pending ON product_prices(effective_from) WHERE applied_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_product_costs_product_id ON product_costs(product_id, effective_from);
+CREATE INDEX IF NOT EXISTS idx_refunds_transaction_id ON refunds(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_refund_items_transaction_detail_id ON refund_items(transaction_detail_id);
+CREATE INDEX IF NOT EXISTS idx_price_list_items_product_id ON price_list_items(product_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions(created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_open ON transactions(store_id) WHERE z_report_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_refunds_open ON refunds(transaction_id) WHERE z_report_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_store_id ON transactions(store_id);
+CREATE INDEX IF NOT EXISTS idx_receivables_customer_id ON receivables(customer_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_customer_id ON transactions(customer_id);
+CREATE INDEX IF NOT EXISTS idx_customer_points_ledger_customer_id ON customer_points_ledger(customer_id);
+
 -- Grant permissions to asisten_intern user
 GRANT ALL PRIVILEGES ON TABLE categories TO asisten_intern;
 GRANT ALL PRIVILEGES ON TABLE products TO asisten_intern;
 GRANT ALL PRIVILEGES ON TABLE transactions TO asisten_intern;
 GRANT ALL PRIVILEGES ON TABLE transaction_details TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE member_tiers TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE customers TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE transaction_payments TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE customer_points_ledger TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE receivables TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE receivable_payments TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE vouchers TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE voucher_redemptions TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE invoice_sequences TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE employees TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE stores TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE store_products TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE stock_movements TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE stock_transfers TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE stock_transfer_items TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE tenants TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE product_batches TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE transaction_detail_batches TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE stock_transfer_item_batches TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE product_units TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE product_prices TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE product_costs TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE refunds TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE refund_items TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE price_lists TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE price_list_items TO asisten_intern;
+-- Z reports are a closed register's record: the API may add them but never change them.
+GRANT SELECT, INSERT ON TABLE z_reports TO asisten_intern;
+GRANT SELECT, INSERT ON TABLE z_report_payments TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE daily_sales_summary TO asisten_intern;
+GRANT ALL PRIVILEGES ON TABLE sales_summary_state TO asisten_intern;
 GRANT ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public TO asisten_intern;
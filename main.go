@@ -6,47 +6,142 @@ import (
 	"andre_kasir_api/handlers"
 	"andre_kasir_api/repositories"
 	"andre_kasir_api/services"
+	"andre_kasir_api/storage"
+	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 func main() {
+	rebuildSummary := flag.Bool("rebuild-sales-summary", false, "rebuild daily_sales_summary from transactions and exit")
+	flag.Parse()
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
 		return
 	}
 
-	db, err := database.InitDB(cfg.DBConn)
+	alerter, err := services.NewStockAlerterFromConfig(cfg)
 	if err != nil {
-		fmt.Printf("Failed to connect to database: %v\n", err)
+		fmt.Printf("Failed to configure low stock alerts: %v\n", err)
 		return
 	}
-	defer db.Close()
-
-	productRepo := repositories.NewProductRepository(db)
-	categoryRepo := repositories.NewCategoryRepository(db)
-	transactionRepo := repositories.NewTransactionRepository(db)
-
-	productService := services.NewProductService(productRepo)
-	categoryService := services.NewCategoryService(categoryRepo)
-	transactionService := services.NewTransactionService(transactionRepo)
-
-	productHandler := handlers.NewProductHandler(productService)
-	categoryHandler := handlers.NewCategoryHandler(categoryService)
-	checkoutHandler := handlers.NewCheckoutHandler(transactionService)
-	reportHandler := handlers.NewReportHandler(transactionService)
-
-	http.HandleFunc("/api/produk/", productHandler.HandleProduct)
-	http.HandleFunc("/api/produk", productHandler.HandleProducts)
-	http.HandleFunc("/api/categories/", categoryHandler.HandleCategory)
-	http.HandleFunc("/api/categories", categoryHandler.HandleCategories)
-	http.HandleFunc("/api/checkout", checkoutHandler.HandleCheckout)
-	http.HandleFunc("/api/report/hari-ini", reportHandler.HandleReport)
-	http.HandleFunc("/api/report", reportHandler.HandleReport)
-
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	alerter.Start()
+
+	images, err := storage.NewFromConfig(cfg)
+	if err != nil {
+		fmt.Printf("Failed to configure image storage: %v\n", err)
+		return
+	}
+
+	businessDay, err := repositories.NewBusinessDay(cfg)
+	if err != nil {
+		fmt.Printf("Failed to configure store time zone: %v\n", err)
+		return
+	}
+	connStr := database.WithTimeZone(cfg.DBConn, businessDay.Location.String())
+
+	mux := http.NewServeMux()
+	var transactionRepos func() ([]*repositories.TransactionRepository, error)
+
+	if cfg.MultiTenant {
+		db, err := database.InitDB(connStr)
+		if err != nil {
+			fmt.Printf("Failed to connect to database: %v\n", err)
+			return
+		}
+		defer db.Close()
+
+		pool := database.NewTenantPool(connStr, cfg.TenantPoolMaxConns, time.Duration(cfg.TenantPoolIdleTimeout)*time.Second)
+		defer pool.Close()
+
+		tenantService := services.NewTenantService(repositories.NewTenantRepository(db))
+		tenantHandler := handlers.NewTenantHandler(tenantService, cfg.AdminToken)
+
+		services.NewPriceScheduler(time.Duration(cfg.PriceScheduleInterval)*time.Second, func() ([]*repositories.ProductRepository, error) {
+			tenants, err := tenantService.GetAll()
+			if err != nil {
+				return nil, err
+			}
+			// Idle tenants are left alone; checkout applies due prices itself.
+			var repos []*repositories.ProductRepository
+			for _, t := range tenants {
+				if !t.Active {
+					continue
+				}
+				if tenantDB, ok := pool.Lookup(t.ID); ok {
+					repos = append(repos, repositories.NewProductRepository(tenantDB))
+				}
+			}
+			return repos, nil
+		}).Start()
+
+		transactionRepos = func() ([]*repositories.TransactionRepository, error) {
+			tenants, err := tenantService.GetAll()
+			if err != nil {
+				return nil, err
+			}
+			// Reports read days not rolled up yet live, so idle tenants are
+			// only rolled up for a rebuild.
+			var repos []*repositories.TransactionRepository
+			for _, t := range tenants {
+				if !t.Active {
+					continue
+				}
+				tenantDB, ok := pool.Lookup(t.ID)
+				if !ok && *rebuildSummary {
+					if tenantDB, err = pool.Get(t.ID); err != nil {
+						return nil, err
+					}
+					ok = true
+				}
+				if ok {
+					repos = append(repos, repositories.NewTransactionRepository(tenantDB, cfg))
+				}
+			}
+			return repos, nil
+		}
+
+		mux.HandleFunc("/api/tenants/", tenantHandler.HandleTenant)
+		mux.HandleFunc("/api/tenants", tenantHandler.HandleTenants)
+		tenantRouter := handlers.NewTenantRouter(tenantService, cfg.TenantBaseDomain, pool.Get, func(tenantDB *sql.DB) http.Handler {
+			return newRouter(tenantDB, cfg, alerter, images)
+		})
+		pool.OnEvict(tenantRouter.Forget)
+		mux.Handle("/api/", tenantRouter)
+	} else {
+		db, err := database.InitDB(database.WithTenant(connStr, database.DefaultTenantID))
+		if err != nil {
+			fmt.Printf("Failed to connect to database: %v\n", err)
+			return
+		}
+		defer db.Close()
+
+		productRepo := repositories.NewProductRepository(db)
+		services.NewPriceScheduler(time.Duration(cfg.PriceScheduleInterval)*time.Second, func() ([]*repositories.ProductRepository, error) {
+			return []*repositories.ProductRepository{productRepo}, nil
+		}).Start()
+
+		transactionRepo := repositories.NewTransactionRepository(db, cfg)
+		transactionRepos = func() ([]*repositories.TransactionRepository, error) {
+			return []*repositories.TransactionRepository{transactionRepo}, nil
+		}
+
+		mux.Handle("/api/", newRouter(db, cfg, alerter, images))
+	}
+
+	salesRollup := services.NewSalesRollup(time.Duration(cfg.SalesRollupInterval)*time.Second, transactionRepos)
+	if *rebuildSummary {
+		salesRollup.Rebuild()
+		return
+	}
+	salesRollup.Start()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{
 			"status":  "200",
@@ -54,13 +149,13 @@ func main() {
 		})
 	})
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/health", http.StatusMovedPermanently)
 	})
 
 	fmt.Printf("Server started on :%s\n", cfg.Port)
 
-	if err := http.ListenAndServe(":"+cfg.Port, nil); err != nil {
+	if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
 		fmt.Printf("Server failed: %v\n", err)
 	}
 }
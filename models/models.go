@@ -3,11 +3,52 @@ package models
 import "time"
 
 type Product struct {
-	ID         int    `json:"id"`
-	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Stock      int    `json:"stock"`
-	CategoryID *int   `json:"category_id,omitempty"`
+	ID           int           `json:"id"`
+	Name         string        `json:"name"`
+	Price        int           `json:"price"`
+	CostPrice    int           `json:"cost_price"`
+	Stock        float64       `json:"stock"`
+	MinStock     float64       `json:"min_stock"`
+	ReorderQty   float64       `json:"reorder_qty"`
+	TrackExpiry  bool          `json:"track_expiry"`
+	BaseUnit     string        `json:"base_unit"`
+	Precision    int           `json:"quantity_precision"`
+	PLU          string        `json:"plu,omitempty"`
+	CategoryID   *int          `json:"category_id,omitempty"`
+	ImageURL     string        `json:"image_url,omitempty"`
+	ThumbnailURL string        `json:"thumbnail_url,omitempty"`
+	Units        []ProductUnit `json:"units,omitempty"`
+}
+
+// ProductImage locates a product's uploaded picture and its thumbnail in
+// image storage.
+type ProductImage struct {
+	ProductID    int       `json:"product_id"`
+	Key          string    `json:"-"`
+	ContentType  string    `json:"content_type"`
+	ThumbnailKey string    `json:"-"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	ImageURL     string    `json:"image_url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+// ProductPrice is one entry in a product's price history. Entries without
+// AppliedAt are scheduled changes that have not taken effect yet.
+type ProductPrice struct {
+	ID            int        `json:"id"`
+	ProductID     int        `json:"product_id"`
+	Price         int        `json:"price"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	AppliedAt     *time.Time `json:"applied_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type ProductUnit struct {
+	ProductID int    `json:"product_id"`
+	Unit      string `json:"unit"`
+	Factor    int    `json:"factor"`
+	Price     *int   `json:"price"`
+	Barcode   string `json:"barcode,omitempty"`
 }
 
 type Category struct {
@@ -17,37 +58,723 @@ type Category struct {
 }
 
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details,omitempty"`
+	ID             int                  `json:"id"`
+	InvoiceNumber  string               `json:"invoice_number"`
+	StoreID        *int                 `json:"store_id,omitempty"`
+	TotalAmount    int                  `json:"total_amount"`
+	CustomerID     *int                 `json:"customer_id,omitempty"`
+	CashierID      *int                 `json:"cashier_id,omitempty"`
+	PointsEarned   int                  `json:"points_earned"`
+	PointsRedeemed int                  `json:"points_redeemed"`
+	Change         int                  `json:"change"`
+	CreatedAt      time.Time            `json:"created_at"`
+	VoidedAt       *time.Time           `json:"voided_at,omitempty"`
+	VoidedBy       *int                 `json:"voided_by,omitempty"`
+	VoidReason     string               `json:"void_reason,omitempty"`
+	Details        []TransactionDetail  `json:"details,omitempty"`
+	Payments       []TransactionPayment `json:"payments,omitempty"`
+	Refunds        []Refund             `json:"refunds,omitempty"`
 }
 
 type TransactionDetail struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	ID             int     `json:"id"`
+	TransactionID  int     `json:"transaction_id"`
+	ProductID      int     `json:"product_id"`
+	ProductName    string  `json:"product_name"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+	BaseQuantity   float64 `json:"base_quantity"`
+	OriginalPrice  int     `json:"original_price"`
+	UnitPrice      int     `json:"unit_price"`
+	Discount       int     `json:"discount"`
+	Subtotal       int     `json:"subtotal"`
+	OverrideReason string  `json:"override_reason,omitempty"`
+	ApprovedBy     *int    `json:"approved_by,omitempty"`
+	PriceListID    *int    `json:"price_list_id,omitempty"`
+	PriceList      string  `json:"price_list,omitempty"`
 }
 
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID     int     `json:"product_id"`
+	Barcode       string  `json:"barcode,omitempty"`
+	Quantity      float64 `json:"quantity"`
+	Unit          string  `json:"unit,omitempty"`
+	OverridePrice *int    `json:"override_price,omitempty"`
+	Discount      int     `json:"discount,omitempty"`
+	Reason        string  `json:"reason,omitempty"`
+}
+
+const (
+	PaymentMethodCash     = "cash"
+	PaymentMethodDebit    = "debit"
+	PaymentMethodQRIS     = "qris"
+	PaymentMethodTransfer = "transfer"
+	PaymentMethodPoints   = "points"
+	PaymentMethodCredit   = "credit"
+	PaymentMethodVoucher  = "voucher"
+	PaymentMethodGiftCard = "gift_card"
+)
+
+type CheckoutPayment struct {
+	Method    string `json:"method"`
+	Amount    int    `json:"amount"`
+	Reference string `json:"reference,omitempty"`
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	Items      []CheckoutItem    `json:"items"`
+	StoreID    *int              `json:"store_id,omitempty"`
+	CustomerID *int              `json:"customer_id,omitempty"`
+	Payments   []CheckoutPayment `json:"payments,omitempty"`
+	ManagerPIN string            `json:"manager_pin,omitempty"`
+	StaffPIN   string            `json:"staff_pin,omitempty"`
+	CashierPIN string            `json:"cashier_pin,omitempty"`
+}
+
+const (
+	EmployeeRoleCashier = "cashier"
+	EmployeeRoleManager = "manager"
+)
+
+type Employee struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Role       string    `json:"role"`
+	PIN        string    `json:"pin,omitempty"`
+	ManagerPIN string    `json:"manager_pin,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TransactionFilter struct {
+	InvoiceNumber string
+	StoreID       *int
+	StartDate     *time.Time
+	EndDate       *time.Time
+	Limit         int
+}
+
+type TransactionPayment struct {
+	ID            int    `json:"id"`
+	TransactionID int    `json:"transaction_id"`
+	Method        string `json:"method"`
+	Amount        int    `json:"amount"`
+	Reference     string `json:"reference,omitempty"`
+}
+
+type Customer struct {
+	ID                int       `json:"id"`
+	Name              string    `json:"name"`
+	Phone             string    `json:"phone"`
+	Tier              string    `json:"tier"`
+	Points            int       `json:"points"`
+	CreditLimit       int       `json:"credit_limit"`
+	OutstandingCredit int       `json:"outstanding_credit"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type MemberTier struct {
+	Name              string `json:"name"`
+	MultiplierPercent int    `json:"multiplier_percent"`
+}
+
+type PointsLedgerEntry struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	Points        int       `json:"points"`
+	Balance       int       `json:"balance"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type SalesReport struct {
-	TotalRevenue   int             `json:"total_revenue"`
-	TotalTransaksi int             `json:"total_transaksi"`
-	ProdukTerlaris *ProdukTerlaris `json:"produk_terlaris,omitempty"`
+	TotalRevenue   int                `json:"total_revenue"`
+	TotalTransaksi int                `json:"total_transaksi"`
+	ProdukTerlaris *ProdukTerlaris    `json:"produk_terlaris,omitempty"`
+	PerStore       []StoreSalesReport `json:"per_store,omitempty"`
+	Comparison     *SalesComparison   `json:"comparison,omitempty"`
+}
+
+const (
+	CompareToPreviousPeriod     = "previous_period"
+	CompareToSamePeriodLastYear = "same_period_last_year"
+)
+
+// SalesComparison sets a report's period against an earlier one of the same
+// length.
+type SalesComparison struct {
+	CompareTo     string               `json:"compare_to"`
+	Current       PeriodSales          `json:"current"`
+	Previous      PeriodSales          `json:"previous"`
+	Revenue       SalesDelta           `json:"revenue"`
+	Transactions  SalesDelta           `json:"transactions"`
+	AverageBasket SalesDelta           `json:"average_basket"`
+	Categories    []CategoryComparison `json:"categories"`
+}
+
+type PeriodSales struct {
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	Revenue       int    `json:"revenue"`
+	Transactions  int    `json:"transactions"`
+	AverageBasket int    `json:"average_basket"`
+}
+
+// SalesDelta is Current minus Previous. ChangePercent is relative to
+// Previous and left out when Previous is zero.
+type SalesDelta struct {
+	Current       int      `json:"current"`
+	Previous      int      `json:"previous"`
+	Change        int      `json:"change"`
+	ChangePercent *float64 `json:"change_percent"`
+}
+
+type CategoryComparison struct {
+	CategoryID *int       `json:"category_id"`
+	Name       string     `json:"name"`
+	Revenue    SalesDelta `json:"revenue"`
+}
+
+type StoreSalesReport struct {
+	StoreID        *int   `json:"store_id"`
+	StoreName      string `json:"store_name"`
+	TotalRevenue   int    `json:"total_revenue"`
+	TotalTransaksi int    `json:"total_transaksi"`
 }
 
 type ProdukTerlaris struct {
-	Nama       string `json:"nama"`
-	QtyTerjual int    `json:"qty_terjual"`
+	Nama       string  `json:"nama"`
+	QtyTerjual float64 `json:"qty_terjual"`
+}
+
+const (
+	BreakdownByHour          = "hour"
+	BreakdownByDay           = "day"
+	BreakdownByWeek          = "week"
+	BreakdownByMonth         = "month"
+	BreakdownByProduct       = "product"
+	BreakdownByCategory      = "category"
+	BreakdownByCashier       = "cashier"
+	BreakdownByPaymentMethod = "payment_method"
+)
+
+var BreakdownGroups = []string{
+	BreakdownByHour, BreakdownByDay, BreakdownByWeek, BreakdownByMonth,
+	BreakdownByProduct, BreakdownByCategory, BreakdownByCashier, BreakdownByPaymentMethod,
+}
+
+type SalesBreakdown struct {
+	GroupBy   string               `json:"group_by"`
+	StartDate string               `json:"start_date"`
+	EndDate   string               `json:"end_date"`
+	Series    []SalesBreakdownItem `json:"series"`
+}
+
+const (
+	RankByQuantity = "quantity"
+	RankByRevenue  = "revenue"
+	RankTop        = "top"
+	RankBottom     = "bottom"
+)
+
+type ProductRanking struct {
+	By        string        `json:"by"`
+	Order     string        `json:"order"`
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Products  []ProductRank `json:"products"`
+}
+
+type ProductRank struct {
+	Rank             int     `json:"rank"`
+	ProductID        int     `json:"product_id"`
+	Name             string  `json:"name"`
+	Quantity         float64 `json:"quantity"`
+	Revenue          int     `json:"revenue"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+type DeadStockReport struct {
+	Days       int             `json:"days"`
+	Since      time.Time       `json:"since"`
+	TotalValue int             `json:"total_value"`
+	Products   []DeadStockItem `json:"products"`
+}
+
+const (
+	ValuationByCategory = "category"
+	ValuationByStore    = "store"
+)
+
+// InventoryValuation is the cost of the stock on hand at AsOf, grouped by
+// category or store.
+type InventoryValuation struct {
+	AsOf       time.Time                 `json:"as_of"`
+	GroupBy    string                    `json:"group_by"`
+	StoreID    *int                      `json:"store_id,omitempty"`
+	TotalValue int                       `json:"total_value"`
+	Groups     []InventoryValuationGroup `json:"groups"`
+}
+
+type InventoryValuationGroup struct {
+	Key      string                   `json:"key"`
+	Label    string                   `json:"label"`
+	Quantity float64                  `json:"quantity"`
+	Value    int                      `json:"value"`
+	Products []InventoryValuationItem `json:"products"`
+}
+
+type InventoryValuationItem struct {
+	ProductID  int     `json:"product_id"`
+	Name       string  `json:"name"`
+	CategoryID *int    `json:"category_id"`
+	Category   string  `json:"category"`
+	StoreID    *int    `json:"store_id"`
+	Store      string  `json:"store"`
+	Quantity   float64 `json:"quantity"`
+	UnitCost   int     `json:"unit_cost"`
+	Value      int     `json:"value"`
+}
+
+// BasketAnalysis lists products bought together between StartDate and
+// EndDate, out of Transactions sales. With a ProductID it only lists the
+// products bought with that one.
+type BasketAnalysis struct {
+	StartDate    string       `json:"start_date"`
+	EndDate      string       `json:"end_date"`
+	StoreID      *int         `json:"store_id,omitempty"`
+	ProductID    *int         `json:"product_id,omitempty"`
+	MinCount     int          `json:"min_count"`
+	Transactions int          `json:"transactions"`
+	Pairs        []BasketPair `json:"pairs"`
+}
+
+// BasketPair is a product bought together with another. Confidence is the
+// share of the product's sales that included the other product, and
+// ReverseConfidence the other way round. A lift above 1 means the two sell
+// together more often than chance.
+type BasketPair struct {
+	ProductID               int     `json:"product_id"`
+	ProductName             string  `json:"product_name"`
+	WithProductID           int     `json:"with_product_id"`
+	WithProductName         string  `json:"with_product_name"`
+	Transactions            int     `json:"transactions"`
+	ProductTransactions     int     `json:"product_transactions"`
+	WithProductTransactions int     `json:"with_product_transactions"`
+	Support                 float64 `json:"support"`
+	Confidence              float64 `json:"confidence"`
+	ReverseConfidence       float64 `json:"reverse_confidence"`
+	Lift                    float64 `json:"lift"`
+}
+
+// DemandForecast projects each product's demand over the Days business days
+// from StartDate to EndDate, from its sales in the HistoryWeeks weeks before.
+type DemandForecast struct {
+	StartDate    string            `json:"start_date"`
+	EndDate      string            `json:"end_date"`
+	Days         int               `json:"days"`
+	HistoryWeeks int               `json:"history_weeks"`
+	StoreID      *int              `json:"store_id,omitempty"`
+	Products     []ProductForecast `json:"products"`
+}
+
+type ProductForecast struct {
+	ProductID     int             `json:"product_id"`
+	Name          string          `json:"name"`
+	Stock         float64         `json:"stock"`
+	MinStock      float64         `json:"min_stock"`
+	ReorderQty    float64         `json:"reorder_qty"`
+	MovingAverage float64         `json:"moving_average"`
+	ForecastDaily float64         `json:"forecast_daily"`
+	ForecastTotal float64         `json:"forecast_total"`
+	DaysOfCover   *float64        `json:"days_of_cover"`
+	SuggestedQty  float64         `json:"suggested_qty"`
+	Daily         []DailyForecast `json:"daily"`
+}
+
+type DailyForecast struct {
+	Date     string  `json:"date"`
+	Quantity float64 `json:"quantity"`
+}
+
+// DailyProductSales is the quantity of a product sold on one business day.
+type DailyProductSales struct {
+	ProductID int       `json:"product_id"`
+	Date      time.Time `json:"date"`
+	Quantity  float64   `json:"quantity"`
+}
+
+type DeadStockItem struct {
+	ProductID  int        `json:"product_id"`
+	Name       string     `json:"name"`
+	Stock      float64    `json:"stock"`
+	Price      int        `json:"price"`
+	StockValue int        `json:"stock_value"`
+	LastSoldAt *time.Time `json:"last_sold_at"`
+}
+
+type SalesBreakdownItem struct {
+	Key              string  `json:"key"`
+	Label            string  `json:"label"`
+	Revenue          int     `json:"revenue"`
+	Quantity         float64 `json:"quantity"`
+	TransactionCount int     `json:"transaction_count"`
+	AverageBasket    int     `json:"average_basket"`
+}
+
+type Receivable struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	CustomerName  string    `json:"customer_name"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	Amount        int       `json:"amount"`
+	PaidAmount    int       `json:"paid_amount"`
+	Outstanding   int       `json:"outstanding"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ReceivablePayment struct {
+	ID           int       `json:"id"`
+	ReceivableID int       `json:"receivable_id"`
+	Amount       int       `json:"amount"`
+	Method       string    `json:"method"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type RepaymentRequest struct {
+	Amount int    `json:"amount"`
+	Method string `json:"method"`
+}
+
+type AgingRow struct {
+	CustomerID   int    `json:"customer_id,omitempty"`
+	CustomerName string `json:"customer_name,omitempty"`
+	Days0To30    int    `json:"days_0_30"`
+	Days31To60   int    `json:"days_31_60"`
+	Over60       int    `json:"days_60_plus"`
+	Total        int    `json:"total"`
+}
+
+type AgingReport struct {
+	AsOf      time.Time  `json:"as_of"`
+	Customers []AgingRow `json:"customers"`
+	Totals    AgingRow   `json:"totals"`
+}
+
+const (
+	VoucherTypeVoucher  = "voucher"
+	VoucherTypeGiftCard = "gift_card"
+)
+
+type Voucher struct {
+	ID           int                 `json:"id"`
+	Code         string              `json:"code"`
+	Type         string              `json:"type"`
+	InitialValue int                 `json:"initial_value"`
+	Balance      int                 `json:"balance"`
+	MaxUses      *int                `json:"max_uses,omitempty"`
+	Uses         int                 `json:"uses"`
+	ExpiresAt    *time.Time          `json:"expires_at,omitempty"`
+	Active       bool                `json:"active"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Redemptions  []VoucherRedemption `json:"redemptions,omitempty"`
+}
+
+type VoucherRedemption struct {
+	ID            int       `json:"id"`
+	VoucherID     int       `json:"voucher_id"`
+	TransactionID int       `json:"transaction_id"`
+	Amount        int       `json:"amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type GenerateVouchersRequest struct {
+	Type      string     `json:"type"`
+	Value     int        `json:"value"`
+	Count     int        `json:"count"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type Store struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StoreProduct struct {
+	StoreID       int     `json:"store_id"`
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	Stock         float64 `json:"stock"`
+	BasePrice     int     `json:"base_price"`
+	PriceOverride *int    `json:"price_override,omitempty"`
+	Price         int     `json:"price"`
+}
+
+const (
+	TransferStatusDispatched = "dispatched"
+	TransferStatusReceived   = "received"
+)
+
+type StockTransfer struct {
+	ID             int                 `json:"id"`
+	FromStoreID    int                 `json:"from_store_id"`
+	FromStoreName  string              `json:"from_store_name"`
+	ToStoreID      int                 `json:"to_store_id"`
+	ToStoreName    string              `json:"to_store_name"`
+	Status         string              `json:"status"`
+	HasDiscrepancy bool                `json:"has_discrepancy"`
+	Notes          string              `json:"notes,omitempty"`
+	DispatchedAt   time.Time           `json:"dispatched_at"`
+	ReceivedAt     *time.Time          `json:"received_at,omitempty"`
+	Items          []StockTransferItem `json:"items,omitempty"`
+}
+
+type StockTransferItem struct {
+	ID               int      `json:"id"`
+	TransferID       int      `json:"transfer_id"`
+	ProductID        int      `json:"product_id"`
+	ProductName      string   `json:"product_name"`
+	QuantitySent     float64  `json:"quantity_sent"`
+	QuantityReceived *float64 `json:"quantity_received,omitempty"`
+	Discrepancy      float64  `json:"discrepancy"`
+	DiscrepancyNote  string   `json:"discrepancy_note,omitempty"`
+}
+
+type CreateTransferRequest struct {
+	FromStoreID int            `json:"from_store_id"`
+	ToStoreID   int            `json:"to_store_id"`
+	Notes       string         `json:"notes"`
+	Items       []CheckoutItem `json:"items"`
+}
+
+type ReceiveTransferItem struct {
+	ProductID        int     `json:"product_id"`
+	QuantityReceived float64 `json:"quantity_received"`
+	Note             string  `json:"note"`
+}
+
+type ReceiveTransferRequest struct {
+	Items []ReceiveTransferItem `json:"items"`
+}
+
+type InTransitStock struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	ToStoreID   int     `json:"to_store_id"`
+	ToStoreName string  `json:"to_store_name"`
+	Quantity    float64 `json:"quantity"`
+}
+
+type StockMovement struct {
+	ID            int       `json:"id"`
+	ProductID     int       `json:"product_id"`
+	ProductName   string    `json:"product_name"`
+	StoreID       *int      `json:"store_id,omitempty"`
+	Quantity      float64   `json:"quantity"`
+	Reason        string    `json:"reason"`
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   *int      `json:"reference_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Tenant struct {
+	ID        int       `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ProvisionTenantRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type TenantCredentials struct {
+	Tenant
+	Token string `json:"token"`
+}
+
+type StockAlert struct {
+	ProductID     int       `json:"product_id"`
+	ProductName   string    `json:"product_name"`
+	StoreID       *int      `json:"store_id,omitempty"`
+	Stock         float64   `json:"stock"`
+	MinStock      float64   `json:"min_stock"`
+	ReorderQty    float64   `json:"reorder_qty"`
+	TransactionID int       `json:"transaction_id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LowStockItem struct {
+	ProductID     int      `json:"product_id"`
+	ProductName   string   `json:"product_name"`
+	StoreID       *int     `json:"store_id,omitempty"`
+	Stock         float64  `json:"stock"`
+	MinStock      float64  `json:"min_stock"`
+	ReorderQty    float64  `json:"reorder_qty"`
+	SoldLastDays  float64  `json:"sold_last_days"`
+	AvgDailySales float64  `json:"avg_daily_sales"`
+	DaysOfCover   *float64 `json:"days_of_cover"`
+	SuggestedQty  float64  `json:"suggested_qty"`
+}
+
+type ProductBatch struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	StoreID     *int      `json:"store_id,omitempty"`
+	BatchCode   string    `json:"batch_code"`
+	ExpiryDate  *string   `json:"expiry_date"`
+	Quantity    float64   `json:"quantity"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+type ReceiveBatchRequest struct {
+	ProductID  int     `json:"product_id"`
+	StoreID    *int    `json:"store_id"`
+	BatchCode  string  `json:"batch_code"`
+	ExpiryDate *string `json:"expiry_date"`
+	Quantity   float64 `json:"quantity"`
+	Unit       string  `json:"unit,omitempty"`
+}
+
+// StockChangeRequest is a delivery received or a stocktake count of a
+// product, in any of its units.
+type StockChangeRequest struct {
+	ProductID int     `json:"product_id"`
+	StoreID   *int    `json:"store_id"`
+	Quantity  float64 `json:"quantity"`
+	Unit      string  `json:"unit,omitempty"`
+}
+
+type StockChange struct {
+	ProductID int     `json:"product_id"`
+	StoreID   *int    `json:"store_id,omitempty"`
+	Stock     float64 `json:"stock"`
+	Change    float64 `json:"change"`
+}
+
+type ExpiringBatch struct {
+	ProductBatch
+	DaysLeft int  `json:"days_left"`
+	Expired  bool `json:"expired"`
+}
+
+type RefundRequest struct {
+	Reason string              `json:"reason"`
+	Items  []RefundItemRequest `json:"items"`
+}
+
+type RefundItemRequest struct {
+	TransactionDetailID int     `json:"transaction_detail_id"`
+	Quantity            float64 `json:"quantity"`
+}
+
+const (
+	RefundKindRefund = "refund"
+	RefundKindVoid   = "void"
+)
+
+type VoidRequest struct {
+	Reason     string `json:"reason"`
+	ManagerPIN string `json:"manager_pin"`
+}
+
+type Refund struct {
+	ID            int          `json:"id"`
+	TransactionID int          `json:"transaction_id"`
+	Kind          string       `json:"kind"`
+	Reason        string       `json:"reason"`
+	TotalAmount   int          `json:"total_amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Items         []RefundItem `json:"items,omitempty"`
+}
+
+type RefundItem struct {
+	ID                  int     `json:"id"`
+	RefundID            int     `json:"refund_id"`
+	TransactionDetailID int     `json:"transaction_detail_id"`
+	ProductID           int     `json:"product_id"`
+	ProductName         string  `json:"product_name"`
+	Quantity            float64 `json:"quantity"`
+	BaseQuantity        float64 `json:"base_quantity"`
+	UnitPrice           int     `json:"unit_price"`
+	Amount              int     `json:"amount"`
+}
+
+// RegisterReport totals the register between two closes. An X report is
+// worked out on request and changes as sales come in; a Z report closes the
+// period, gets the next number for its store and is never changed again.
+type RegisterReport struct {
+	ID               int               `json:"id,omitempty"`
+	Type             string            `json:"type"`
+	Number           int               `json:"number,omitempty"`
+	StoreID          *int              `json:"store_id,omitempty"`
+	PeriodStart      time.Time         `json:"period_start"`
+	PeriodEnd        time.Time         `json:"period_end"`
+	TransactionCount int               `json:"transaction_count"`
+	GrossSales       int               `json:"gross_sales"`
+	Discounts        int               `json:"discounts"`
+	NetSales         int               `json:"net_sales"`
+	RefundCount      int               `json:"refund_count"`
+	Refunds          int               `json:"refunds"`
+	VoidCount        int               `json:"void_count"`
+	Voids            int               `json:"voids"`
+	TotalRevenue     int               `json:"total_revenue"`
+	TaxRatePercent   int               `json:"tax_rate_percent"`
+	Tax              int               `json:"tax"`
+	FirstInvoice     string            `json:"first_invoice"`
+	LastInvoice      string            `json:"last_invoice"`
+	Payments         []RegisterPayment `json:"payments"`
+}
+
+const (
+	RegisterReportX = "X"
+	RegisterReportZ = "Z"
+)
+
+type RegisterPayment struct {
+	Method           string `json:"method"`
+	TransactionCount int    `json:"transaction_count"`
+	Amount           int    `json:"amount"`
+}
+
+const (
+	PriceListAudienceAll    = "all"
+	PriceListAudienceMember = "member"
+	PriceListAudienceStaff  = "staff"
+)
+
+// PriceList prices products for a group of buyers. StartTime and EndTime,
+// when set, limit it to a time of day ("HH:MM"); a window whose end is before
+// its start runs past midnight. A list only applies where it is cheaper than
+// the unit's own price and the store's price.
+type PriceList struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Audience   string          `json:"audience"`
+	MemberTier string          `json:"member_tier,omitempty"`
+	Priority   int             `json:"priority"`
+	StartTime  string          `json:"start_time,omitempty"`
+	EndTime    string          `json:"end_time,omitempty"`
+	Active     bool            `json:"active"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Items      []PriceListItem `json:"items,omitempty"`
+}
+
+// PriceListItem is the price per base unit once a line reaches MinQuantity
+// base units; several items for one product make quantity breaks.
+type PriceListItem struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name,omitempty"`
+	MinQuantity float64 `json:"min_quantity"`
+	Price       int     `json:"price"`
 }
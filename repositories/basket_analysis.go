@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"time"
+)
+
+// CountTransactions counts the sales made on the business days from
+// startDate to endDate that were not voided.
+func (r *TransactionRepository) CountTransactions(startDate, endDate time.Time, storeID *int) (int, error) {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return 0, err
+	}
+	from, to := day.Range(startDate, endDate)
+
+	var n int
+	err = r.db.QueryRow(
+		`SELECT COUNT(*) FROM transactions WHERE created_at >= $1 AND created_at < $2 AND voided_at IS NULL AND ($3::INT IS NULL OR store_id = $3)`,
+		from, to, storeID,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+	return n, nil
+}
+
+// GetBasketPairs returns pairs of products sold together in at least minCount
+// sales on the business days from startDate to endDate, leaving out voided
+// sales and refunded lines, with how many sales included each of them. With a productID only pairs
+// starting with that product are returned, otherwise each pair once, lowest
+// product ID first. Pairs are ordered by how often they sold together, then
+// by how far that beats chance.
+func (r *TransactionRepository) GetBasketPairs(startDate, endDate time.Time, storeID, productID *int, minCount, limit int) ([]models.BasketPair, error) {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return nil, err
+	}
+	from, to := day.Range(startDate, endDate)
+
+	rows, err := r.db.Query(
+		`WITH baskets AS (
+			SELECT DISTINCT td.transaction_id, td.product_id
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL AND `+netQuantitySQL+` > 0
+				AND ($3::INT IS NULL OR t.store_id = $3)
+		), sold AS (
+			SELECT product_id, COUNT(*) AS transactions FROM baskets GROUP BY product_id
+		), pairs AS (
+			SELECT a.product_id, b.product_id AS with_product_id, COUNT(*) AS transactions
+			FROM baskets a
+			JOIN baskets b ON b.transaction_id = a.transaction_id AND b.product_id <> a.product_id
+			WHERE CASE WHEN $4::INT IS NULL THEN a.product_id < b.product_id ELSE a.product_id = $4 END
+			GROUP BY a.product_id, b.product_id
+			HAVING COUNT(*) >= $5
+		)
+		SELECT pr.product_id, pa.name, pr.with_product_id, pb.name, pr.transactions, sa.transactions, sb.transactions
+		FROM pairs pr
+		JOIN sold sa ON sa.product_id = pr.product_id
+		JOIN sold sb ON sb.product_id = pr.with_product_id
+		JOIN products pa ON pa.id = pr.product_id
+		JOIN products pb ON pb.id = pr.with_product_id
+		ORDER BY pr.transactions DESC, pr.transactions::FLOAT8 / (sa.transactions * sb.transactions) DESC, pr.product_id, pr.with_product_id
+		LIMIT $6`,
+		from, to, storeID, productID, minCount, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get basket pairs: %w", err)
+	}
+	defer rows.Close()
+
+	pairs := []models.BasketPair{}
+	for rows.Next() {
+		var p models.BasketPair
+		if err := rows.Scan(&p.ProductID, &p.ProductName, &p.WithProductID, &p.WithProductName,
+			&p.Transactions, &p.ProductTransactions, &p.WithProductTransactions); err != nil {
+			return nil, fmt.Errorf("failed to scan basket pair: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+
+	return pairs, rows.Err()
+}
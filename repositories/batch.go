@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type batchPick struct {
+	batchID  int
+	quantity float64
+}
+
+// pickBatches takes quantity from the product's unexpired batches, earliest
+// expiry first, with batches that never expire used last. Expired batches are
+// never picked, so a sale that would need them fails.
+func pickBatches(tx *sql.Tx, productID int, storeID *int, productName string, quantity float64) ([]batchPick, error) {
+	rows, err := tx.Query(
+		`SELECT id, quantity FROM product_batches
+		WHERE product_id = $1 AND store_id IS NOT DISTINCT FROM $2 AND quantity > 0
+		AND (expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+		ORDER BY expiry_date NULLS LAST, id
+		FOR UPDATE`,
+		productID, storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batches for product %d: %w", productID, err)
+	}
+
+	var picks []batchPick
+	remaining := quantity
+	for rows.Next() && remaining > 0 {
+		var b batchPick
+		if err := rows.Scan(&b.batchID, &b.quantity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan batch: %w", err)
+		}
+		if b.quantity > remaining {
+			b.quantity = remaining
+		}
+		remaining = RoundQuantity(remaining - b.quantity)
+		picks = append(picks, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if remaining > 0 {
+		var expired float64
+		err := tx.QueryRow(
+			`SELECT COALESCE(SUM(quantity), 0) FROM product_batches
+			WHERE product_id = $1 AND store_id IS NOT DISTINCT FROM $2 AND expiry_date < CURRENT_DATE`,
+			productID, storeID,
+		).Scan(&expired)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get expired batches for product %d: %w", productID, err)
+		}
+		if expired > 0 {
+			return nil, fmt.Errorf("insufficient unexpired stock for product %s: available %g, requested %g (%g expired)", productName, RoundQuantity(quantity-remaining), quantity, expired)
+		}
+		return nil, fmt.Errorf("insufficient batch stock for product %s: available %g, requested %g", productName, RoundQuantity(quantity-remaining), quantity)
+	}
+
+	for _, p := range picks {
+		if _, err := tx.Exec(`UPDATE product_batches SET quantity = quantity - $1 WHERE id = $2`, p.quantity, p.batchID); err != nil {
+			return nil, fmt.Errorf("failed to update batch %d: %w", p.batchID, err)
+		}
+	}
+
+	return picks, nil
+}
+
+func insertBatchPicks(tx *sql.Tx, table, column string, parentID int, picks []batchPick) error {
+	for _, p := range picks {
+		_, err := tx.Exec(
+			fmt.Sprintf(`INSERT INTO %s (%s, batch_id, quantity) VALUES ($1, $2, $3)`, table, column),
+			parentID, p.batchID, p.quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record batch %d: %w", p.batchID, err)
+		}
+	}
+	return nil
+}
+
+func addBatch(tx *sql.Tx, productID int, storeID *int, batchCode string, expiryDate *string, quantity float64) (int, error) {
+	var id int
+	err := tx.QueryRow(
+		`INSERT INTO product_batches (product_id, store_id, batch_code, expiry_date, quantity) VALUES ($1, $2, $3, $4::DATE, $5) RETURNING id`,
+		productID, storeID, batchCode, expiryDate, quantity,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create batch for product %d: %w", productID, err)
+	}
+	return id, nil
+}
+
+// undatedBatchCode names the batch holding a tracked product's stock that was
+// set directly instead of received as a batch.
+const undatedBatchCode = "UNDATED"
+
+// syncBatches makes a tracked product's batches add up to stock again after
+// the stock was set directly, or tracking was turned on. Stock the batches
+// lack goes into an undated batch; stock they hold beyond it is written off
+// earliest expiry first, so expired batches go before any others.
+func syncBatches(tx *sql.Tx, productID int, storeID *int, stock float64) error {
+	var batched float64
+	err := tx.QueryRow(
+		`SELECT COALESCE(SUM(quantity), 0) FROM product_batches WHERE product_id = $1 AND store_id IS NOT DISTINCT FROM $2`,
+		productID, storeID,
+	).Scan(&batched)
+	if err != nil {
+		return fmt.Errorf("failed to get batches for product %d: %w", productID, err)
+	}
+
+	diff := RoundQuantity(stock - batched)
+	if diff > 0 {
+		_, err := addBatch(tx, productID, storeID, undatedBatchCode, nil, diff)
+		return err
+	}
+	if diff == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(
+		`SELECT id, quantity FROM product_batches
+		WHERE product_id = $1 AND store_id IS NOT DISTINCT FROM $2 AND quantity > 0
+		ORDER BY expiry_date NULLS LAST, id
+		FOR UPDATE`,
+		productID, storeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get batches for product %d: %w", productID, err)
+	}
+
+	var picks []batchPick
+	remaining := -diff
+	for rows.Next() && remaining > 0 {
+		var b batchPick
+		if err := rows.Scan(&b.batchID, &b.quantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan batch: %w", err)
+		}
+		b.quantity = min(b.quantity, remaining)
+		remaining = RoundQuantity(remaining - b.quantity)
+		picks = append(picks, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range picks {
+		if _, err := tx.Exec(`UPDATE product_batches SET quantity = quantity - $1 WHERE id = $2`, p.quantity, p.batchID); err != nil {
+			return fmt.Errorf("failed to update batch %d: %w", p.batchID, err)
+		}
+	}
+	return nil
+}
+
+// returnBatches puts refunded stock back into the batches the sale was
+// picked from, latest pick first (the reverse of pickBatches' order), so the
+// batches keep adding up to stock.
+func returnBatches(tx *sql.Tx, transactionDetailID int, quantity float64) error {
+	rows, err := tx.Query(
+		`SELECT tdb.batch_id, tdb.quantity
+		FROM transaction_detail_batches tdb
+		JOIN product_batches b ON b.id = tdb.batch_id
+		WHERE tdb.transaction_detail_id = $1
+		ORDER BY b.expiry_date DESC NULLS FIRST, b.id DESC`,
+		transactionDetailID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get batches for transaction detail %d: %w", transactionDetailID, err)
+	}
+
+	var picks []batchPick
+	for rows.Next() {
+		var b batchPick
+		if err := rows.Scan(&b.batchID, &b.quantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan batch: %w", err)
+		}
+		picks = append(picks, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, p := range picks {
+		if quantity <= 0 {
+			break
+		}
+		n := p.quantity
+		if n > quantity || i == len(picks)-1 {
+			n = quantity
+		}
+		if _, err := tx.Exec(`UPDATE product_batches SET quantity = quantity + $1 WHERE id = $2`, n, p.batchID); err != nil {
+			return fmt.Errorf("failed to update batch %d: %w", p.batchID, err)
+		}
+		quantity = RoundQuantity(quantity - n)
+	}
+	return nil
+}
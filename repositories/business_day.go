@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"andre_kasir_api/config"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+)
+
+// timezoneAliases maps the Indonesian zone abbreviations to their IANA names.
+var timezoneAliases = map[string]string{
+	"WIB":  "Asia/Jakarta",
+	"WITA": "Asia/Makassar",
+	"WIT":  "Asia/Jayapura",
+}
+
+var locations sync.Map
+
+// LoadStoreLocation resolves a STORE_TIMEZONE value: an IANA name or one of
+// WIB, WITA and WIT. An empty value means UTC.
+func LoadStoreLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if alias, ok := timezoneAliases[strings.ToUpper(name)]; ok {
+		name = alias
+	}
+	if name == "" {
+		name = "UTC"
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store time zone %q: %w", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// BusinessDay maps calendar dates to the span of time a store trades under
+// them. With a cutoff of 04:00 the business day for 1 March runs from 1 March
+// 04:00 to 2 March 04:00 in the store's time zone, so sales after midnight
+// still count towards the evening they belong to.
+type BusinessDay struct {
+	Location *time.Location
+	Cutoff   time.Duration
+}
+
+func NewBusinessDay(cfg *config.Config) (BusinessDay, error) {
+	loc, err := LoadStoreLocation(cfg.StoreTimezone)
+	if err != nil {
+		return BusinessDay{}, err
+	}
+
+	day := BusinessDay{Location: loc}
+	if cfg.BusinessDayCutoff != "" {
+		minutes, err := ParseClock(cfg.BusinessDayCutoff)
+		if err != nil {
+			return BusinessDay{}, fmt.Errorf("invalid business day cutoff: %w", err)
+		}
+		day.Cutoff = time.Duration(minutes) * time.Minute
+	}
+	return day, nil
+}
+
+// Start is the instant the business day for date's year, month and day
+// begins.
+func (d BusinessDay) Start(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, d.Location).Add(d.Cutoff)
+}
+
+// Range returns the half-open span [from, to) covering the business days
+// startDate through endDate.
+func (d BusinessDay) Range(startDate, endDate time.Time) (time.Time, time.Time) {
+	return d.Start(startDate), d.Start(endDate.AddDate(0, 0, 1))
+}
+
+// Date returns the business date at instant t, as midnight in the store's
+// time zone.
+func (d BusinessDay) Date(t time.Time) time.Time {
+	local := t.In(d.Location).Add(-d.Cutoff)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, d.Location)
+}
+
+// CutoffMinutes is the cutoff as whole minutes, for SQL that shifts
+// timestamps onto their business date.
+func (d BusinessDay) CutoffMinutes() int {
+	return int(d.Cutoff / time.Minute)
+}
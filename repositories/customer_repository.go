@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+func (r *CustomerRepository) GetAll(search string) ([]models.Customer, error) {
+	var query string
+	var args []interface{}
+
+	if search != "" {
+		query = `SELECT c.id, c.name, COALESCE(c.phone, ''), c.tier, c.points, c.credit_limit,
+			(SELECT COALESCE(SUM(rc.amount - rc.paid_amount), 0) FROM receivables rc WHERE rc.customer_id = c.id), c.created_at
+			FROM customers c
+			WHERE c.name ILIKE $1 OR c.phone ILIKE $1 ORDER BY c.id`
+		args = append(args, "%"+search+"%")
+	} else {
+		query = `SELECT c.id, c.name, COALESCE(c.phone, ''), c.tier, c.points, c.credit_limit,
+			(SELECT COALESCE(SUM(rc.amount - rc.paid_amount), 0) FROM receivables rc WHERE rc.customer_id = c.id), c.created_at
+			FROM customers c ORDER BY c.id`
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customers: %w", err)
+	}
+	defer rows.Close()
+
+	var customers []models.Customer
+	for rows.Next() {
+		var c models.Customer
+		if err := rows.Scan(&c.ID, &c.Name, &c.Phone, &c.Tier, &c.Points, &c.CreditLimit, &c.OutstandingCredit, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan customer: %w", err)
+		}
+		customers = append(customers, c)
+	}
+
+	return customers, rows.Err()
+}
+
+func (r *CustomerRepository) GetByID(id int) (*models.Customer, error) {
+	var c models.Customer
+	err := r.db.QueryRow(
+		`SELECT c.id, c.name, COALESCE(c.phone, ''), c.tier, c.points, c.credit_limit,
+		(SELECT COALESCE(SUM(rc.amount - rc.paid_amount), 0) FROM receivables rc WHERE rc.customer_id = c.id), c.created_at
+		FROM customers c WHERE c.id = $1`,
+		id,
+	).Scan(&c.ID, &c.Name, &c.Phone, &c.Tier, &c.Points, &c.CreditLimit, &c.OutstandingCredit, &c.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (r *CustomerRepository) Create(customer *models.Customer) error {
+	return r.db.QueryRow(
+		`INSERT INTO customers (name, phone, tier, credit_limit) VALUES ($1, NULLIF($2, ''), $3, $4) RETURNING id, points, created_at`,
+		customer.Name, customer.Phone, customer.Tier, customer.CreditLimit,
+	).Scan(&customer.ID, &customer.Points, &customer.CreatedAt)
+}
+
+func (r *CustomerRepository) Update(customer *models.Customer) error {
+	err := r.db.QueryRow(
+		`UPDATE customers SET name = $1, phone = NULLIF($2, ''), tier = $3, credit_limit = $4 WHERE id = $5
+		RETURNING points, (SELECT COALESCE(SUM(amount - paid_amount), 0) FROM receivables WHERE customer_id = $5), created_at`,
+		customer.Name, customer.Phone, customer.Tier, customer.CreditLimit, customer.ID,
+	).Scan(&customer.Points, &customer.OutstandingCredit, &customer.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("customer not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update customer: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CustomerRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM customers WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete customer: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("customer not found")
+	}
+
+	return nil
+}
+
+func (r *CustomerRepository) GetTiers() ([]models.MemberTier, error) {
+	rows, err := r.db.Query(`SELECT name, multiplier_percent FROM member_tiers ORDER BY multiplier_percent`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member tiers: %w", err)
+	}
+	defer rows.Close()
+
+	var tiers []models.MemberTier
+	for rows.Next() {
+		var t models.MemberTier
+		if err := rows.Scan(&t.Name, &t.MultiplierPercent); err != nil {
+			return nil, fmt.Errorf("failed to scan member tier: %w", err)
+		}
+		tiers = append(tiers, t)
+	}
+
+	return tiers, rows.Err()
+}
+
+func (r *CustomerRepository) GetTransactions(customerID int) ([]models.Transaction, error) {
+	rows, err := r.db.Query(
+		`SELECT id, COALESCE(invoice_number, ''), store_id, total_amount, customer_id, points_earned, points_redeemed, change_amount, created_at
+		FROM transactions WHERE customer_id = $1 ORDER BY created_at DESC, id DESC`,
+		customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(&t.ID, &t.InvoiceNumber, &t.StoreID, &t.TotalAmount, &t.CustomerID, &t.PointsEarned, &t.PointsRedeemed, &t.Change, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range transactions {
+		details, err := getTransactionDetails(r.db, transactions[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		transactions[i].Details = details
+	}
+
+	return transactions, nil
+}
+
+func (r *CustomerRepository) GetPointsLedger(customerID int) ([]models.PointsLedgerEntry, error) {
+	rows, err := r.db.Query(
+		`SELECT id, customer_id, transaction_id, points, balance, COALESCE(description, ''), created_at
+		FROM customer_points_ledger WHERE customer_id = $1 ORDER BY created_at DESC, id DESC`,
+		customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get points ledger: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.PointsLedgerEntry
+	for rows.Next() {
+		var e models.PointsLedgerEntry
+		if err := rows.Scan(&e.ID, &e.CustomerID, &e.TransactionID, &e.Points, &e.Balance, &e.Description, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan points ledger entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
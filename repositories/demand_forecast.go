@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"time"
+)
+
+// GetDailyProductSales returns the quantity of each product sold on each
+// business day from startDate to endDate, ordered by product and date. Days a
+// product did not sell are left out.
+func (r *TransactionRepository) GetDailyProductSales(startDate, endDate time.Time, storeID, productID *int) ([]models.DailyProductSales, error) {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return nil, err
+	}
+	split, err := r.splitSales(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT product_id, business_date, SUM(quantity)
+		FROM (
+			SELECT product_id, business_date, quantity FROM daily_sales_summary
+			WHERE product_id IS NOT NULL AND business_date BETWEEN $5::DATE AND $6::DATE
+				AND ($7::INT IS NULL OR store_id = $7) AND ($8::INT IS NULL OR product_id = $8)
+			UNION ALL
+			SELECT td.product_id, `+businessDateSQL+`, `+netQuantitySQL+`
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL
+				AND ($7::INT IS NULL OR t.store_id = $7) AND ($8::INT IS NULL OR td.product_id = $8)
+		) sales
+		GROUP BY product_id, business_date
+		ORDER BY product_id, business_date`,
+		split.LiveFrom, split.LiveTo, day.Location.String(), day.CutoffMinutes(),
+		split.SummaryFrom, split.SummaryTo, storeID, productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily product sales: %w", err)
+	}
+	defer rows.Close()
+
+	sales := []models.DailyProductSales{}
+	for rows.Next() {
+		var s models.DailyProductSales
+		if err := rows.Scan(&s.ProductID, &s.Date, &s.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan daily product sales: %w", err)
+		}
+		sales = append(sales, s)
+	}
+
+	return sales, rows.Err()
+}
+
+// GetForecastProducts returns every product with its stock and reorder
+// settings, or the products a store carries with that store's stock, ready
+// for a demand forecast.
+func (r *TransactionRepository) GetForecastProducts(storeID, productID *int) ([]models.ProductForecast, error) {
+	rows, err := r.db.Query(
+		`SELECT p.id, p.name, CASE WHEN $1::INT IS NULL THEN p.stock ELSE sp.stock END, p.min_stock, p.reorder_qty
+		FROM products p
+		LEFT JOIN store_products sp ON sp.product_id = p.id AND sp.store_id = $1
+		WHERE ($1::INT IS NULL OR sp.product_id IS NOT NULL) AND ($2::INT IS NULL OR p.id = $2)
+		ORDER BY p.id`,
+		storeID, productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forecast products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.ProductForecast{}
+	for rows.Next() {
+		var p models.ProductForecast
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.Stock, &p.MinStock, &p.ReorderQty); err != nil {
+			return nil, fmt.Errorf("failed to scan forecast product: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
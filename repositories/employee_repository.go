@@ -0,0 +1,289 @@
+package repositories
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type EmployeeRepository struct {
+	db  *sql.DB
+	cfg *config.Config
+}
+
+func NewEmployeeRepository(db *sql.DB, cfg *config.Config) *EmployeeRepository {
+	return &EmployeeRepository{db: db, cfg: cfg}
+}
+
+func (r *EmployeeRepository) GetAll() ([]models.Employee, error) {
+	rows, err := r.db.Query(`SELECT id, name, role, active, created_at FROM employees ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employees: %w", err)
+	}
+	defer rows.Close()
+
+	var employees []models.Employee
+	for rows.Next() {
+		var e models.Employee
+		if err := rows.Scan(&e.ID, &e.Name, &e.Role, &e.Active, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan employee: %w", err)
+		}
+		employees = append(employees, e)
+	}
+
+	return employees, rows.Err()
+}
+
+func (r *EmployeeRepository) GetByID(id int) (*models.Employee, error) {
+	var e models.Employee
+	err := r.db.QueryRow(
+		`SELECT id, name, role, active, created_at FROM employees WHERE id = $1`,
+		id,
+	).Scan(&e.ID, &e.Name, &e.Role, &e.Active, &e.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employee: %w", err)
+	}
+
+	return &e, nil
+}
+
+func (r *EmployeeRepository) Create(employee *models.Employee) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hash, lookup, err := newPINHash(tx, r.cfg.PINLookupKey, employee.PIN, 0)
+	if err != nil {
+		return err
+	}
+	employee.PIN = ""
+
+	err = tx.QueryRow(
+		`INSERT INTO employees (name, role, pin_salt, pin_hash, pin_lookup) VALUES ($1, $2, '', $3, $4) RETURNING id, active, created_at`,
+		employee.Name, employee.Role, hash, lookup,
+	).Scan(&employee.ID, &employee.Active, &employee.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create employee: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *EmployeeRepository) Update(employee *models.Employee) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hash, lookup string
+	if employee.PIN != "" {
+		if hash, lookup, err = newPINHash(tx, r.cfg.PINLookupKey, employee.PIN, employee.ID); err != nil {
+			return err
+		}
+		employee.PIN = ""
+	}
+
+	err = tx.QueryRow(
+		`UPDATE employees SET name = $1, role = $2, active = $3,
+			pin_salt = CASE WHEN $4 = '' THEN pin_salt ELSE '' END, pin_hash = COALESCE(NULLIF($4, ''), pin_hash),
+			pin_lookup = COALESCE(NULLIF($5, ''), pin_lookup)
+		WHERE id = $6 RETURNING created_at`,
+		employee.Name, employee.Role, employee.Active, hash, lookup, employee.ID,
+	).Scan(&employee.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("employee not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update employee: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// HasActiveManager reports whether any active employee is a manager. Until
+// one is, employees can be added without a manager's approval.
+func (r *EmployeeRepository) HasActiveManager() (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM employees WHERE role = $1 AND active)`, models.EmployeeRoleManager).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to get managers: %w", err)
+	}
+	return exists, nil
+}
+
+// CheckManagerPIN fails unless pin belongs to an active manager.
+func (r *EmployeeRepository) CheckManagerPIN(pin string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := findManagerByPIN(tx, r.cfg.PINLookupKey, pin); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *EmployeeRepository) Delete(id int) error {
+	result, err := r.db.Exec(`UPDATE employees SET active = FALSE WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete employee: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("employee not found")
+	}
+
+	return nil
+}
+
+func findManagerByPIN(tx *sql.Tx, key, pin string) (int, error) {
+	id, err := findEmployeeByPIN(tx, key, pin, models.EmployeeRoleManager)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("invalid manager PIN")
+	}
+	return id, nil
+}
+
+func findStaffByPIN(tx *sql.Tx, key, pin string) (int, error) {
+	id, err := findEmployeeByPIN(tx, key, pin, "")
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("invalid staff PIN")
+	}
+	return id, nil
+}
+
+// findEmployeeByPIN returns the active employee with pin, limited to role
+// unless it is empty, or 0 when there is none. The PIN's lookup key finds the
+// one employee whose bcrypt hash is worth checking; only employees whose PIN
+// has not been used since lookup keys were added are checked one by one, and
+// they get their key on a match.
+func findEmployeeByPIN(tx *sql.Tx, key, pin, role string) (int, error) {
+	lookup := pinLookup(key, pin)
+	rows, err := tx.Query(
+		`SELECT id, pin_salt, pin_hash, pin_lookup IS NULL FROM employees
+		WHERE (pin_lookup = $1 OR pin_lookup IS NULL) AND ($2 = '' OR role = $2) AND active
+		ORDER BY pin_lookup IS NULL, id`,
+		lookup, role,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get employees: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var salt, hash string
+		var unkeyed bool
+		if err := rows.Scan(&id, &salt, &hash, &unkeyed); err != nil {
+			return 0, fmt.Errorf("failed to scan employee: %w", err)
+		}
+		if !pinMatches(salt, hash, pin) {
+			continue
+		}
+		rows.Close()
+
+		if salt != "" || unkeyed {
+			if err := rehashPIN(tx, id, salt, hash, pin, lookup); err != nil {
+				return 0, err
+			}
+		}
+		return id, nil
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+// newPINHash hashes pin for the employee with id (0 for a new one) and gives
+// its lookup key, refusing a PIN another active employee already uses: PINs
+// alone tell employees apart at the till. The lock keeps two requests from
+// taking the same PIN.
+func newPINHash(tx *sql.Tx, key, pin string, id int) (string, string, error) {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('employee_pins:' || current_tenant_id()))`); err != nil {
+		return "", "", fmt.Errorf("failed to lock employee PINs: %w", err)
+	}
+	owner, err := findEmployeeByPIN(tx, key, pin, "")
+	if err != nil {
+		return "", "", err
+	}
+	if owner != 0 && owner != id {
+		return "", "", fmt.Errorf("pin is already used by another employee")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash PIN: %w", err)
+	}
+	return string(hash), pinLookup(key, pin), nil
+}
+
+// pinLookup is the indexed key employees are found by from their PIN alone.
+// It is keyed with PIN_LOOKUP_KEY so a copy of the database does not give the
+// PINs away; after changing the key, clear pin_lookup and employees get the
+// new one the next time they enter their PIN.
+func pinLookup(key, pin string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(pin))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// pinMatches checks pin against a bcrypt hash, or against a salted SHA-256
+// hash when salt is set, as PINs were stored before bcrypt.
+func pinMatches(salt, hash, pin string) bool {
+	if salt == "" {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pin)) == nil
+	}
+	sum := sha256.Sum256([]byte(salt + pin))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(hash)) == 1
+}
+
+// rehashPIN stores the lookup key of an employee found by checking their
+// hash directly, moving a PIN that still has a SHA-256 hash to bcrypt on the
+// way.
+func rehashPIN(tx *sql.Tx, id int, salt, hash, pin, lookup string) error {
+	if salt != "" {
+		rehashed, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash PIN: %w", err)
+		}
+		hash = string(rehashed)
+	}
+	if _, err := tx.Exec(`UPDATE employees SET pin_salt = '', pin_hash = $1, pin_lookup = $2 WHERE id = $3`, hash, lookup, id); err != nil {
+		return fmt.Errorf("failed to update PIN hash: %w", err)
+	}
+	return nil
+}
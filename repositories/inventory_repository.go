@@ -0,0 +1,295 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// GetStockLevels returns stock, reorder settings and units sold since the
+// given time for every product, or only those at or below their minimum stock
+// when lowOnly is set. With a store it reads that store's stock and sales.
+func (r *InventoryRepository) GetStockLevels(storeID *int, since time.Time, lowOnly bool) ([]models.LowStockItem, error) {
+	var rows *sql.Rows
+	var err error
+	if storeID == nil {
+		rows, err = r.db.Query(
+			`SELECT p.id, p.name, p.stock, p.min_stock, p.reorder_qty, COALESCE(s.sold, 0)
+			FROM products p
+			LEFT JOIN (
+				SELECT td.product_id, SUM(`+netQuantitySQL+`) AS sold
+				FROM transaction_details td
+				JOIN transactions t ON td.transaction_id = t.id
+				WHERE t.created_at >= $1 AND t.voided_at IS NULL AND t.store_id IS NULL
+				GROUP BY td.product_id
+			) s ON s.product_id = p.id
+			WHERE NOT $2 OR (p.min_stock > 0 AND p.stock <= p.min_stock)
+			ORDER BY p.stock - p.min_stock, p.id`,
+			since, lowOnly,
+		)
+	} else {
+		rows, err = r.db.Query(
+			`SELECT p.id, p.name, sp.stock, p.min_stock, p.reorder_qty, COALESCE(s.sold, 0)
+			FROM store_products sp
+			JOIN products p ON sp.product_id = p.id
+			LEFT JOIN (
+				SELECT td.product_id, SUM(`+netQuantitySQL+`) AS sold
+				FROM transaction_details td
+				JOIN transactions t ON td.transaction_id = t.id
+				WHERE t.created_at >= $1 AND t.voided_at IS NULL AND t.store_id = $3
+				GROUP BY td.product_id
+			) s ON s.product_id = p.id
+			WHERE sp.store_id = $3 AND (NOT $2 OR (p.min_stock > 0 AND sp.stock <= p.min_stock))
+			ORDER BY sp.stock - p.min_stock, p.id`,
+			since, lowOnly, *storeID,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock levels: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.LowStockItem
+	for rows.Next() {
+		item := models.LowStockItem{StoreID: storeID}
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Stock, &item.MinStock, &item.ReorderQty, &item.SoldLastDays); err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *InventoryRepository) GetBatches(productID, storeID *int) ([]models.ProductBatch, error) {
+	rows, err := r.db.Query(
+		`SELECT b.id, b.product_id, p.name, b.store_id, b.batch_code, to_char(b.expiry_date, 'YYYY-MM-DD'), b.quantity, b.received_at
+		FROM product_batches b
+		JOIN products p ON b.product_id = p.id
+		WHERE b.quantity > 0
+		AND ($1::INT IS NULL OR b.product_id = $1)
+		AND ($2::INT IS NULL OR b.store_id = $2)
+		ORDER BY b.product_id, b.expiry_date NULLS LAST, b.id`,
+		productID, storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []models.ProductBatch
+	for rows.Next() {
+		var b models.ProductBatch
+		if err := rows.Scan(&b.ID, &b.ProductID, &b.ProductName, &b.StoreID, &b.BatchCode, &b.ExpiryDate, &b.Quantity, &b.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan batch: %w", err)
+		}
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
+
+// ReceiveBatch books a delivery of a tracked product as a new batch and adds
+// it to the product's stock, or the store's stock when the batch has a store.
+// Quantities in another unit are converted to the base unit.
+func (r *InventoryRepository) ReceiveBatch(req *models.ReceiveBatchRequest) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name, baseUnit string
+	var trackExpiry bool
+	var precision int
+	err = tx.QueryRow(
+		`SELECT name, track_expiry, base_unit, quantity_precision FROM products WHERE id = $1 FOR UPDATE`,
+		req.ProductID,
+	).Scan(&name, &trackExpiry, &baseUnit, &precision)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("product with ID %d not found", req.ProductID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get product %d: %w", req.ProductID, err)
+	}
+	if !trackExpiry {
+		return 0, fmt.Errorf("product %s does not track expiry; enable track_expiry first", name)
+	}
+
+	unit, err := resolveUnit(tx, req.ProductID, req.Unit, baseUnit, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckPrecision(req.Quantity, precision, name); err != nil {
+		return 0, err
+	}
+	quantity := RoundQuantity(req.Quantity * float64(unit.factor))
+
+	if req.StoreID == nil {
+		_, err = tx.Exec(`UPDATE products SET stock = stock + $1 WHERE id = $2`, quantity, req.ProductID)
+	} else {
+		if _, err := storeCode(tx, *req.StoreID); err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO store_products (store_id, product_id, stock) VALUES ($1, $2, $3)
+			ON CONFLICT (store_id, product_id) DO UPDATE SET stock = store_products.stock + EXCLUDED.stock`,
+			*req.StoreID, req.ProductID, quantity,
+		)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to update stock for product %d: %w", req.ProductID, err)
+	}
+
+	batchID, err := addBatch(tx, req.ProductID, req.StoreID, req.BatchCode, req.ExpiryDate, quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := recordMovement(tx, req.ProductID, req.StoreID, quantity, "receive", "batch", &batchID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return batchID, nil
+}
+
+// ReceiveStock adds a delivery of any product to its stock, or the store's
+// stock when the request has a store. A product that tracks expiry gets the
+// delivery as an undated batch; ReceiveBatch books one with its expiry date.
+func (r *InventoryRepository) ReceiveStock(req *models.StockChangeRequest) (*models.StockChange, error) {
+	return r.changeStock(req, "receive", func(stock, quantity float64) float64 { return stock + quantity })
+}
+
+// CountStock sets a product's stock, or the store's, to what a stocktake
+// counted and records the difference as a stock movement.
+func (r *InventoryRepository) CountStock(req *models.StockChangeRequest) (*models.StockChange, error) {
+	return r.changeStock(req, "stocktake", func(_, quantity float64) float64 { return quantity })
+}
+
+// changeStock converts the request's quantity to the base unit and sets the
+// stock to what apply makes of the current stock and that quantity.
+func (r *InventoryRepository) changeStock(req *models.StockChangeRequest, reason string, apply func(stock, quantity float64) float64) (*models.StockChange, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if req.StoreID != nil {
+		if _, err := storeCode(tx, *req.StoreID); err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO store_products (store_id, product_id, stock) SELECT $1, id, 0 FROM products WHERE id = $2
+			ON CONFLICT (store_id, product_id) DO NOTHING`,
+			*req.StoreID, req.ProductID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add product %d to store %d: %w", req.ProductID, *req.StoreID, err)
+		}
+	}
+
+	product, err := lockProductStock(tx, req.ProductID, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := resolveUnit(tx, req.ProductID, req.Unit, product.baseUnit, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckPrecision(req.Quantity, product.precision, product.name); err != nil {
+		return nil, err
+	}
+	stock := RoundQuantity(apply(product.stock, RoundQuantity(req.Quantity*float64(unit.factor))))
+	change := RoundQuantity(stock - product.stock)
+
+	if req.StoreID == nil {
+		_, err = tx.Exec(`UPDATE products SET stock = $1 WHERE id = $2`, stock, req.ProductID)
+	} else {
+		_, err = tx.Exec(`UPDATE store_products SET stock = $1 WHERE product_id = $2 AND store_id = $3`, stock, req.ProductID, *req.StoreID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update stock for product %d: %w", req.ProductID, err)
+	}
+
+	if product.trackExpiry {
+		if err := syncBatches(tx, req.ProductID, req.StoreID, stock); err != nil {
+			return nil, err
+		}
+	}
+	if change != 0 {
+		if err := recordMovement(tx, req.ProductID, req.StoreID, change, reason, "", nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &models.StockChange{ProductID: req.ProductID, StoreID: req.StoreID, Stock: stock, Change: change}, nil
+}
+
+func (r *InventoryRepository) GetBatch(id int) (*models.ProductBatch, error) {
+	var b models.ProductBatch
+	err := r.db.QueryRow(
+		`SELECT b.id, b.product_id, p.name, b.store_id, b.batch_code, to_char(b.expiry_date, 'YYYY-MM-DD'), b.quantity, b.received_at
+		FROM product_batches b
+		JOIN products p ON b.product_id = p.id
+		WHERE b.id = $1`,
+		id,
+	).Scan(&b.ID, &b.ProductID, &b.ProductName, &b.StoreID, &b.BatchCode, &b.ExpiryDate, &b.Quantity, &b.ReceivedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	return &b, nil
+}
+
+// GetExpiring lists batches still holding stock that expire within days,
+// including those already expired.
+func (r *InventoryRepository) GetExpiring(storeID *int, days int) ([]models.ExpiringBatch, error) {
+	rows, err := r.db.Query(
+		`SELECT b.id, b.product_id, p.name, b.store_id, b.batch_code, to_char(b.expiry_date, 'YYYY-MM-DD'), b.quantity, b.received_at,
+			b.expiry_date - CURRENT_DATE
+		FROM product_batches b
+		JOIN products p ON b.product_id = p.id
+		WHERE b.quantity > 0 AND b.expiry_date IS NOT NULL AND b.expiry_date <= CURRENT_DATE + $1::INT
+		AND ($2::INT IS NULL OR b.store_id = $2)
+		ORDER BY b.expiry_date, b.id`,
+		days, storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []models.ExpiringBatch
+	for rows.Next() {
+		var b models.ExpiringBatch
+		if err := rows.Scan(&b.ID, &b.ProductID, &b.ProductName, &b.StoreID, &b.BatchCode, &b.ExpiryDate, &b.Quantity, &b.ReceivedAt, &b.DaysLeft); err != nil {
+			return nil, fmt.Errorf("failed to scan expiring batch: %w", err)
+		}
+		b.Expired = b.DaysLeft < 0
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
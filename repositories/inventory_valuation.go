@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+// EachInventoryValue calls fn for every product and location holding stock at
+// asOf, ordered by groupBy. Quantities are today's stock with every movement
+// since asOf taken back out, and each product is costed at the cost price it
+// had at asOf. Stock kept on the product itself rather than in a store is
+// reported under the configured store name.
+func (r *TransactionRepository) EachInventoryValue(asOf time.Time, storeID *int, groupBy string, fn func(models.InventoryValuationItem) error) error {
+	order := `category, p.category_id`
+	if groupBy == models.ValuationByStore {
+		order = `v.store_id NULLS FIRST`
+	}
+
+	rows, err := r.db.Query(
+		`WITH holdings AS (
+			SELECT id AS product_id, NULL::INT AS store_id, stock FROM products WHERE $2::INT IS NULL
+			UNION ALL
+			SELECT product_id, store_id, stock FROM store_products WHERE $2::INT IS NULL OR store_id = $2
+		), since AS (
+			SELECT product_id, store_id, SUM(quantity) AS quantity
+			FROM stock_movements
+			WHERE created_at >= $1
+			GROUP BY product_id, store_id
+		), valued AS (
+			SELECT h.product_id, h.store_id, h.stock - COALESCE(s.quantity, 0) AS quantity,
+				COALESCE((SELECT pc.cost_price FROM product_costs pc
+					WHERE pc.product_id = h.product_id AND pc.effective_from < $1
+					ORDER BY pc.effective_from DESC, pc.id DESC LIMIT 1), 0) AS unit_cost
+			FROM holdings h
+			LEFT JOIN since s ON s.product_id = h.product_id AND s.store_id IS NOT DISTINCT FROM h.store_id
+		)
+		SELECT v.product_id, p.name, p.category_id, COALESCE(c.name, 'Uncategorized') AS category,
+			v.store_id, COALESCE(st.name, $3), v.quantity, v.unit_cost
+		FROM valued v
+		JOIN products p ON p.id = v.product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		LEFT JOIN stores st ON st.id = v.store_id
+		WHERE v.quantity <> 0
+		ORDER BY `+order+`, p.name, p.id, v.store_id NULLS FIRST`,
+		asOf, storeID, r.cfg.StoreName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get inventory valuation: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.InventoryValuationItem
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.CategoryID, &item.Category, &item.StoreID, &item.Store,
+			&item.Quantity, &item.UnitCost); err != nil {
+			return fmt.Errorf("failed to scan inventory valuation: %w", err)
+		}
+		item.Value = int(math.Round(item.Quantity * float64(item.UnitCost)))
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func FormatInvoiceNumber(format, storeCode string, date time.Time, seq, digits int) string {
+	return strings.NewReplacer(
+		"{STORE}", storeCode,
+		"{YYYY}", date.Format("2006"),
+		"{YY}", date.Format("06"),
+		"{MM}", date.Format("01"),
+		"{DD}", date.Format("02"),
+		"{SEQ}", fmt.Sprintf("%0*d", digits, seq),
+	).Replace(format)
+}
+
+// InvoiceSequence is the counter a format numbers its invoices from: the
+// store's and the business day's, but shared by every store when the format
+// has no {STORE}, and by a whole month, year or all time when it leaves out
+// the day, month or year. config.Validate refuses formats that would repeat
+// a number, such as {DD} without {MM} and a year.
+func InvoiceSequence(format, storeCode string, date time.Time) (string, time.Time) {
+	if !strings.Contains(format, "{STORE}") {
+		storeCode = ""
+	}
+
+	year, month, day := date.Date()
+	switch {
+	case strings.Contains(format, "{DD}"):
+	case strings.Contains(format, "{MM}"):
+		day = 1
+	case strings.Contains(format, "{YYYY}"), strings.Contains(format, "{YY}"):
+		month, day = time.January, 1
+	default:
+		year, month, day = 1, time.January, 1
+	}
+	return storeCode, time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// nextInvoiceNumber bumps the counter InvoiceSequence picks inside the
+// checkout transaction. The counter row stays locked until commit and is
+// rolled back with a failed checkout, so numbers are never skipped.
+func nextInvoiceNumber(tx *sql.Tx, storeCode string, date time.Time) (int, error) {
+	var seq int
+	err := tx.QueryRow(
+		`INSERT INTO invoice_sequences (store_code, business_date, last_number) VALUES ($1, $2, 1)
+		ON CONFLICT (tenant_id, store_code, business_date) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`,
+		storeCode, date.Format("2006-01-02"),
+	).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+	return seq, nil
+}
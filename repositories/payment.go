@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type checkoutCustomer struct {
+	id                int
+	tier              string
+	points            int
+	multiplierPercent int
+	creditLimit       int
+	outstandingCredit int
+}
+
+func lockCustomer(tx *sql.Tx, customerID int) (*checkoutCustomer, error) {
+	c := &checkoutCustomer{id: customerID}
+	err := tx.QueryRow(
+		`SELECT c.tier, c.points, mt.multiplier_percent, c.credit_limit,
+		(SELECT COALESCE(SUM(rc.amount - rc.paid_amount), 0) FROM receivables rc WHERE rc.customer_id = c.id)
+		FROM customers c
+		JOIN member_tiers mt ON c.tier = mt.name
+		WHERE c.id = $1
+		FOR UPDATE OF c`,
+		customerID,
+	).Scan(&c.tier, &c.points, &c.multiplierPercent, &c.creditLimit, &c.outstandingCredit)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("customer with ID %d not found", customerID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer %d: %w", customerID, err)
+	}
+
+	return c, nil
+}
+
+func resolvePayments(totalAmount int, requested []models.CheckoutPayment) ([]models.TransactionPayment, int, error) {
+	if len(requested) == 0 {
+		return []models.TransactionPayment{{Method: models.PaymentMethodCash, Amount: totalAmount}}, 0, nil
+	}
+
+	var paid, cash int
+	payments := make([]models.TransactionPayment, 0, len(requested))
+	for _, p := range requested {
+		switch p.Method {
+		case models.PaymentMethodCash, models.PaymentMethodDebit, models.PaymentMethodQRIS,
+			models.PaymentMethodTransfer, models.PaymentMethodPoints, models.PaymentMethodCredit,
+			models.PaymentMethodVoucher, models.PaymentMethodGiftCard:
+		default:
+			return nil, 0, fmt.Errorf("unsupported payment method %q", p.Method)
+		}
+		if p.Amount <= 0 {
+			return nil, 0, fmt.Errorf("payment amount for %s must be greater than 0", p.Method)
+		}
+
+		if (p.Method == models.PaymentMethodVoucher || p.Method == models.PaymentMethodGiftCard) && p.Reference == "" {
+			return nil, 0, fmt.Errorf("reference is required for %s payments", p.Method)
+		}
+
+		paid += p.Amount
+		if p.Method == models.PaymentMethodCash {
+			cash += p.Amount
+		}
+		payments = append(payments, models.TransactionPayment{
+			Method:    p.Method,
+			Amount:    p.Amount,
+			Reference: p.Reference,
+		})
+	}
+
+	if paid < totalAmount {
+		return nil, 0, fmt.Errorf("insufficient payment: total %d, paid %d", totalAmount, paid)
+	}
+
+	change := paid - totalAmount
+	if change > cash {
+		return nil, 0, fmt.Errorf("non-cash payments exceed total by %d", change-cash)
+	}
+
+	// Change is handed back from the cash tender, so record what was actually kept.
+	for i := len(payments) - 1; i >= 0 && change > 0; i-- {
+		if payments[i].Method == models.PaymentMethodCash {
+			payments[i].Amount -= change
+			break
+		}
+	}
+
+	return payments, change, nil
+}
+
+func paymentTotal(payments []models.TransactionPayment, method string) int {
+	var total int
+	for _, p := range payments {
+		if p.Method == method {
+			total += p.Amount
+		}
+	}
+	return total
+}
+
+// PointsEarningAmount is the part of a sale that earns loyalty points: what
+// was paid for it in money, including gift cards bought with money. Points,
+// vouchers and credit earn nothing; credit is not paid yet.
+func PointsEarningAmount(payments []models.TransactionPayment) int {
+	var total int
+	for _, p := range payments {
+		switch p.Method {
+		case models.PaymentMethodPoints, models.PaymentMethodVoucher, models.PaymentMethodCredit:
+		default:
+			total += p.Amount
+		}
+	}
+	return total
+}
+
+func insertPayments(tx *sql.Tx, transactionID int, payments []models.TransactionPayment) error {
+	for i := range payments {
+		err := tx.QueryRow(
+			`INSERT INTO transaction_payments (transaction_id, method, amount, reference) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id`,
+			transactionID, payments[i].Method, payments[i].Amount, payments[i].Reference,
+		).Scan(&payments[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction payment: %w", err)
+		}
+		payments[i].TransactionID = transactionID
+	}
+	return nil
+}
+
+func addPoints(tx *sql.Tx, customerID int, transactionID *int, points int, description string) error {
+	var balance int
+	err := tx.QueryRow(
+		`UPDATE customers SET points = points + $1 WHERE id = $2 RETURNING points`,
+		points, customerID,
+	).Scan(&balance)
+	if err != nil {
+		return fmt.Errorf("failed to update customer points: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO customer_points_ledger (customer_id, transaction_id, points, balance, description) VALUES ($1, $2, $3, $4, $5)`,
+		customerID, transactionID, points, balance, description,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create points ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+func createReceivable(tx *sql.Tx, customerID, transactionID, amount int) error {
+	_, err := tx.Exec(
+		`INSERT INTO receivables (customer_id, transaction_id, amount) VALUES ($1, $2, $3)`,
+		customerID, transactionID, amount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create receivable: %w", err)
+	}
+	return nil
+}
+
+type voucherRedemption struct {
+	voucherID int
+	amount    int
+}
+
+func redeemVouchers(tx *sql.Tx, payments []models.TransactionPayment, now time.Time) ([]voucherRedemption, error) {
+	var redemptions []voucherRedemption
+	for _, p := range payments {
+		if p.Method != models.PaymentMethodVoucher && p.Method != models.PaymentMethodGiftCard {
+			continue
+		}
+
+		var id, balance, uses int
+		var voucherType string
+		var maxUses sql.NullInt64
+		var expiresAt sql.NullTime
+		var active bool
+		err := tx.QueryRow(
+			`SELECT id, type, balance, max_uses, uses, expires_at, active FROM vouchers WHERE code = $1 FOR UPDATE`,
+			strings.ToUpper(p.Reference),
+		).Scan(&id, &voucherType, &balance, &maxUses, &uses, &expiresAt, &active)
+
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s %s not found", p.Method, p.Reference)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s %s: %w", p.Method, p.Reference, err)
+		}
+
+		switch {
+		case voucherType != p.Method:
+			return nil, fmt.Errorf("%s is not a %s", p.Reference, p.Method)
+		case !active:
+			return nil, fmt.Errorf("%s %s is no longer active", p.Method, p.Reference)
+		case expiresAt.Valid && !now.Before(expiresAt.Time):
+			return nil, fmt.Errorf("%s %s expired at %s", p.Method, p.Reference, expiresAt.Time.Format("2006-01-02"))
+		case maxUses.Valid && int64(uses) >= maxUses.Int64:
+			return nil, fmt.Errorf("%s %s has already been used", p.Method, p.Reference)
+		case balance < p.Amount:
+			return nil, fmt.Errorf("insufficient %s balance for %s: available %d, requested %d", p.Method, p.Reference, balance, p.Amount)
+		}
+
+		_, err = tx.Exec(
+			`UPDATE vouchers SET balance = balance - $1, uses = uses + 1 WHERE id = $2`,
+			p.Amount, id,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update %s %s: %w", p.Method, p.Reference, err)
+		}
+
+		redemptions = append(redemptions, voucherRedemption{voucherID: id, amount: p.Amount})
+	}
+
+	return redemptions, nil
+}
+
+func insertVoucherRedemptions(tx *sql.Tx, transactionID int, redemptions []voucherRedemption) error {
+	for _, v := range redemptions {
+		_, err := tx.Exec(
+			`INSERT INTO voucher_redemptions (voucher_id, transaction_id, amount) VALUES ($1, $2, $3)`,
+			v.voucherID, transactionID, v.amount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create voucher redemption: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PriceListEntry is one quantity break of a price list for a product.
+type PriceListEntry struct {
+	PriceListID int
+	Name        string
+	Audience    string
+	MemberTier  string
+	Priority    int
+	StartTime   string
+	EndTime     string
+	MinQuantity float64
+	Price       int
+}
+
+// PriceBuyer describes who is buying, as far as price lists care.
+type PriceBuyer struct {
+	Member     bool
+	MemberTier string
+	Staff      bool
+}
+
+// ChoosePriceList picks the price for a line of quantity base units sold at
+// at. Lists the buyer is not eligible for, that are outside their time
+// window, or whose breaks the quantity does not reach are ignored. Each list
+// offers its largest break the quantity reaches; the list with the highest
+// priority wins, the lower price breaking ties. It returns nil when no list
+// applies.
+func ChoosePriceList(entries []PriceListEntry, buyer PriceBuyer, quantity float64, at time.Time) *PriceListEntry {
+	best := make(map[int]*PriceListEntry)
+	for i := range entries {
+		e := &entries[i]
+		if !e.eligible(buyer) || !InTimeWindow(e.StartTime, e.EndTime, at) || e.MinQuantity > quantity {
+			continue
+		}
+		if cur := best[e.PriceListID]; cur == nil || e.MinQuantity > cur.MinQuantity {
+			best[e.PriceListID] = e
+		}
+	}
+
+	var chosen *PriceListEntry
+	for _, e := range best {
+		if chosen == nil || e.Priority > chosen.Priority ||
+			(e.Priority == chosen.Priority && (e.Price < chosen.Price || (e.Price == chosen.Price && e.PriceListID < chosen.PriceListID))) {
+			chosen = e
+		}
+	}
+	return chosen
+}
+
+// BelowUnitPrice keeps the entries that sell a unit of factor base units for
+// less than price, what the unit costs without a list: its own unit price or
+// the store's price. A price list never makes a line dearer.
+func BelowUnitPrice(entries []PriceListEntry, price, factor int) []PriceListEntry {
+	cheaper := entries[:0:0]
+	for _, e := range entries {
+		if e.Price*factor < price {
+			cheaper = append(cheaper, e)
+		}
+	}
+	return cheaper
+}
+
+func (e *PriceListEntry) eligible(buyer PriceBuyer) bool {
+	switch e.Audience {
+	case models.PriceListAudienceMember:
+		return buyer.Member && (e.MemberTier == "" || e.MemberTier == buyer.MemberTier)
+	case models.PriceListAudienceStaff:
+		return buyer.Staff
+	default:
+		return true
+	}
+}
+
+// InTimeWindow reports whether at's time of day falls in [start, end). An
+// empty window always matches and one whose end is before its start wraps
+// past midnight.
+func InTimeWindow(start, end string, at time.Time) bool {
+	if start == "" || end == "" {
+		return true
+	}
+	from, err1 := ParseClock(start)
+	to, err2 := ParseClock(end)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	now := at.Hour()*60 + at.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight.
+func ParseClock(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+func priceListEntries(tx *sql.Tx, productID int) ([]PriceListEntry, error) {
+	rows, err := tx.Query(
+		`SELECT pl.id, pl.name, pl.audience, COALESCE(pl.member_tier, ''), pl.priority,
+			COALESCE(TO_CHAR(pl.start_time, 'HH24:MI'), ''), COALESCE(TO_CHAR(pl.end_time, 'HH24:MI'), ''),
+			pli.min_quantity, pli.price
+		FROM price_list_items pli
+		JOIN price_lists pl ON pli.price_list_id = pl.id
+		WHERE pli.product_id = $1 AND pl.active`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price lists for product %d: %w", productID, err)
+	}
+	defer rows.Close()
+
+	var entries []PriceListEntry
+	for rows.Next() {
+		var e PriceListEntry
+		if err := rows.Scan(&e.PriceListID, &e.Name, &e.Audience, &e.MemberTier, &e.Priority,
+			&e.StartTime, &e.EndTime, &e.MinQuantity, &e.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan price list: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
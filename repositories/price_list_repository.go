@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+)
+
+type PriceListRepository struct {
+	db *sql.DB
+}
+
+func NewPriceListRepository(db *sql.DB) *PriceListRepository {
+	return &PriceListRepository{db: db}
+}
+
+const priceListColumns = `id, name, audience, COALESCE(member_tier, ''), priority,
+	COALESCE(TO_CHAR(start_time, 'HH24:MI'), ''), COALESCE(TO_CHAR(end_time, 'HH24:MI'), ''), active, created_at`
+
+func scanPriceList(row interface{ Scan(...interface{}) error }, l *models.PriceList) error {
+	return row.Scan(&l.ID, &l.Name, &l.Audience, &l.MemberTier, &l.Priority, &l.StartTime, &l.EndTime, &l.Active, &l.CreatedAt)
+}
+
+func (r *PriceListRepository) GetAll() ([]models.PriceList, error) {
+	rows, err := r.db.Query(`SELECT ` + priceListColumns + ` FROM price_lists ORDER BY priority DESC, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price lists: %w", err)
+	}
+	defer rows.Close()
+
+	var lists []models.PriceList
+	for rows.Next() {
+		var l models.PriceList
+		if err := scanPriceList(rows, &l); err != nil {
+			return nil, fmt.Errorf("failed to scan price list: %w", err)
+		}
+		lists = append(lists, l)
+	}
+
+	return lists, rows.Err()
+}
+
+func (r *PriceListRepository) GetByID(id int) (*models.PriceList, error) {
+	var l models.PriceList
+	err := scanPriceList(r.db.QueryRow(`SELECT `+priceListColumns+` FROM price_lists WHERE id = $1`, id), &l)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price list: %w", err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT pli.product_id, p.name, pli.min_quantity, pli.price
+		FROM price_list_items pli
+		JOIN products p ON pli.product_id = p.id
+		WHERE pli.price_list_id = $1
+		ORDER BY pli.product_id, pli.min_quantity`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price list items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.PriceListItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.MinQuantity, &item.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan price list item: %w", err)
+		}
+		l.Items = append(l.Items, item)
+	}
+
+	return &l, rows.Err()
+}
+
+func (r *PriceListRepository) Create(list *models.PriceList) error {
+	err := r.db.QueryRow(
+		`INSERT INTO price_lists (name, audience, member_tier, priority, start_time, end_time)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, '')::TIME, NULLIF($6, '')::TIME)
+		RETURNING id, active, created_at`,
+		list.Name, list.Audience, list.MemberTier, list.Priority, list.StartTime, list.EndTime,
+	).Scan(&list.ID, &list.Active, &list.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create price list: %w", err)
+	}
+	return nil
+}
+
+func (r *PriceListRepository) Update(list *models.PriceList) error {
+	err := r.db.QueryRow(
+		`UPDATE price_lists SET name = $1, audience = $2, member_tier = NULLIF($3, ''), priority = $4,
+			start_time = NULLIF($5, '')::TIME, end_time = NULLIF($6, '')::TIME, active = $7
+		WHERE id = $8 RETURNING created_at`,
+		list.Name, list.Audience, list.MemberTier, list.Priority, list.StartTime, list.EndTime, list.Active, list.ID,
+	).Scan(&list.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("price list not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update price list: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PriceListRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM price_lists WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete price list: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("price list not found")
+	}
+
+	return nil
+}
+
+// SetItems replaces every price in the list.
+func (r *PriceListRepository) SetItems(priceListID int, items []models.PriceListItem) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`SELECT id FROM price_lists WHERE id = $1 FOR UPDATE`, priceListID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("price list not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get price list: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM price_list_items WHERE price_list_id = $1`, priceListID); err != nil {
+		return fmt.Errorf("failed to clear price list items: %w", err)
+	}
+
+	for _, item := range items {
+		_, err := tx.Exec(
+			`INSERT INTO price_list_items (price_list_id, product_id, min_quantity, price) VALUES ($1, $2, $3, $4)`,
+			priceListID, item.ProductID, item.MinQuantity, item.Price,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set price for product %d: %w", item.ProductID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
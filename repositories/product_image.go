@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// productImageURLs are versioned by upload time so clients can cache them
+// for as long as they like.
+func productImageURLs(productID int, updatedAt time.Time) (string, string) {
+	v := updatedAt.Unix()
+	return fmt.Sprintf("/api/produk/%d/image?v=%d", productID, v), fmt.Sprintf("/api/produk/%d/image/thumbnail?v=%d", productID, v)
+}
+
+func setProductImageURLs(p *models.Product, updatedAt *time.Time) {
+	if updatedAt != nil {
+		p.ImageURL, p.ThumbnailURL = productImageURLs(p.ID, *updatedAt)
+	}
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func getProductImage(q queryRower, productID int, lock bool) (*models.ProductImage, error) {
+	query := `SELECT image_key, image_type, thumbnail_key, image_updated_at FROM products WHERE id = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	var key, contentType, thumbnailKey sql.NullString
+	var updatedAt sql.NullTime
+	err := q.QueryRow(query, productID).Scan(&key, &contentType, &thumbnailKey, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("product not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product image: %w", err)
+	}
+	if !key.Valid {
+		return nil, nil
+	}
+
+	img := &models.ProductImage{
+		ProductID:    productID,
+		Key:          key.String,
+		ContentType:  contentType.String,
+		ThumbnailKey: thumbnailKey.String,
+		UpdatedAt:    updatedAt.Time,
+	}
+	img.ImageURL, img.ThumbnailURL = productImageURLs(productID, img.UpdatedAt)
+	return img, nil
+}
+
+// GetImage returns the product's image, or nil when it has none.
+func (r *ProductRepository) GetImage(productID int) (*models.ProductImage, error) {
+	return getProductImage(r.db, productID, false)
+}
+
+// ImageKeyPrefix is where the product's images are stored. It includes the
+// tenant so tenants sharing a bucket never collide.
+func (r *ProductRepository) ImageKeyPrefix(productID int) (string, error) {
+	var tenantID int
+	err := r.db.QueryRow(`SELECT tenant_id FROM products WHERE id = $1`, productID).Scan(&tenantID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("product not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get product: %w", err)
+	}
+	return fmt.Sprintf("products/%d/%d", tenantID, productID), nil
+}
+
+// SetImage points the product at img and returns the image it replaces so the
+// caller can remove it from storage.
+func (r *ProductRepository) SetImage(img *models.ProductImage) (*models.ProductImage, error) {
+	return r.replaceImage(img.ProductID, img)
+}
+
+// ClearImage removes the product's image and returns it, or nil if there was none.
+func (r *ProductRepository) ClearImage(productID int) (*models.ProductImage, error) {
+	return r.replaceImage(productID, nil)
+}
+
+func (r *ProductRepository) replaceImage(productID int, img *models.ProductImage) (*models.ProductImage, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := getProductImage(tx, productID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if img == nil {
+		_, err = tx.Exec(
+			`UPDATE products SET image_key = NULL, image_type = NULL, thumbnail_key = NULL, image_updated_at = NULL WHERE id = $1`,
+			productID,
+		)
+	} else {
+		_, err = tx.Exec(
+			`UPDATE products SET image_key = $1, image_type = $2, thumbnail_key = $3, image_updated_at = $4 WHERE id = $5`,
+			img.Key, img.ContentType, img.ThumbnailKey, img.UpdatedAt, productID,
+		)
+		img.ImageURL, img.ThumbnailURL = productImageURLs(productID, img.UpdatedAt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update product image: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return old, nil
+}
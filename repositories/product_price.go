@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// priceAtSaleSQL is the list price of td's product when its transaction t
+// was made, taken from the price history. Sales recorded before history was
+// kept resolve to the earliest known price.
+const priceAtSaleSQL = `(SELECT pp.price FROM product_prices pp
+	WHERE pp.product_id = td.product_id AND pp.effective_from <= t.created_at
+	ORDER BY pp.effective_from DESC, pp.id DESC LIMIT 1)`
+
+func recordPrice(tx *sql.Tx, productID, price int) error {
+	_, err := tx.Exec(
+		`INSERT INTO product_prices (product_id, price, effective_from, applied_at) VALUES ($1, $2, NOW(), NOW())`,
+		productID, price,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record price for product %d: %w", productID, err)
+	}
+	return nil
+}
+
+// recordCost adds a cost price to the product's cost history, which
+// inventory valuations as of a past date read from.
+func recordCost(tx *sql.Tx, productID, cost int) error {
+	_, err := tx.Exec(`INSERT INTO product_costs (product_id, cost_price) VALUES ($1, $2)`, productID, cost)
+	if err != nil {
+		return fmt.Errorf("failed to record cost for product %d: %w", productID, err)
+	}
+	return nil
+}
+
+// applyDuePrices moves scheduled prices whose time has come onto products,
+// for one product or, when productID is nil, for all of them. A scheduled
+// change already overtaken by a later applied price is marked applied without
+// touching the product.
+func applyDuePrices(tx *sql.Tx, productID *int) (int, error) {
+	result, err := tx.Exec(
+		`WITH due AS (
+			SELECT DISTINCT ON (pp.product_id) pp.product_id, pp.price
+			FROM product_prices pp
+			WHERE pp.applied_at IS NULL AND pp.effective_from <= NOW()
+			AND ($1::INT IS NULL OR pp.product_id = $1)
+			AND NOT EXISTS (
+				SELECT 1 FROM product_prices a
+				WHERE a.product_id = pp.product_id AND a.applied_at IS NOT NULL AND a.effective_from > pp.effective_from
+			)
+			ORDER BY pp.product_id, pp.effective_from DESC, pp.id DESC
+		), applied AS (
+			UPDATE product_prices SET applied_at = NOW()
+			WHERE applied_at IS NULL AND effective_from <= NOW() AND ($1::INT IS NULL OR product_id = $1)
+		)
+		UPDATE products p SET price = due.price FROM due WHERE p.id = due.product_id`,
+		productID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply scheduled prices: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+// ApplyDuePrices applies every scheduled price change that has taken effect
+// and returns how many products changed price.
+func (r *ProductRepository) ApplyDuePrices() (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	n, err := applyDuePrices(tx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return n, nil
+}
+
+func (r *ProductRepository) GetPrices(productID int) ([]models.ProductPrice, error) {
+	rows, err := r.db.Query(
+		`SELECT id, product_id, price, effective_from, applied_at, created_at
+		FROM product_prices WHERE product_id = $1
+		ORDER BY effective_from DESC, id DESC`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []models.ProductPrice{}
+	for rows.Next() {
+		var p models.ProductPrice
+		if err := rows.Scan(&p.ID, &p.ProductID, &p.Price, &p.EffectiveFrom, &p.AppliedAt, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product price: %w", err)
+		}
+		prices = append(prices, p)
+	}
+
+	return prices, rows.Err()
+}
+
+// SchedulePrice records a price change that takes effect at
+// price.EffectiveFrom. A change dated now or earlier is applied immediately.
+func (r *ProductRepository) SchedulePrice(price *models.ProductPrice) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		`INSERT INTO product_prices (product_id, price, effective_from) VALUES ($1, $2, $3)
+		RETURNING id, created_at`,
+		price.ProductID, price.Price, price.EffectiveFrom,
+	).Scan(&price.ID, &price.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to schedule price: %w", err)
+	}
+
+	if !price.EffectiveFrom.After(time.Now()) {
+		if _, err := applyDuePrices(tx, &price.ProductID); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(`SELECT applied_at FROM product_prices WHERE id = $1`, price.ID).Scan(&price.AppliedAt); err != nil {
+			return fmt.Errorf("failed to get product price: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) CancelPrice(productID, priceID int) error {
+	result, err := r.db.Exec(
+		`DELETE FROM product_prices WHERE id = $1 AND product_id = $2 AND applied_at IS NULL`,
+		priceID, productID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled price: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("scheduled price not found")
+	}
+
+	return nil
+}
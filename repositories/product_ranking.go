@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+// RankProducts ranks products by quantity sold or revenue between startDate
+// and endDate (inclusive). Bottom rankings include products that did not sell
+// at all, since those are the slowest movers; top rankings only list products
+// that sold.
+func (r *TransactionRepository) RankProducts(startDate, endDate time.Time, storeID *int, by, order string, limit int) ([]models.ProductRank, error) {
+	metric := "quantity"
+	if by == models.RankByRevenue {
+		metric = "revenue"
+	}
+	direction := "DESC"
+	if order == models.RankBottom {
+		direction = "ASC"
+	}
+	split, err := r.splitSales(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`WITH sold AS (
+			SELECT product_id, SUM(quantity) AS quantity, SUM(revenue)::BIGINT AS revenue, SUM(transactions) AS transactions
+			FROM (
+				SELECT product_id, quantity, revenue, transaction_count AS transactions FROM daily_sales_summary
+				WHERE product_id IS NOT NULL AND business_date BETWEEN $6::DATE AND $7::DATE AND ($3::INT IS NULL OR store_id = $3)
+				UNION ALL
+				SELECT td.product_id, SUM(`+netQuantitySQL+`), SUM(`+netSubtotalSQL+`), COUNT(DISTINCT t.id)
+				FROM transaction_details td
+				JOIN transactions t ON td.transaction_id = t.id
+				WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL AND ($3::INT IS NULL OR t.store_id = $3)
+				GROUP BY td.product_id
+			) sales
+			GROUP BY product_id
+		)
+		SELECT p.id, p.name, COALESCE(s.quantity, 0) AS quantity, COALESCE(s.revenue, 0) AS revenue, COALESCE(s.transactions, 0)
+		FROM products p
+		LEFT JOIN sold s ON s.product_id = p.id
+		WHERE s.product_id IS NOT NULL
+			OR ($4 AND ($3::INT IS NULL OR EXISTS (SELECT 1 FROM store_products sp WHERE sp.product_id = p.id AND sp.store_id = $3)))
+		ORDER BY `+metric+` `+direction+`, p.id
+		LIMIT $5`,
+		split.LiveFrom, split.LiveTo, storeID, order == models.RankBottom, limit, split.SummaryFrom, split.SummaryTo,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank products: %w", err)
+	}
+	defer rows.Close()
+
+	ranks := []models.ProductRank{}
+	for rows.Next() {
+		p := models.ProductRank{Rank: len(ranks) + 1}
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.Quantity, &p.Revenue, &p.TransactionCount); err != nil {
+			return nil, fmt.Errorf("failed to scan product rank: %w", err)
+		}
+		ranks = append(ranks, p)
+	}
+
+	return ranks, rows.Err()
+}
+
+// topProduct is the best seller by quantity between startDate and endDate, or
+// nil when nothing sold.
+func (r *TransactionRepository) topProduct(startDate, endDate time.Time, storeID *int) (*models.ProdukTerlaris, error) {
+	ranks, err := r.RankProducts(startDate, endDate, storeID, models.RankByQuantity, models.RankTop, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top product: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, nil
+	}
+	return &models.ProdukTerlaris{Nama: ranks[0].Name, QtyTerjual: ranks[0].Quantity}, nil
+}
+
+// GetDeadStock lists products with stock on hand that have not sold since
+// since, most tied-up value first. Stock is valued at its selling price.
+func (r *TransactionRepository) GetDeadStock(since time.Time, storeID *int) ([]models.DeadStockItem, error) {
+	items := []models.DeadStockItem{}
+	err := r.EachDeadStockItem(since, storeID, func(item models.DeadStockItem) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// EachDeadStockItem is GetDeadStock calling fn for every product as it is
+// read instead of collecting them.
+func (r *TransactionRepository) EachDeadStockItem(since time.Time, storeID *int, fn func(models.DeadStockItem) error) error {
+	rows, err := r.db.Query(
+		`WITH on_hand AS (
+			SELECT p.id, p.name,
+				CASE WHEN $2::INT IS NULL THEN p.stock ELSE sp.stock END AS stock,
+				COALESCE(sp.price, p.price) AS price
+			FROM products p
+			LEFT JOIN store_products sp ON sp.product_id = p.id AND sp.store_id = $2
+			WHERE $2::INT IS NULL OR sp.product_id IS NOT NULL
+		), last_sale AS (
+			SELECT td.product_id, MAX(t.created_at) AS sold_at
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.voided_at IS NULL AND `+netQuantitySQL+` > 0 AND ($2::INT IS NULL OR t.store_id = $2)
+			GROUP BY td.product_id
+		)
+		SELECT o.id, o.name, o.stock, o.price, l.sold_at
+		FROM on_hand o
+		LEFT JOIN last_sale l ON l.product_id = o.id
+		WHERE o.stock > 0 AND (l.sold_at IS NULL OR l.sold_at < $1)
+		ORDER BY o.stock * o.price DESC, o.id`,
+		since, storeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get dead stock: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.DeadStockItem
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.Stock, &item.Price, &item.LastSoldAt); err != nil {
+			return fmt.Errorf("failed to scan dead stock: %w", err)
+		}
+		item.StockValue = int(math.Round(item.Stock * float64(item.Price)))
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"andre_kasir_api/models"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 type ProductRepository struct {
@@ -19,10 +20,10 @@ func (r *ProductRepository) GetAll(searchName string) ([]models.Product, error)
 	var args []interface{}
 
 	if searchName != "" {
-		query = `SELECT id, name, price, stock, category_id FROM products WHERE name ILIKE $1 ORDER BY id`
+		query = `SELECT id, name, price, cost_price, stock, min_stock, reorder_qty, track_expiry, base_unit, quantity_precision, COALESCE(plu, ''), category_id, image_updated_at FROM products WHERE name ILIKE $1 ORDER BY id`
 		args = append(args, "%"+searchName+"%")
 	} else {
-		query = `SELECT id, name, price, stock, category_id FROM products ORDER BY id`
+		query = `SELECT id, name, price, cost_price, stock, min_stock, reorder_qty, track_expiry, base_unit, quantity_precision, COALESCE(plu, ''), category_id, image_updated_at FROM products ORDER BY id`
 	}
 
 	rows, err := r.db.Query(query, args...)
@@ -34,9 +35,11 @@ func (r *ProductRepository) GetAll(searchName string) ([]models.Product, error)
 	var products []models.Product
 	for rows.Next() {
 		var p models.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID); err != nil {
+		var imageUpdatedAt *time.Time
+		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.ReorderQty, &p.TrackExpiry, &p.BaseUnit, &p.Precision, &p.PLU, &p.CategoryID, &imageUpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
+		setProductImageURLs(&p, imageUpdatedAt)
 		products = append(products, p)
 	}
 
@@ -45,10 +48,11 @@ func (r *ProductRepository) GetAll(searchName string) ([]models.Product, error)
 
 func (r *ProductRepository) GetByID(id int) (*models.Product, error) {
 	var p models.Product
+	var imageUpdatedAt *time.Time
 	err := r.db.QueryRow(
-		`SELECT id, name, price, stock, category_id FROM products WHERE id = $1`,
+		`SELECT id, name, price, cost_price, stock, min_stock, reorder_qty, track_expiry, base_unit, quantity_precision, COALESCE(plu, ''), category_id, image_updated_at FROM products WHERE id = $1`,
 		id,
-	).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID)
+	).Scan(&p.ID, &p.Name, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.ReorderQty, &p.TrackExpiry, &p.BaseUnit, &p.Precision, &p.PLU, &p.CategoryID, &imageUpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -57,31 +61,109 @@ func (r *ProductRepository) GetByID(id int) (*models.Product, error) {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
+	setProductImageURLs(&p, imageUpdatedAt)
+
+	if p.Units, err = r.GetUnits(p.ID); err != nil {
+		return nil, err
+	}
+
 	return &p, nil
 }
 
 func (r *ProductRepository) Create(product *models.Product) error {
-	return r.db.QueryRow(
-		`INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id`,
-		product.Name, product.Price, product.Stock, product.CategoryID,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		`INSERT INTO products (name, price, stock, min_stock, reorder_qty, track_expiry, base_unit, quantity_precision, plu, category_id, cost_price)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11) RETURNING id`,
+		product.Name, product.Price, product.Stock, product.MinStock, product.ReorderQty, product.TrackExpiry, product.BaseUnit,
+		product.Precision, product.PLU, product.CategoryID, product.CostPrice,
 	).Scan(&product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create product: %w", err)
+	}
+
+	if err := recordPrice(tx, product.ID, product.Price); err != nil {
+		return err
+	}
+	if err := recordCost(tx, product.ID, product.CostPrice); err != nil {
+		return err
+	}
+
+	if product.Stock != 0 {
+		if err := recordMovement(tx, product.ID, nil, product.Stock, "initial", "", nil); err != nil {
+			return err
+		}
+	}
+	if product.TrackExpiry {
+		if err := syncBatches(tx, product.ID, nil, product.Stock); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
 }
 
 func (r *ProductRepository) Update(product *models.Product) error {
-	result, err := r.db.Exec(
-		`UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5`,
-		product.Name, product.Price, product.Stock, product.CategoryID, product.ID,
-	)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldStock float64
+	var oldPrice, oldCost int
+	err = tx.QueryRow(`SELECT stock, price, cost_price FROM products WHERE id = $1 FOR UPDATE`, product.ID).Scan(&oldStock, &oldPrice, &oldCost)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product not found")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
+	_, err = tx.Exec(
+		`UPDATE products SET name = $1, price = $2, stock = $3, min_stock = $4, reorder_qty = $5, track_expiry = $6, base_unit = $7,
+			quantity_precision = $8, plu = NULLIF($9, ''), category_id = $10, cost_price = $11
+		WHERE id = $12`,
+		product.Name, product.Price, product.Stock, product.MinStock, product.ReorderQty, product.TrackExpiry, product.BaseUnit,
+		product.Precision, product.PLU, product.CategoryID, product.CostPrice, product.ID,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to update product: %w", err)
 	}
-	if rows == 0 {
-		return fmt.Errorf("product not found")
+
+	if product.Price != oldPrice {
+		if err := recordPrice(tx, product.ID, product.Price); err != nil {
+			return err
+		}
+	}
+	if product.CostPrice != oldCost {
+		if err := recordCost(tx, product.ID, product.CostPrice); err != nil {
+			return err
+		}
+	}
+
+	if product.Stock != oldStock {
+		if err := recordMovement(tx, product.ID, nil, product.Stock-oldStock, "adjustment", "", nil); err != nil {
+			return err
+		}
+	}
+	if product.TrackExpiry {
+		if err := syncBatches(tx, product.ID, nil, product.Stock); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
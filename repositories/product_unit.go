@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+)
+
+type saleUnit struct {
+	name   string
+	factor int
+	price  int
+}
+
+// resolveUnit returns how many base units one unit of the product holds and
+// what it sells for. Units without their own price sell at basePrice times
+// the factor.
+func resolveUnit(tx *sql.Tx, productID int, unit, baseUnit string, basePrice int) (*saleUnit, error) {
+	if unit == "" || unit == baseUnit {
+		return &saleUnit{name: baseUnit, factor: 1, price: basePrice}, nil
+	}
+
+	var factor int
+	var price *int
+	err := tx.QueryRow(
+		`SELECT factor, price FROM product_units WHERE product_id = $1 AND unit = $2`,
+		productID, unit,
+	).Scan(&factor, &price)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unit %q is not defined for product %d", unit, productID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit %q for product %d: %w", unit, productID, err)
+	}
+
+	su := &saleUnit{name: unit, factor: factor, price: basePrice * factor}
+	if price != nil {
+		su.price = *price
+	}
+	return su, nil
+}
+
+// resolveBarcode fills in the product and unit of an item scanned by barcode.
+func resolveBarcode(tx *sql.Tx, item models.CheckoutItem) (models.CheckoutItem, error) {
+	var productID int
+	var unit string
+	err := tx.QueryRow(`SELECT product_id, unit FROM product_units WHERE barcode = $1`, item.Barcode).Scan(&productID, &unit)
+	if err == sql.ErrNoRows {
+		return item, fmt.Errorf("barcode %s not found", item.Barcode)
+	}
+	if err != nil {
+		return item, fmt.Errorf("failed to look up barcode %s: %w", item.Barcode, err)
+	}
+
+	if item.ProductID != 0 && item.ProductID != productID {
+		return item, fmt.Errorf("barcode %s belongs to product %d, not %d", item.Barcode, productID, item.ProductID)
+	}
+	item.ProductID = productID
+	if item.Unit == "" {
+		item.Unit = unit
+	}
+	return item, nil
+}
+
+func (r *ProductRepository) GetUnits(productID int) ([]models.ProductUnit, error) {
+	rows, err := r.db.Query(
+		`SELECT product_id, unit, factor, price, COALESCE(barcode, '') FROM product_units WHERE product_id = $1 ORDER BY factor, unit`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	defer rows.Close()
+
+	var units []models.ProductUnit
+	for rows.Next() {
+		var u models.ProductUnit
+		if err := rows.Scan(&u.ProductID, &u.Unit, &u.Factor, &u.Price, &u.Barcode); err != nil {
+			return nil, fmt.Errorf("failed to scan product unit: %w", err)
+		}
+		units = append(units, u)
+	}
+
+	return units, rows.Err()
+}
+
+func (r *ProductRepository) SetUnit(unit *models.ProductUnit) error {
+	_, err := r.db.Exec(
+		`INSERT INTO product_units (product_id, unit, factor, price, barcode) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		ON CONFLICT (product_id, unit) DO UPDATE SET factor = EXCLUDED.factor, price = EXCLUDED.price, barcode = EXCLUDED.barcode`,
+		unit.ProductID, unit.Unit, unit.Factor, unit.Price, unit.Barcode,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set product unit: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) RemoveUnit(productID int, unit string) error {
+	result, err := r.db.Exec(`DELETE FROM product_units WHERE product_id = $1 AND unit = $2`, productID, unit)
+	if err != nil {
+		return fmt.Errorf("failed to remove product unit: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("product unit not found")
+	}
+
+	return nil
+}
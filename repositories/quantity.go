@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"fmt"
+	"math"
+)
+
+// Quantities are stored as NUMERIC(14,3), so anything finer than a thousandth
+// of a unit is rounding noise.
+const quantityScale = 1000
+
+const (
+	RoundingHalfUp   = "half_up"
+	RoundingHalfEven = "half_even"
+	RoundingDown     = "down"
+	RoundingUp       = "up"
+)
+
+func RoundQuantity(q float64) float64 {
+	return math.Round(q*quantityScale) / quantityScale
+}
+
+// CheckPrecision rejects quantities that are not positive or have more
+// decimal places than the product allows.
+func CheckPrecision(quantity float64, precision int, productName string) error {
+	if quantity <= 0 {
+		return fmt.Errorf("quantity for product %s must be greater than 0", productName)
+	}
+	scaled := quantity * math.Pow10(precision)
+	if math.Abs(scaled-math.Round(scaled)) > 1e-6 {
+		if precision == 0 {
+			return fmt.Errorf("quantity for product %s must be a whole number", productName)
+		}
+		return fmt.Errorf("quantity for product %s allows at most %d decimal places", productName, precision)
+	}
+	return nil
+}
+
+// LineAmount prices quantity at unitPrice in whole Rupiah. The product is
+// computed in integer thousandths of a Rupiah so the rounding mode alone
+// decides the last Rupiah.
+func LineAmount(unitPrice int, quantity float64, mode string) int {
+	milli := int64(unitPrice) * int64(math.Round(quantity*quantityScale))
+	whole, rem := milli/quantityScale, milli%quantityScale
+	if rem < 0 {
+		whole, rem = whole-1, rem+quantityScale
+	}
+
+	switch mode {
+	case RoundingDown:
+	case RoundingUp:
+		if rem > 0 {
+			whole++
+		}
+	case RoundingHalfEven:
+		if rem > quantityScale/2 || (rem == quantityScale/2 && whole%2 != 0) {
+			whole++
+		}
+	default:
+		if rem >= quantityScale/2 {
+			whole++
+		}
+	}
+	return int(whole)
+}
@@ -0,0 +1,247 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type ReceivableRepository struct {
+	db *sql.DB
+}
+
+func NewReceivableRepository(db *sql.DB) *ReceivableRepository {
+	return &ReceivableRepository{db: db}
+}
+
+func (r *ReceivableRepository) GetAll(customerID *int, openOnly bool) ([]models.Receivable, error) {
+	rows, err := r.db.Query(
+		`SELECT rc.id, rc.customer_id, c.name, rc.transaction_id, rc.amount, rc.paid_amount, rc.created_at
+		FROM receivables rc
+		JOIN customers c ON rc.customer_id = c.id
+		WHERE ($1::INT IS NULL OR rc.customer_id = $1)
+		AND (NOT $2 OR rc.paid_amount < rc.amount)
+		ORDER BY rc.created_at, rc.id`,
+		customerID, openOnly,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receivables: %w", err)
+	}
+	defer rows.Close()
+
+	var receivables []models.Receivable
+	for rows.Next() {
+		var rc models.Receivable
+		if err := rows.Scan(&rc.ID, &rc.CustomerID, &rc.CustomerName, &rc.TransactionID, &rc.Amount, &rc.PaidAmount, &rc.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan receivable: %w", err)
+		}
+		rc.Outstanding = rc.Amount - rc.PaidAmount
+		receivables = append(receivables, rc)
+	}
+
+	return receivables, rows.Err()
+}
+
+func (r *ReceivableRepository) GetByID(id int) (*models.Receivable, error) {
+	var rc models.Receivable
+	err := r.db.QueryRow(
+		`SELECT rc.id, rc.customer_id, c.name, rc.transaction_id, rc.amount, rc.paid_amount, rc.created_at
+		FROM receivables rc
+		JOIN customers c ON rc.customer_id = c.id
+		WHERE rc.id = $1`,
+		id,
+	).Scan(&rc.ID, &rc.CustomerID, &rc.CustomerName, &rc.TransactionID, &rc.Amount, &rc.PaidAmount, &rc.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receivable: %w", err)
+	}
+
+	rc.Outstanding = rc.Amount - rc.PaidAmount
+	return &rc, nil
+}
+
+func (r *ReceivableRepository) GetPayments(receivableID int) ([]models.ReceivablePayment, error) {
+	rows, err := r.db.Query(
+		`SELECT id, receivable_id, amount, method, created_at FROM receivable_payments WHERE receivable_id = $1 ORDER BY created_at, id`,
+		receivableID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receivable payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []models.ReceivablePayment
+	for rows.Next() {
+		var p models.ReceivablePayment
+		if err := rows.Scan(&p.ID, &p.ReceivableID, &p.Amount, &p.Method, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan receivable payment: %w", err)
+		}
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *ReceivableRepository) AddPayment(receivableID int, req *models.RepaymentRequest) (*models.ReceivablePayment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var amount, paidAmount int
+	err = tx.QueryRow(
+		`SELECT amount, paid_amount FROM receivables WHERE id = $1 FOR UPDATE`,
+		receivableID,
+	).Scan(&amount, &paidAmount)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("receivable not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receivable: %w", err)
+	}
+
+	if req.Amount > amount-paidAmount {
+		return nil, fmt.Errorf("repayment exceeds outstanding amount: outstanding %d, paid %d", amount-paidAmount, req.Amount)
+	}
+
+	payment, err := applyRepayment(tx, receivableID, req.Amount, req.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return payment, nil
+}
+
+func (r *ReceivableRepository) RepayCustomer(customerID int, req *models.RepaymentRequest) ([]models.ReceivablePayment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		`SELECT id, amount - paid_amount FROM receivables
+		WHERE customer_id = $1 AND paid_amount < amount
+		ORDER BY created_at, id
+		FOR UPDATE`,
+		customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receivables: %w", err)
+	}
+
+	type openReceivable struct {
+		id          int
+		outstanding int
+	}
+	var open []openReceivable
+	var totalOutstanding int
+	for rows.Next() {
+		var o openReceivable
+		if err := rows.Scan(&o.id, &o.outstanding); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan receivable: %w", err)
+		}
+		totalOutstanding += o.outstanding
+		open = append(open, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.Amount > totalOutstanding {
+		return nil, fmt.Errorf("repayment exceeds outstanding amount: outstanding %d, paid %d", totalOutstanding, req.Amount)
+	}
+
+	remaining := req.Amount
+	var payments []models.ReceivablePayment
+	for _, o := range open {
+		if remaining == 0 {
+			break
+		}
+		amount := o.outstanding
+		if amount > remaining {
+			amount = remaining
+		}
+
+		payment, err := applyRepayment(tx, o.id, amount, req.Method)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+		remaining -= amount
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return payments, nil
+}
+
+func applyRepayment(tx *sql.Tx, receivableID, amount int, method string) (*models.ReceivablePayment, error) {
+	_, err := tx.Exec(
+		`UPDATE receivables SET paid_amount = paid_amount + $1 WHERE id = $2`,
+		amount, receivableID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update receivable: %w", err)
+	}
+
+	p := models.ReceivablePayment{ReceivableID: receivableID, Amount: amount, Method: method}
+	err = tx.QueryRow(
+		`INSERT INTO receivable_payments (receivable_id, amount, method) VALUES ($1, $2, $3) RETURNING id, created_at`,
+		receivableID, amount, method,
+	).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create receivable payment: %w", err)
+	}
+
+	return &p, nil
+}
+
+func (r *ReceivableRepository) GetAging(asOf time.Time) (*models.AgingReport, error) {
+	rows, err := r.db.Query(
+		`SELECT c.id, c.name,
+			COALESCE(SUM(rc.amount - rc.paid_amount) FILTER (WHERE DATE($1) - DATE(rc.created_at) <= 30), 0),
+			COALESCE(SUM(rc.amount - rc.paid_amount) FILTER (WHERE DATE($1) - DATE(rc.created_at) BETWEEN 31 AND 60), 0),
+			COALESCE(SUM(rc.amount - rc.paid_amount) FILTER (WHERE DATE($1) - DATE(rc.created_at) > 60), 0)
+		FROM receivables rc
+		JOIN customers c ON rc.customer_id = c.id
+		WHERE rc.paid_amount < rc.amount AND DATE(rc.created_at) <= DATE($1)
+		GROUP BY c.id, c.name
+		ORDER BY c.name`,
+		asOf,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aging report: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.AgingReport{AsOf: asOf, Customers: []models.AgingRow{}}
+	for rows.Next() {
+		var row models.AgingRow
+		if err := rows.Scan(&row.CustomerID, &row.CustomerName, &row.Days0To30, &row.Days31To60, &row.Over60); err != nil {
+			return nil, fmt.Errorf("failed to scan aging row: %w", err)
+		}
+		row.Total = row.Days0To30 + row.Days31To60 + row.Over60
+
+		report.Totals.Days0To30 += row.Days0To30
+		report.Totals.Days31To60 += row.Days31To60
+		report.Totals.Over60 += row.Over60
+		report.Totals.Total += row.Total
+		report.Customers = append(report.Customers, row)
+	}
+
+	return report, rows.Err()
+}
@@ -0,0 +1,450 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+type refundableLine struct {
+	productID      int
+	productName    string
+	quantity       float64
+	baseQuantity   float64
+	unitPrice      int
+	subtotal       int
+	refundedQty    float64
+	refundedAmount int
+	hasBatches     bool
+}
+
+// lockRefundableLine reads a sold line together with what earlier refunds
+// already returned. Its unit price is the one charged at the time of sale.
+func lockRefundableLine(tx *sql.Tx, transactionID, detailID int) (*refundableLine, error) {
+	var l refundableLine
+	err := tx.QueryRow(
+		`SELECT td.product_id, COALESCE(p.name, ''), td.quantity, td.base_quantity,
+			COALESCE(td.unit_price, `+priceAtSaleSQL+`, ROUND(td.subtotal / NULLIF(td.quantity, 0))::INT, 0), td.subtotal,
+			COALESCE((SELECT SUM(ri.quantity) FROM refund_items ri WHERE ri.transaction_detail_id = td.id), 0),
+			COALESCE((SELECT SUM(ri.amount) FROM refund_items ri WHERE ri.transaction_detail_id = td.id), 0),
+			EXISTS (SELECT 1 FROM transaction_detail_batches tdb WHERE tdb.transaction_detail_id = td.id)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		LEFT JOIN products p ON td.product_id = p.id
+		WHERE td.id = $1 AND td.transaction_id = $2
+		FOR UPDATE OF td`,
+		detailID, transactionID,
+	).Scan(&l.productID, &l.productName, &l.quantity, &l.baseQuantity, &l.unitPrice, &l.subtotal, &l.refundedQty, &l.refundedAmount,
+		&l.hasBatches)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction detail %d not found in transaction %d", detailID, transactionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction detail %d: %w", detailID, err)
+	}
+	return &l, nil
+}
+
+// refundAmount is the share of what the customer paid for the line that
+// quantity accounts for. Refunding the rest of a line returns exactly what
+// is left, so partial refunds never drift from the original subtotal.
+func (l *refundableLine) refundAmount(quantity float64) int {
+	if RoundQuantity(l.refundedQty+quantity) >= l.quantity {
+		return l.subtotal - l.refundedAmount
+	}
+	return int(math.Round(float64(l.subtotal) * quantity / l.quantity))
+}
+
+type lockedSale struct {
+	storeID   *int
+	voided    bool
+	closedByZ *int
+}
+
+// lockSale locks a transaction against concurrent refunds, voids and
+// register closes.
+func lockSale(tx *sql.Tx, transactionID int) (*lockedSale, error) {
+	var sale lockedSale
+	err := tx.QueryRow(
+		`SELECT t.store_id, t.voided_at IS NOT NULL, z.number
+		FROM transactions t
+		LEFT JOIN z_reports z ON t.z_report_id = z.id
+		WHERE t.id = $1
+		FOR UPDATE OF t`,
+		transactionID,
+	).Scan(&sale.storeID, &sale.voided, &sale.closedByZ)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return &sale, nil
+}
+
+// Refund returns items of a sale to stock and records the money given back,
+// priced at what was charged when the sale was made.
+func (r *TransactionRepository) Refund(transactionID int, req *models.RefundRequest) (*models.Refund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.lockClosedSaleDay(tx, transactionID); err != nil {
+		return nil, err
+	}
+	sale, err := lockSale(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if sale.voided {
+		return nil, fmt.Errorf("transaction has been voided")
+	}
+
+	refund, err := createRefund(tx, transactionID, sale.storeID, models.RefundKindRefund, req.Reason, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.resummarizeSale(tx, transactionID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return refund, nil
+}
+
+// Void cancels a sale that has not been closed by a Z report yet: whatever
+// has not been refunded goes back to stock and the sale is marked void. A
+// manager has to approve it.
+func (r *TransactionRepository) Void(transactionID int, req *models.VoidRequest) (*models.Refund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.lockClosedSaleDay(tx, transactionID); err != nil {
+		return nil, err
+	}
+	sale, err := lockSale(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if sale.voided {
+		return nil, fmt.Errorf("transaction is already voided")
+	}
+	if sale.closedByZ != nil {
+		return nil, fmt.Errorf("transaction was closed by Z report %d and can only be refunded", *sale.closedByZ)
+	}
+
+	managerID, err := findManagerByPIN(tx, r.cfg.PINLookupKey, req.ManagerPIN)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := unrefundedItems(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("transaction has been fully refunded, nothing left to void")
+	}
+
+	refund, err := createRefund(tx, transactionID, sale.storeID, models.RefundKindVoid, req.Reason, items)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE transactions SET voided_at = $1, voided_by = $2, void_reason = $3 WHERE id = $4`,
+		refund.CreatedAt, managerID, req.Reason, transactionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to void transaction: %w", err)
+	}
+	if err := r.resummarizeSale(tx, transactionID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return refund, nil
+}
+
+// unrefundedItems lists what is left of each line of a sale after refunds.
+func unrefundedItems(tx *sql.Tx, transactionID int) ([]models.RefundItemRequest, error) {
+	rows, err := tx.Query(
+		`SELECT td.id, td.quantity - COALESCE((SELECT SUM(ri.quantity) FROM refund_items ri WHERE ri.transaction_detail_id = td.id), 0)
+		FROM transaction_details td
+		WHERE td.transaction_id = $1
+		ORDER BY td.id`,
+		transactionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction details: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.RefundItemRequest
+	for rows.Next() {
+		var item models.RefundItemRequest
+		if err := rows.Scan(&item.TransactionDetailID, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction detail: %w", err)
+		}
+		if item.Quantity = RoundQuantity(item.Quantity); item.Quantity > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items, rows.Err()
+}
+
+func createRefund(tx *sql.Tx, transactionID int, storeID *int, kind, reason string, items []models.RefundItemRequest) (*models.Refund, error) {
+	refund := &models.Refund{TransactionID: transactionID, Kind: kind, Reason: reason}
+	err := tx.QueryRow(
+		`INSERT INTO refunds (transaction_id, kind, reason, total_amount) VALUES ($1, $2, $3, 0) RETURNING id, created_at`,
+		transactionID, kind, reason,
+	).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	for _, item := range items {
+		line, err := lockRefundableLine(tx, transactionID, item.TransactionDetailID)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := RoundQuantity(line.quantity - line.refundedQty)
+		if item.Quantity > remaining {
+			return nil, fmt.Errorf("cannot refund %g of product %s: only %g left to refund", item.Quantity, line.productName, remaining)
+		}
+
+		refundItem := models.RefundItem{
+			RefundID:            refund.ID,
+			TransactionDetailID: item.TransactionDetailID,
+			ProductID:           line.productID,
+			ProductName:         line.productName,
+			Quantity:            item.Quantity,
+			BaseQuantity:        RoundQuantity(line.baseQuantity * item.Quantity / line.quantity),
+			UnitPrice:           line.unitPrice,
+			Amount:              line.refundAmount(item.Quantity),
+		}
+
+		err = tx.QueryRow(
+			`INSERT INTO refund_items (refund_id, transaction_detail_id, product_id, quantity, base_quantity, unit_price, amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			refund.ID, refundItem.TransactionDetailID, refundItem.ProductID, refundItem.Quantity, refundItem.BaseQuantity,
+			refundItem.UnitPrice, refundItem.Amount,
+		).Scan(&refundItem.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create refund item: %w", err)
+		}
+
+		if err := deductStock(tx, line.productID, storeID, -refundItem.BaseQuantity); err != nil {
+			return nil, err
+		}
+		if line.hasBatches {
+			if err := returnBatches(tx, item.TransactionDetailID, refundItem.BaseQuantity); err != nil {
+				return nil, err
+			}
+		}
+		if err := recordMovement(tx, line.productID, storeID, refundItem.BaseQuantity, kind, "refund", &refund.ID); err != nil {
+			return nil, err
+		}
+
+		refund.TotalAmount += refundItem.Amount
+		refund.Items = append(refund.Items, refundItem)
+	}
+
+	if _, err := tx.Exec(`UPDATE refunds SET total_amount = $1 WHERE id = $2`, refund.TotalAmount, refund.ID); err != nil {
+		return nil, fmt.Errorf("failed to update refund: %w", err)
+	}
+
+	if err := reverseTenders(tx, transactionID, refund); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// tenderShare is the part of amount that refunds totalling refunded out of a
+// sale of total account for. Once the whole sale is refunded it is all of
+// amount, so the shares taken by successive partial refunds add up exactly.
+func tenderShare(amount, refunded, total int) int {
+	if refunded >= total {
+		return amount
+	}
+	return int(math.Round(float64(amount) * float64(refunded) / float64(total)))
+}
+
+// reverseTenders undoes what the sale did besides moving stock, in
+// proportion to what refund gives back: the credit owed shrinks, points
+// spent come back, points earned are taken back and vouchers get their value
+// back. A voucher use is only given back once the whole sale is refunded.
+func reverseTenders(tx *sql.Tx, transactionID int, refund *models.Refund) error {
+	var customerID sql.NullInt64
+	var total, pointsEarned, pointsRedeemed, credit, refunded int
+	err := tx.QueryRow(
+		`SELECT t.customer_id, t.total_amount, t.points_earned, t.points_redeemed,
+			COALESCE((SELECT SUM(tp.amount) FROM transaction_payments tp WHERE tp.transaction_id = t.id AND tp.method = $2), 0),
+			COALESCE((SELECT SUM(rf.total_amount) FROM refunds rf WHERE rf.transaction_id = t.id), 0)
+		FROM transactions t
+		WHERE t.id = $1`,
+		transactionID, models.PaymentMethodCredit,
+	).Scan(&customerID, &total, &pointsEarned, &pointsRedeemed, &credit, &refunded)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction tenders: %w", err)
+	}
+
+	before := refunded - refund.TotalAmount
+	delta := func(amount int) int {
+		return tenderShare(amount, refunded, total) - tenderShare(amount, before, total)
+	}
+
+	if d := delta(credit); d > 0 {
+		if err := reduceReceivable(tx, transactionID, d); err != nil {
+			return err
+		}
+	}
+
+	if customerID.Valid {
+		if d := delta(pointsRedeemed); d > 0 {
+			if err := addPoints(tx, int(customerID.Int64), &transactionID, d, fmt.Sprintf("Returned on refund #%d", refund.ID)); err != nil {
+				return err
+			}
+		}
+		if d := delta(pointsEarned); d > 0 {
+			var balance int
+			err := tx.QueryRow(`SELECT points FROM customers WHERE id = $1 FOR UPDATE`, customerID.Int64).Scan(&balance)
+			if err != nil {
+				return fmt.Errorf("failed to get customer points: %w", err)
+			}
+			// Points already spent elsewhere cannot be taken back.
+			if d > balance {
+				d = balance
+			}
+			if d > 0 {
+				if err := addPoints(tx, int(customerID.Int64), &transactionID, -d, fmt.Sprintf("Reversed on refund #%d", refund.ID)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	rows, err := tx.Query(`SELECT voucher_id, amount FROM voucher_redemptions WHERE transaction_id = $1 ORDER BY id`, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get voucher redemptions: %w", err)
+	}
+	var redemptions []voucherRedemption
+	for rows.Next() {
+		var v voucherRedemption
+		if err := rows.Scan(&v.voucherID, &v.amount); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan voucher redemption: %w", err)
+		}
+		redemptions = append(redemptions, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get voucher redemptions: %w", err)
+	}
+
+	fullyRefunded := refunded >= total && before < total
+	for _, v := range redemptions {
+		var uses int
+		if fullyRefunded {
+			uses = 1
+		}
+		d := delta(v.amount)
+		if d == 0 && uses == 0 {
+			continue
+		}
+		_, err := tx.Exec(
+			`UPDATE vouchers SET balance = balance + $1, uses = GREATEST(uses - $2, 0) WHERE id = $3`,
+			d, uses, v.voucherID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to restore voucher %d: %w", v.voucherID, err)
+		}
+	}
+
+	return nil
+}
+
+// reduceReceivable takes amount off what the customer owes for a sale. What
+// has already been paid stays on the books, and a receivable with nothing
+// paid and nothing left owing is dropped.
+func reduceReceivable(tx *sql.Tx, transactionID, amount int) error {
+	var id, owed, paid int
+	err := tx.QueryRow(
+		`SELECT id, amount, paid_amount FROM receivables WHERE transaction_id = $1 ORDER BY id LIMIT 1 FOR UPDATE`,
+		transactionID,
+	).Scan(&id, &owed, &paid)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get receivable: %w", err)
+	}
+
+	owed -= amount
+	if owed < paid {
+		owed = paid
+	}
+	if owed == 0 {
+		_, err = tx.Exec(`DELETE FROM receivables WHERE id = $1`, id)
+	} else {
+		_, err = tx.Exec(`UPDATE receivables SET amount = $1 WHERE id = $2`, owed, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reduce receivable: %w", err)
+	}
+	return nil
+}
+
+func getTransactionRefunds(db *sql.DB, transactionID int) ([]models.Refund, error) {
+	rows, err := db.Query(
+		`SELECT r.id, r.transaction_id, r.kind, r.reason, r.total_amount, r.created_at,
+			ri.id, ri.transaction_detail_id, ri.product_id, COALESCE(p.name, ''), ri.quantity, ri.base_quantity, ri.unit_price, ri.amount
+		FROM refunds r
+		JOIN refund_items ri ON ri.refund_id = r.id
+		LEFT JOIN products p ON ri.product_id = p.id
+		WHERE r.transaction_id = $1
+		ORDER BY r.id, ri.id`,
+		transactionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+	defer rows.Close()
+
+	var refunds []models.Refund
+	for rows.Next() {
+		var rf models.Refund
+		var item models.RefundItem
+		if err := rows.Scan(&rf.ID, &rf.TransactionID, &rf.Kind, &rf.Reason, &rf.TotalAmount, &rf.CreatedAt,
+			&item.ID, &item.TransactionDetailID, &item.ProductID, &item.ProductName, &item.Quantity, &item.BaseQuantity,
+			&item.UnitPrice, &item.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan refund: %w", err)
+		}
+		item.RefundID = rf.ID
+
+		if n := len(refunds); n > 0 && refunds[n-1].ID == rf.ID {
+			refunds[n-1].Items = append(refunds[n-1].Items, item)
+			continue
+		}
+		rf.Items = []models.RefundItem{item}
+		refunds = append(refunds, rf)
+	}
+
+	return refunds, rows.Err()
+}
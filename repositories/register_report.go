@@ -0,0 +1,324 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// registerTotals adds up the register for one store. With zReportID nil it
+// covers the sales and refunds no Z report has closed yet (an X report);
+// otherwise those the given Z report closed.
+func registerTotals(tx *sql.Tx, storeID, zReportID *int, taxRatePercent int) (*models.RegisterReport, error) {
+	report := &models.RegisterReport{StoreID: storeID, TaxRatePercent: taxRatePercent, Payments: []models.RegisterPayment{}}
+
+	err := tx.QueryRow(
+		`SELECT COUNT(DISTINCT t.id), COALESCE(SUM(td.subtotal + td.discount), 0), COALESCE(SUM(td.discount), 0), COALESCE(SUM(td.subtotal), 0)
+		FROM transactions t
+		LEFT JOIN transaction_details td ON td.transaction_id = t.id
+		WHERE t.z_report_id IS NOT DISTINCT FROM $1 AND t.store_id IS NOT DISTINCT FROM $2`,
+		zReportID, storeID,
+	).Scan(&report.TransactionCount, &report.GrossSales, &report.Discounts, &report.NetSales)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total sales: %w", err)
+	}
+
+	err = tx.QueryRow(
+		`SELECT
+			COALESCE((SELECT invoice_number FROM transactions
+				WHERE z_report_id IS NOT DISTINCT FROM $1 AND store_id IS NOT DISTINCT FROM $2 ORDER BY created_at, id LIMIT 1), ''),
+			COALESCE((SELECT invoice_number FROM transactions
+				WHERE z_report_id IS NOT DISTINCT FROM $1 AND store_id IS NOT DISTINCT FROM $2 ORDER BY created_at DESC, id DESC LIMIT 1), '')`,
+		zReportID, storeID,
+	).Scan(&report.FirstInvoice, &report.LastInvoice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice range: %w", err)
+	}
+
+	rows, err := tx.Query(
+		`SELECT r.kind, COUNT(*), COALESCE(SUM(r.total_amount), 0)
+		FROM refunds r
+		JOIN transactions t ON r.transaction_id = t.id
+		WHERE r.z_report_id IS NOT DISTINCT FROM $1 AND t.store_id IS NOT DISTINCT FROM $2
+		GROUP BY r.kind`,
+		zReportID, storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total refunds: %w", err)
+	}
+	for rows.Next() {
+		var kind string
+		var count, amount int
+		if err := rows.Scan(&kind, &count, &amount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan refund totals: %w", err)
+		}
+		if kind == models.RefundKindVoid {
+			report.VoidCount, report.Voids = count, amount
+		} else {
+			report.RefundCount, report.Refunds = count, amount
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A voided sale's tenders were handed back in full, so they are left out
+	// of what each method took in. Refunds are reported on their own.
+	rows, err = tx.Query(
+		`SELECT tp.method, COUNT(DISTINCT t.id), COALESCE(SUM(tp.amount), 0)
+		FROM transaction_payments tp
+		JOIN transactions t ON tp.transaction_id = t.id
+		WHERE t.z_report_id IS NOT DISTINCT FROM $1 AND t.store_id IS NOT DISTINCT FROM $2 AND t.voided_at IS NULL
+		GROUP BY tp.method
+		ORDER BY tp.method`,
+		zReportID, storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total payments: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p models.RegisterPayment
+		if err := rows.Scan(&p.Method, &p.TransactionCount, &p.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan payment totals: %w", err)
+		}
+		report.Payments = append(report.Payments, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.TotalRevenue = report.NetSales - report.Refunds - report.Voids
+	report.Tax = IncludedTax(report.TotalRevenue, taxRatePercent)
+	return report, nil
+}
+
+// IncludedTax is the tax contained in a tax-inclusive amount.
+func IncludedTax(amount, ratePercent int) int {
+	if ratePercent <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(amount) * float64(ratePercent) / float64(100+ratePercent)))
+}
+
+// lastZReport returns the number and period end of the store's latest Z
+// report, or zero values before the first one.
+func lastZReport(tx *sql.Tx, storeID *int) (int, *time.Time, error) {
+	var number int
+	var periodEnd *time.Time
+	err := tx.QueryRow(
+		`SELECT COALESCE(MAX(number), 0), MAX(period_end) FROM z_reports WHERE store_id IS NOT DISTINCT FROM $1`,
+		storeID,
+	).Scan(&number, &periodEnd)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get last Z report: %w", err)
+	}
+	return number, periodEnd, nil
+}
+
+// openPeriodStart is where the store's current register period began: the
+// end of its last Z report, or its first open sale before any Z report.
+func openPeriodStart(tx *sql.Tx, storeID *int, lastEnd *time.Time, now time.Time) (time.Time, error) {
+	if lastEnd != nil {
+		return *lastEnd, nil
+	}
+	var first *time.Time
+	err := tx.QueryRow(
+		`SELECT MIN(created_at) FROM transactions WHERE z_report_id IS NULL AND store_id IS NOT DISTINCT FROM $1`,
+		storeID,
+	).Scan(&first)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get period start: %w", err)
+	}
+	if first == nil {
+		return now, nil
+	}
+	return *first, nil
+}
+
+// GetXReport totals the store's register since its last Z report without
+// closing it.
+func (r *TransactionRepository) GetXReport(storeID *int) (*models.RegisterReport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, lastEnd, err := lastZReport(tx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	start, err := openPeriodStart(tx, storeID, lastEnd, now)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := registerTotals(tx, storeID, nil, r.cfg.TaxRatePercent)
+	if err != nil {
+		return nil, err
+	}
+	report.Type = models.RegisterReportX
+	report.PeriodStart, report.PeriodEnd = start, now
+	return report, nil
+}
+
+// CloseZReport closes the store's register. Every open sale and refund is
+// stamped with the new report first and the totals are taken from what was
+// stamped, so a checkout still in flight simply falls into the next Z.
+func (r *TransactionRepository) CloseZReport(storeID *int) (*models.RegisterReport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(current_tenant_id(), COALESCE($1::INT, 0))`, storeID); err != nil {
+		return nil, fmt.Errorf("failed to lock register: %w", err)
+	}
+
+	now := time.Now()
+	lastNumber, lastEnd, err := lastZReport(tx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	start, err := openPeriodStart(tx, storeID, lastEnd, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	if err := tx.QueryRow(`SELECT nextval(pg_get_serial_sequence('z_reports', 'id'))`).Scan(&id); err != nil {
+		return nil, fmt.Errorf("failed to allocate Z report: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE transactions SET z_report_id = $1 WHERE z_report_id IS NULL AND store_id IS NOT DISTINCT FROM $2`,
+		id, storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close transactions: %w", err)
+	}
+	_, err = tx.Exec(
+		`UPDATE refunds r SET z_report_id = $1
+		FROM transactions t
+		WHERE r.transaction_id = t.id AND r.z_report_id IS NULL AND t.store_id IS NOT DISTINCT FROM $2`,
+		id, storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close refunds: %w", err)
+	}
+
+	report, err := registerTotals(tx, storeID, &id, r.cfg.TaxRatePercent)
+	if err != nil {
+		return nil, err
+	}
+	report.ID = id
+	report.Type = models.RegisterReportZ
+	report.Number = lastNumber + 1
+	report.PeriodStart, report.PeriodEnd = start, now
+
+	_, err = tx.Exec(
+		`INSERT INTO z_reports (id, store_id, number, period_start, period_end, transaction_count, gross_sales, discounts, net_sales,
+			refund_count, refunds, void_count, voids, total_revenue, tax_rate_percent, tax, first_invoice, last_invoice)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, ''), NULLIF($18, ''))`,
+		report.ID, report.StoreID, report.Number, report.PeriodStart, report.PeriodEnd, report.TransactionCount,
+		report.GrossSales, report.Discounts, report.NetSales, report.RefundCount, report.Refunds, report.VoidCount, report.Voids,
+		report.TotalRevenue, report.TaxRatePercent, report.Tax, report.FirstInvoice, report.LastInvoice,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Z report: %w", err)
+	}
+
+	for _, p := range report.Payments {
+		_, err = tx.Exec(
+			`INSERT INTO z_report_payments (z_report_id, method, transaction_count, amount) VALUES ($1, $2, $3, $4)`,
+			report.ID, p.Method, p.TransactionCount, p.Amount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Z report payment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return report, nil
+}
+
+const zReportColumns = `id, store_id, number, period_start, period_end, transaction_count, gross_sales, discounts, net_sales,
+	refund_count, refunds, void_count, voids, total_revenue, tax_rate_percent, tax, COALESCE(first_invoice, ''), COALESCE(last_invoice, '')`
+
+func scanZReport(row interface{ Scan(...any) error }) (*models.RegisterReport, error) {
+	z := models.RegisterReport{Type: models.RegisterReportZ}
+	err := row.Scan(&z.ID, &z.StoreID, &z.Number, &z.PeriodStart, &z.PeriodEnd, &z.TransactionCount, &z.GrossSales, &z.Discounts,
+		&z.NetSales, &z.RefundCount, &z.Refunds, &z.VoidCount, &z.Voids, &z.TotalRevenue, &z.TaxRatePercent, &z.Tax,
+		&z.FirstInvoice, &z.LastInvoice)
+	if err != nil {
+		return nil, err
+	}
+	return &z, nil
+}
+
+// GetZReports lists Z reports newest first, for one store or for all of them
+// when storeID is nil. Payment totals are left out; GetZReport has them.
+func (r *TransactionRepository) GetZReports(storeID *int, limit int) ([]models.RegisterReport, error) {
+	rows, err := r.db.Query(
+		`SELECT `+zReportColumns+` FROM z_reports
+		WHERE $1::INT IS NULL OR store_id = $1
+		ORDER BY period_end DESC, id DESC
+		LIMIT $2`,
+		storeID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Z reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []models.RegisterReport{}
+	for rows.Next() {
+		z, err := scanZReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Z report: %w", err)
+		}
+		reports = append(reports, *z)
+	}
+
+	return reports, rows.Err()
+}
+
+func (r *TransactionRepository) GetZReport(id int) (*models.RegisterReport, error) {
+	z, err := scanZReport(r.db.QueryRow(`SELECT `+zReportColumns+` FROM z_reports WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("Z report not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Z report: %w", err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT method, transaction_count, amount FROM z_report_payments WHERE z_report_id = $1 ORDER BY method`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Z report payments: %w", err)
+	}
+	defer rows.Close()
+
+	z.Payments = []models.RegisterPayment{}
+	for rows.Next() {
+		var p models.RegisterPayment
+		if err := rows.Scan(&p.Method, &p.TransactionCount, &p.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan Z report payment: %w", err)
+		}
+		z.Payments = append(z.Payments, p)
+	}
+
+	return z, rows.Err()
+}
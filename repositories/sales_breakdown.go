@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"time"
+)
+
+// breakdownGroup describes how one group_by option keys and sums sales. Rows
+// come from the unvoided transactions in range (tx, net of refunds, with their
+// store-local time and business date), each transaction's sold quantity (qty)
+// and whatever the group joins onto them. Hours are clock hours; days, weeks and months follow
+// the business day.
+type breakdownGroup struct {
+	key, label string
+	revenue    string
+	quantity   string
+	from       string
+	byRevenue  bool
+}
+
+const breakdownFromTransactions = `tx LEFT JOIN qty ON qty.transaction_id = tx.id`
+
+const breakdownFromDetails = `tx
+	JOIN transaction_details td ON td.transaction_id = tx.id
+	LEFT JOIN products p ON td.product_id = p.id`
+
+var breakdownGroups = map[string]breakdownGroup{
+	models.BreakdownByHour: {
+		key:      `LPAD(EXTRACT(HOUR FROM tx.local_at)::TEXT, 2, '0')`,
+		label:    `LPAD(EXTRACT(HOUR FROM tx.local_at)::TEXT, 2, '0') || ':00'`,
+		revenue:  `tx.total_amount`,
+		quantity: `qty.quantity`,
+		from:     breakdownFromTransactions,
+	},
+	models.BreakdownByDay: {
+		key:      `TO_CHAR(tx.business_date, 'YYYY-MM-DD')`,
+		label:    `TO_CHAR(tx.business_date, 'YYYY-MM-DD')`,
+		revenue:  `tx.total_amount`,
+		quantity: `qty.quantity`,
+		from:     breakdownFromTransactions,
+	},
+	models.BreakdownByWeek: {
+		key:      `TO_CHAR(DATE_TRUNC('week', tx.business_date), 'YYYY-MM-DD')`,
+		label:    `TO_CHAR(tx.business_date, 'IYYY-"W"IW')`,
+		revenue:  `tx.total_amount`,
+		quantity: `qty.quantity`,
+		from:     breakdownFromTransactions,
+	},
+	models.BreakdownByMonth: {
+		key:      `TO_CHAR(tx.business_date, 'YYYY-MM')`,
+		label:    `TO_CHAR(tx.business_date, 'YYYY-MM')`,
+		revenue:  `tx.total_amount`,
+		quantity: `qty.quantity`,
+		from:     breakdownFromTransactions,
+	},
+	models.BreakdownByCashier: {
+		key:       `COALESCE(tx.cashier_id::TEXT, '')`,
+		label:     `COALESCE(e.name, 'Unassigned')`,
+		revenue:   `tx.total_amount`,
+		quantity:  `qty.quantity`,
+		from:      breakdownFromTransactions + ` LEFT JOIN employees e ON tx.cashier_id = e.id`,
+		byRevenue: true,
+	},
+	models.BreakdownByProduct: {
+		key:       `td.product_id::TEXT`,
+		label:     `COALESCE(p.name, '')`,
+		revenue:   netSubtotalSQL,
+		quantity:  netQuantitySQL,
+		from:      breakdownFromDetails,
+		byRevenue: true,
+	},
+	models.BreakdownByCategory: {
+		key:       `COALESCE(c.id::TEXT, '')`,
+		label:     `COALESCE(c.name, 'Uncategorized')`,
+		revenue:   netSubtotalSQL,
+		quantity:  netQuantitySQL,
+		from:      breakdownFromDetails + ` LEFT JOIN categories c ON p.category_id = c.id`,
+		byRevenue: true,
+	},
+	// Refunds do not record how they were paid out, so each tender gives back
+	// its share of them.
+	models.BreakdownByPaymentMethod: {
+		key:      `pay.method`,
+		label:    `pay.method`,
+		revenue:  `ROUND(pay.amount::NUMERIC * tx.total_amount / NULLIF(tx.gross_amount, 0))`,
+		quantity: `qty.quantity`,
+		from: `(SELECT transaction_id, method, SUM(amount) AS amount FROM transaction_payments GROUP BY transaction_id, method) pay
+			JOIN tx ON pay.transaction_id = tx.id
+			LEFT JOIN qty ON qty.transaction_id = tx.id`,
+		byRevenue: true,
+	},
+}
+
+// GetSalesBreakdown groups the sales between startDate and endDate (inclusive)
+// by groupBy. Time groups come back in time order, the rest by revenue.
+func (r *TransactionRepository) GetSalesBreakdown(startDate, endDate time.Time, storeID *int, groupBy string) ([]models.SalesBreakdownItem, error) {
+	series := []models.SalesBreakdownItem{}
+	err := r.EachSalesBreakdownItem(startDate, endDate, storeID, groupBy, func(item models.SalesBreakdownItem) error {
+		series = append(series, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// EachSalesBreakdownItem calls fn for every row of the breakdown as it is
+// read from the database, for exports too large to hold in memory.
+func (r *TransactionRepository) EachSalesBreakdownItem(startDate, endDate time.Time, storeID *int, groupBy string, fn func(models.SalesBreakdownItem) error) error {
+	group, ok := breakdownGroups[groupBy]
+	if !ok {
+		return fmt.Errorf("unknown group_by %q", groupBy)
+	}
+
+	order := "1"
+	if group.byRevenue {
+		order = "3 DESC, 1"
+	}
+
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return err
+	}
+	from, to := day.Range(startDate, endDate)
+
+	rows, err := r.db.Query(
+		`WITH tx AS (
+			SELECT t.id, t.cashier_id, `+netSaleSQL+` AS total_amount, t.total_amount AS gross_amount,
+				t.created_at AT TIME ZONE $4::TEXT AS local_at,
+				(t.created_at AT TIME ZONE $4::TEXT - $5::INT * INTERVAL '1 minute')::DATE AS business_date
+			FROM transactions t
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL AND ($3::INT IS NULL OR t.store_id = $3)
+		), qty AS (
+			SELECT td.transaction_id, SUM(`+netQuantitySQL+`) AS quantity
+			FROM transaction_details td JOIN tx ON td.transaction_id = tx.id
+			GROUP BY td.transaction_id
+		)
+		SELECT `+group.key+`, `+group.label+`, COALESCE(SUM(`+group.revenue+`), 0)::BIGINT,
+			COALESCE(SUM(`+group.quantity+`), 0), COUNT(DISTINCT tx.id)
+		FROM `+group.from+`
+		GROUP BY 1, 2
+		ORDER BY `+order,
+		from, to, storeID, day.Location.String(), day.CutoffMinutes(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get sales breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.SalesBreakdownItem
+		if err := rows.Scan(&item.Key, &item.Label, &item.Revenue, &item.Quantity, &item.TransactionCount); err != nil {
+			return fmt.Errorf("failed to scan sales breakdown: %w", err)
+		}
+		if item.TransactionCount > 0 {
+			item.AverageBasket = item.Revenue / item.TransactionCount
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
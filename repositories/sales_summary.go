@@ -0,0 +1,305 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// businessDateSQL is the business date of created_at given the store's time
+// zone ($3) and cutoff in minutes ($4).
+const businessDateSQL = `(t.created_at AT TIME ZONE $3::TEXT - $4::INT * INTERVAL '1 minute')::DATE`
+
+// Sales reports leave voided sales (t.voided_at IS NOT NULL) out and count
+// what other refunds gave back against the sale they refunded. netSaleSQL is
+// what a sale (t) brought in after its refunds, and netQuantitySQL and
+// netSubtotalSQL what is left of a sold line (td).
+const (
+	netSaleSQL     = `(t.total_amount - COALESCE((SELECT SUM(rf.total_amount) FROM refunds rf WHERE rf.transaction_id = t.id), 0))`
+	netQuantitySQL = `(td.base_quantity - COALESCE((SELECT SUM(ri.base_quantity) FROM refund_items ri WHERE ri.transaction_detail_id = td.id), 0))`
+	netSubtotalSQL = `(td.subtotal - COALESCE((SELECT SUM(ri.amount) FROM refund_items ri WHERE ri.transaction_detail_id = td.id), 0))`
+)
+
+// summaryVersion marks summaries that leave voided sales out and net out
+// refunds. A summary with an older version is rebuilt.
+const summaryVersion = 2
+
+// RollUpSales adds the closed business days (every day before the one at
+// now) that daily_sales_summary does not cover yet, and returns how many days
+// it added. A summary cut with another time zone or cutoff is rebuilt. Closed
+// days change afterwards only through refunds and voids, which summarize
+// their sale's day again themselves.
+func (r *TransactionRepository) RollUpSales(now time.Time) (int, error) {
+	return r.rollUpSales(now, false)
+}
+
+// RebuildSalesSummary discards daily_sales_summary and rolls every closed
+// day up again, for after history was corrected by hand.
+func (r *TransactionRepository) RebuildSalesSummary(now time.Time) (int, error) {
+	return r.rollUpSales(now, true)
+}
+
+func (r *TransactionRepository) rollUpSales(now time.Time, rebuild bool) (int, error) {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockSalesSummary(tx); err != nil {
+		return 0, err
+	}
+
+	var through *time.Time
+	if !rebuild {
+		if through, err = summaryThrough(tx, day); err != nil {
+			return 0, err
+		}
+	}
+	if through == nil {
+		if _, err := tx.Exec(`DELETE FROM daily_sales_summary`); err != nil {
+			return 0, fmt.Errorf("failed to clear sales summary: %w", err)
+		}
+	}
+
+	closed := dateOnly(day.Date(now)).AddDate(0, 0, -1)
+	var from time.Time
+	if through != nil {
+		from = through.AddDate(0, 0, 1)
+	} else {
+		var first *time.Time
+		if err := tx.QueryRow(`SELECT MIN(created_at) FROM transactions`).Scan(&first); err != nil {
+			return 0, fmt.Errorf("failed to find first sale: %w", err)
+		}
+		from = closed.AddDate(0, 0, 1)
+		if first != nil {
+			from = dateOnly(day.Date(*first))
+		}
+	}
+
+	days := 0
+	if !from.After(closed) {
+		if err := summarizeSales(tx, day, from, closed, nil); err != nil {
+			return 0, err
+		}
+		days = int(closed.Sub(from).Hours()/24) + 1
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO sales_summary_state (rolled_up_through, store_timezone, cutoff_minutes, version)
+		VALUES ($1::DATE, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE SET rolled_up_through = EXCLUDED.rolled_up_through,
+			store_timezone = EXCLUDED.store_timezone, cutoff_minutes = EXCLUDED.cutoff_minutes,
+			version = EXCLUDED.version, updated_at = CURRENT_TIMESTAMP`,
+		closed.Format("2006-01-02"), day.Location.String(), day.CutoffMinutes(), summaryVersion,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update sales summary state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return days, nil
+}
+
+// salesSummaryLock is the advisory lock key of the tenant's
+// daily_sales_summary.
+const salesSummaryLock = `hashtext('daily_sales_summary:' || current_tenant_id())`
+
+// lockSalesSummary keeps rollups, refunds and voids from changing the
+// tenant's daily_sales_summary at the same time.
+func lockSalesSummary(tx *sql.Tx) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(` + salesSummaryLock + `)`); err != nil {
+		return fmt.Errorf("failed to lock sales summary: %w", err)
+	}
+	return nil
+}
+
+// shareSalesSummary keeps a rollup from closing a business day while a sale
+// dated in it has yet to commit. Checkouts take the lock shared, so they do
+// not wait for each other, only for a rollup in progress and it for them.
+func shareSalesSummary(tx *sql.Tx) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock_shared(` + salesSummaryLock + `)`); err != nil {
+		return fmt.Errorf("failed to lock sales summary: %w", err)
+	}
+	return nil
+}
+
+// lockClosedSaleDay takes the summary lock for a refund or void of a sale
+// from a closed business day before any row is locked. resummarizeSale would
+// only take it after the stock rows, which a checkout holding the lock shared
+// may be waiting for.
+func (r *TransactionRepository) lockClosedSaleDay(tx *sql.Tx, transactionID int) error {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return err
+	}
+
+	var createdAt time.Time
+	err = tx.QueryRow(`SELECT created_at FROM transactions WHERE id = $1`, transactionID).Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("transaction not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if !dateOnly(day.Date(createdAt)).Before(dateOnly(day.Date(time.Now()))) {
+		return nil
+	}
+	return lockSalesSummary(tx)
+}
+
+// summarizeSales adds the business days from startDate to endDate to
+// daily_sales_summary, for one store when storeID is set. storeID points to
+// nil for sales made outside any store.
+func summarizeSales(tx *sql.Tx, day BusinessDay, startDate, endDate time.Time, storeID **int) error {
+	start, end := day.Range(startDate, endDate)
+	var store *int
+	if storeID != nil {
+		store = *storeID
+	}
+	args := []any{start, end, day.Location.String(), day.CutoffMinutes(), storeID != nil, store}
+
+	_, err := tx.Exec(
+		`INSERT INTO daily_sales_summary (business_date, store_id, product_id, revenue, transaction_count)
+		SELECT `+businessDateSQL+`, t.store_id, NULL, SUM(`+netSaleSQL+`), COUNT(*)
+		FROM transactions t
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL
+			AND (NOT $5 OR t.store_id IS NOT DISTINCT FROM $6::INT)
+		GROUP BY 1, 2`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to summarize sales: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO daily_sales_summary (business_date, store_id, product_id, quantity, revenue, transaction_count)
+		SELECT `+businessDateSQL+`, t.store_id, td.product_id, SUM(`+netQuantitySQL+`), SUM(`+netSubtotalSQL+`), COUNT(DISTINCT t.id)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL
+			AND (NOT $5 OR t.store_id IS NOT DISTINCT FROM $6::INT)
+		GROUP BY 1, 2, 3`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to summarize product sales: %w", err)
+	}
+	return nil
+}
+
+// resummarizeSale summarizes the business day of a sale again after a refund
+// or void changed it, if that day has already been rolled up. It runs inside
+// the refund's transaction, so reports never see one without the other.
+func (r *TransactionRepository) resummarizeSale(tx *sql.Tx, transactionID int) error {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return err
+	}
+
+	var createdAt time.Time
+	var storeID *int
+	if err := tx.QueryRow(`SELECT created_at, store_id FROM transactions WHERE id = $1`, transactionID).Scan(&createdAt, &storeID); err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+	date := dateOnly(day.Date(createdAt))
+	if !date.Before(dateOnly(day.Date(time.Now()))) {
+		return nil
+	}
+
+	if err := lockSalesSummary(tx); err != nil {
+		return err
+	}
+	through, err := summaryThrough(tx, day)
+	if err != nil {
+		return err
+	}
+	if through == nil || date.After(*through) {
+		return nil
+	}
+
+	_, err = tx.Exec(
+		`DELETE FROM daily_sales_summary WHERE business_date = $1::DATE AND store_id IS NOT DISTINCT FROM $2`,
+		date.Format("2006-01-02"), storeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear sales summary: %w", err)
+	}
+	return summarizeSales(tx, day, date, date, &storeID)
+}
+
+// summaryThrough is the last business day daily_sales_summary covers, or nil
+// when there is no current summary cut the way day cuts days.
+func summaryThrough(q queryRower, day BusinessDay) (*time.Time, error) {
+	var through time.Time
+	err := q.QueryRow(
+		`SELECT rolled_up_through FROM sales_summary_state WHERE store_timezone = $1 AND cutoff_minutes = $2 AND version = $3`,
+		day.Location.String(), day.CutoffMinutes(), summaryVersion,
+	).Scan(&through)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sales summary state: %w", err)
+	}
+	through = dateOnly(through)
+	return &through, nil
+}
+
+// dateOnly keeps t's year, month and day at midnight UTC, so dates read in
+// different zones compare and subtract as whole days.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// salesSplit divides a report's business days between daily_sales_summary
+// and the live tables. SummaryFrom and SummaryTo are inclusive dates, and the
+// summary part is empty when SummaryTo is before SummaryFrom; LiveFrom and
+// LiveTo are a half-open span of time, empty when equal.
+type salesSplit struct {
+	SummaryFrom, SummaryTo string
+	LiveFrom, LiveTo       time.Time
+}
+
+func (r *TransactionRepository) splitSales(startDate, endDate time.Time) (salesSplit, error) {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return salesSplit{}, err
+	}
+	through, err := summaryThrough(r.db, day)
+	if err != nil {
+		return salesSplit{}, err
+	}
+
+	startDate, endDate = dateOnly(startDate), dateOnly(endDate)
+	split := salesSplit{
+		SummaryFrom: startDate.Format("2006-01-02"),
+		SummaryTo:   startDate.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	liveStart := startDate
+	if through != nil && !through.Before(startDate) {
+		covered := endDate
+		if through.Before(endDate) {
+			covered = *through
+		}
+		split.SummaryTo = covered.Format("2006-01-02")
+		liveStart = covered.AddDate(0, 0, 1)
+	}
+
+	if liveStart.After(endDate) {
+		split.LiveFrom = day.Start(liveStart)
+		split.LiveTo = split.LiveFrom
+	} else {
+		split.LiveFrom, split.LiveTo = day.Range(liveStart, endDate)
+	}
+	return split, nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type ScaleBarcode struct {
+	PLU    string
+	Weight *float64
+	Price  *int
+}
+
+// ParseScaleBarcode decodes an EAN-13 printed by an in-store scale: a two
+// digit prefix starting with 2, the product PLU, then either the weight in
+// grams or the price in Rupiah, and a check digit. It returns nil for codes
+// that are not scale barcodes.
+func ParseScaleBarcode(code string, cfg *config.Config) (*ScaleBarcode, error) {
+	if len(code) != 13 || code[0] != '2' {
+		return nil, nil
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return nil, nil
+		}
+	}
+
+	prefix := code[:2]
+	isWeight := containsPrefix(cfg.ScaleWeightPrefixes, prefix)
+	if !isWeight && !containsPrefix(cfg.ScalePricePrefixes, prefix) {
+		return nil, nil
+	}
+
+	pluDigits := cfg.ScalePLUDigits
+	if pluDigits <= 0 || pluDigits >= 10 {
+		return nil, fmt.Errorf("SCALE_PLU_DIGITS must be between 1 and 9")
+	}
+	if eanCheckDigit(code[:12]) != code[12] {
+		return nil, fmt.Errorf("invalid check digit in scale barcode %s", code)
+	}
+
+	value, _ := strconv.Atoi(code[2+pluDigits : 12])
+	scale := &ScaleBarcode{PLU: strings.TrimLeft(code[2:2+pluDigits], "0")}
+	if isWeight {
+		kg := float64(value) / 1000
+		scale.Weight = &kg
+	} else {
+		scale.Price = &value
+	}
+	return scale, nil
+}
+
+// applyScaleBarcode points item at the product whose PLU is in the barcode
+// and, for weight barcodes, sells the embedded weight in the base unit.
+func applyScaleBarcode(tx *sql.Tx, item models.CheckoutItem, scale *ScaleBarcode) (models.CheckoutItem, error) {
+	var productID int
+	err := tx.QueryRow(`SELECT id FROM products WHERE LTRIM(plu, '0') = $1`, scale.PLU).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return item, fmt.Errorf("no product with PLU %s for scale barcode %s", scale.PLU, item.Barcode)
+	}
+	if err != nil {
+		return item, fmt.Errorf("failed to look up PLU %s: %w", scale.PLU, err)
+	}
+
+	if item.ProductID != 0 && item.ProductID != productID {
+		return item, fmt.Errorf("barcode %s belongs to product %d, not %d", item.Barcode, productID, item.ProductID)
+	}
+	item.ProductID = productID
+	item.Unit = ""
+	if scale.Weight != nil {
+		item.Quantity = *scale.Weight
+	}
+	return item, nil
+}
+
+// quantityForAmount works out how much of a product an embedded price buys,
+// rounded to the product's precision.
+func quantityForAmount(amount, unitPrice, precision int) float64 {
+	if unitPrice <= 0 {
+		return 0
+	}
+	p := math.Pow10(precision)
+	return math.Round(float64(amount)/float64(unitPrice)*p) / p
+}
+
+func containsPrefix(list, prefix string) bool {
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+func eanCheckDigit(digits string) byte {
+	sum := 0
+	for i, c := range digits {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
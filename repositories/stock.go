@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type lockedProduct struct {
+	name        string
+	price       int
+	stock       float64
+	minStock    float64
+	reorderQty  float64
+	trackExpiry bool
+	baseUnit    string
+	precision   int
+}
+
+// lockProductStock locks the stock row a checkout will deduct from: the
+// product itself in single-store mode, or its store_products row when the
+// sale belongs to a store.
+func lockProductStock(tx *sql.Tx, productID int, storeID *int) (*lockedProduct, error) {
+	var p lockedProduct
+	var err error
+	if storeID == nil {
+		err = tx.QueryRow(
+			`SELECT name, price, stock, min_stock, reorder_qty, track_expiry, base_unit, quantity_precision FROM products WHERE id = $1 FOR UPDATE`,
+			productID,
+		).Scan(&p.name, &p.price, &p.stock, &p.minStock, &p.reorderQty, &p.trackExpiry, &p.baseUnit, &p.precision)
+	} else {
+		err = tx.QueryRow(
+			`SELECT p.name, COALESCE(sp.price, p.price), sp.stock, p.min_stock, p.reorder_qty, p.track_expiry, p.base_unit, p.quantity_precision
+			FROM store_products sp
+			JOIN products p ON sp.product_id = p.id
+			WHERE sp.product_id = $1 AND sp.store_id = $2
+			FOR UPDATE OF sp`,
+			productID, *storeID,
+		).Scan(&p.name, &p.price, &p.stock, &p.minStock, &p.reorderQty, &p.trackExpiry, &p.baseUnit, &p.precision)
+	}
+
+	if err == sql.ErrNoRows {
+		if storeID != nil {
+			return nil, fmt.Errorf("product with ID %d not found in store %d", productID, *storeID)
+		}
+		return nil, fmt.Errorf("product with ID %d not found", productID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product %d: %w", productID, err)
+	}
+
+	return &p, nil
+}
+
+// crossesMinStock reports whether selling quantity takes the product from
+// above its minimum stock to at or below it.
+func (p *lockedProduct) crossesMinStock(quantity float64) bool {
+	return p.minStock > 0 && p.stock > p.minStock && p.stock-quantity <= p.minStock
+}
+
+func deductStock(tx *sql.Tx, productID int, storeID *int, quantity float64) error {
+	var err error
+	if storeID == nil {
+		_, err = tx.Exec(`UPDATE products SET stock = stock - $1 WHERE id = $2`, quantity, productID)
+	} else {
+		_, err = tx.Exec(`UPDATE store_products SET stock = stock - $1 WHERE product_id = $2 AND store_id = $3`, quantity, productID, *storeID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update stock for product %d: %w", productID, err)
+	}
+	return nil
+}
+
+func storeCode(tx *sql.Tx, storeID int) (string, error) {
+	var code string
+	err := tx.QueryRow(`SELECT code FROM stores WHERE id = $1 AND active`, storeID).Scan(&code)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("store with ID %d not found", storeID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get store %d: %w", storeID, err)
+	}
+	return code, nil
+}
+
+func recordMovement(tx *sql.Tx, productID int, storeID *int, quantity float64, reason, referenceType string, referenceID *int) error {
+	_, err := tx.Exec(
+		`INSERT INTO stock_movements (product_id, store_id, quantity, reason, reference_type, reference_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)`,
+		productID, storeID, quantity, reason, referenceType, referenceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement for product %d: %w", productID, err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+)
+
+type StoreRepository struct {
+	db *sql.DB
+}
+
+func NewStoreRepository(db *sql.DB) *StoreRepository {
+	return &StoreRepository{db: db}
+}
+
+func (r *StoreRepository) GetAll() ([]models.Store, error) {
+	rows, err := r.db.Query(`SELECT id, code, name, COALESCE(address, ''), active, created_at FROM stores ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stores: %w", err)
+	}
+	defer rows.Close()
+
+	var stores []models.Store
+	for rows.Next() {
+		var s models.Store
+		if err := rows.Scan(&s.ID, &s.Code, &s.Name, &s.Address, &s.Active, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan store: %w", err)
+		}
+		stores = append(stores, s)
+	}
+
+	return stores, rows.Err()
+}
+
+func (r *StoreRepository) GetByID(id int) (*models.Store, error) {
+	var s models.Store
+	err := r.db.QueryRow(
+		`SELECT id, code, name, COALESCE(address, ''), active, created_at FROM stores WHERE id = $1`,
+		id,
+	).Scan(&s.ID, &s.Code, &s.Name, &s.Address, &s.Active, &s.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	return &s, nil
+}
+
+func (r *StoreRepository) Create(store *models.Store) error {
+	return r.db.QueryRow(
+		`INSERT INTO stores (code, name, address) VALUES ($1, $2, NULLIF($3, '')) RETURNING id, active, created_at`,
+		store.Code, store.Name, store.Address,
+	).Scan(&store.ID, &store.Active, &store.CreatedAt)
+}
+
+func (r *StoreRepository) Update(store *models.Store) error {
+	err := r.db.QueryRow(
+		`UPDATE stores SET code = $1, name = $2, address = NULLIF($3, ''), active = $4 WHERE id = $5 RETURNING created_at`,
+		store.Code, store.Name, store.Address, store.Active, store.ID,
+	).Scan(&store.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("store not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update store: %w", err)
+	}
+
+	return nil
+}
+
+func (r *StoreRepository) Delete(id int) error {
+	result, err := r.db.Exec(`UPDATE stores SET active = FALSE WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete store: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("store not found")
+	}
+
+	return nil
+}
+
+func (r *StoreRepository) GetProducts(storeID int) ([]models.StoreProduct, error) {
+	rows, err := r.db.Query(
+		`SELECT sp.store_id, sp.product_id, p.name, sp.stock, p.price, sp.price, COALESCE(sp.price, p.price)
+		FROM store_products sp
+		JOIN products p ON sp.product_id = p.id
+		WHERE sp.store_id = $1
+		ORDER BY p.id`,
+		storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []models.StoreProduct
+	for rows.Next() {
+		var p models.StoreProduct
+		if err := rows.Scan(&p.StoreID, &p.ProductID, &p.ProductName, &p.Stock, &p.BasePrice, &p.PriceOverride, &p.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan store product: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
+
+func (r *StoreRepository) SetProduct(product *models.StoreProduct) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldStock float64
+	err = tx.QueryRow(
+		`SELECT stock FROM store_products WHERE store_id = $1 AND product_id = $2 FOR UPDATE`,
+		product.StoreID, product.ProductID,
+	).Scan(&oldStock)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get store product: %w", err)
+	}
+
+	var trackExpiry bool
+	err = tx.QueryRow(
+		`WITH upserted AS (
+			INSERT INTO store_products (store_id, product_id, stock, price) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (store_id, product_id) DO UPDATE SET stock = EXCLUDED.stock, price = EXCLUDED.price
+			RETURNING product_id, price
+		)
+		SELECT p.name, p.price, COALESCE(u.price, p.price), p.track_expiry
+		FROM upserted u
+		JOIN products p ON u.product_id = p.id`,
+		product.StoreID, product.ProductID, product.Stock, product.PriceOverride,
+	).Scan(&product.ProductName, &product.BasePrice, &product.Price, &trackExpiry)
+	if err != nil {
+		return fmt.Errorf("failed to set store product: %w", err)
+	}
+
+	if product.Stock != oldStock {
+		if err := recordMovement(tx, product.ProductID, &product.StoreID, product.Stock-oldStock, "adjustment", "", nil); err != nil {
+			return err
+		}
+	}
+	if trackExpiry {
+		if err := syncBatches(tx, product.ProductID, &product.StoreID, product.Stock); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *StoreRepository) RemoveProduct(storeID, productID int) error {
+	result, err := r.db.Exec(`DELETE FROM store_products WHERE store_id = $1 AND product_id = $2`, storeID, productID)
+	if err != nil {
+		return fmt.Errorf("failed to remove store product: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("store product not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+type TenantRepository struct {
+	db *sql.DB
+}
+
+func NewTenantRepository(db *sql.DB) *TenantRepository {
+	return &TenantRepository{db: db}
+}
+
+func (r *TenantRepository) GetAll() ([]models.Tenant, error) {
+	rows, err := r.db.Query(`SELECT id, slug, name, active, created_at FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []models.Tenant
+	for rows.Next() {
+		var t models.Tenant
+		if err := rows.Scan(&t.ID, &t.Slug, &t.Name, &t.Active, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+
+	return tenants, rows.Err()
+}
+
+func (r *TenantRepository) GetByID(id int) (*models.Tenant, error) {
+	return r.findOne(`SELECT id, slug, name, active, created_at FROM tenants WHERE id = $1`, id)
+}
+
+func (r *TenantRepository) GetBySlug(slug string) (*models.Tenant, error) {
+	return r.findOne(`SELECT id, slug, name, active, created_at FROM tenants WHERE slug = $1`, slug)
+}
+
+func (r *TenantRepository) GetByToken(token string) (*models.Tenant, error) {
+	return r.findOne(`SELECT id, slug, name, active, created_at FROM tenants WHERE token_hash = $1`, hashToken(token))
+}
+
+func (r *TenantRepository) findOne(query string, arg interface{}) (*models.Tenant, error) {
+	var t models.Tenant
+	err := r.db.QueryRow(query, arg).Scan(&t.ID, &t.Slug, &t.Name, &t.Active, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *TenantRepository) Create(tenant *models.Tenant, token string) error {
+	err := r.db.QueryRow(
+		`INSERT INTO tenants (slug, name, token_hash) VALUES ($1, $2, $3) RETURNING id, active, created_at`,
+		tenant.Slug, tenant.Name, hashToken(token),
+	).Scan(&tenant.ID, &tenant.Active, &tenant.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TenantRepository) SetToken(id int, token string) error {
+	result, err := r.db.Exec(`UPDATE tenants SET token_hash = $1 WHERE id = $2`, hashToken(token), id)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("tenant not found")
+	}
+
+	return nil
+}
+
+func (r *TenantRepository) SetActive(id int, active bool) error {
+	result, err := r.db.Exec(`UPDATE tenants SET active = $1 WHERE id = $2`, active, id)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("tenant not found")
+	}
+
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"andre_kasir_api/config"
 	"andre_kasir_api/models"
 	"database/sql"
 	"fmt"
@@ -8,167 +9,601 @@ import (
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	cfg *config.Config
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+func NewTransactionRepository(db *sql.DB, cfg *config.Config) *TransactionRepository {
+	return &TransactionRepository{db: db, cfg: cfg}
 }
 
-func (r *TransactionRepository) Checkout(req *models.CheckoutRequest) (*models.Transaction, error) {
+// Checkout records the sale and returns, alongside the transaction, an alert
+// for every product whose stock this sale took down to its minimum.
+func (r *TransactionRepository) Checkout(req *models.CheckoutRequest) (*models.Transaction, []models.StockAlert, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// Taken before the sale is dated, so a rollup either sees the sale or
+	// closes the day before it is dated.
+	if err := shareSalesSummary(tx); err != nil {
+		return nil, nil, err
+	}
+
+	// PINs are checked before any row is locked: a bcrypt check is slow.
+	if req.StaffPIN != "" {
+		if _, err := findStaffByPIN(tx, r.cfg.PINLookupKey, req.StaffPIN); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var cashierID *int
+	if req.CashierPIN != "" {
+		id, err := findEmployeeByPIN(tx, r.cfg.PINLookupKey, req.CashierPIN, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		if id == 0 {
+			return nil, nil, fmt.Errorf("invalid cashier PIN")
+		}
+		cashierID = &id
+	}
+
+	var customer *checkoutCustomer
+	if req.CustomerID != nil {
+		customer, err = lockCustomer(tx, *req.CustomerID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	buyer := PriceBuyer{Member: customer != nil, Staff: req.StaffPIN != ""}
+	if customer != nil {
+		buyer.MemberTier = customer.tier
+	}
+
+	invoiceStoreCode := r.cfg.StoreCode
+	if req.StoreID != nil {
+		if invoiceStoreCode, err = storeCode(tx, *req.StoreID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().In(day.Location)
 	var totalAmount int
+	approval := &priceApproval{key: r.cfg.PINLookupKey, pin: req.ManagerPIN}
 	details := make([]models.TransactionDetail, 0, len(req.Items))
+	var alerts []models.StockAlert
+	batches := make([][]batchPick, 0, len(req.Items))
 
 	for _, item := range req.Items {
-		var price, stock int
-		var productName string
-		err := tx.QueryRow(
-			`SELECT name, price, stock FROM products WHERE id = $1 FOR UPDATE`,
-			item.ProductID,
-		).Scan(&productName, &price, &stock)
+		var scale *ScaleBarcode
+		if item.Barcode != "" {
+			if scale, err = ParseScaleBarcode(item.Barcode, r.cfg); err != nil {
+				return nil, nil, err
+			}
+			if scale != nil {
+				item, err = applyScaleBarcode(tx, item, scale)
+			} else {
+				item, err = resolveBarcode(tx, item)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
 
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product with ID %d not found", item.ProductID)
+		if _, err := applyDuePrices(tx, &item.ProductID); err != nil {
+			return nil, nil, err
 		}
+
+		product, err := lockProductStock(tx, item.ProductID, req.StoreID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product %d: %w", item.ProductID, err)
+			return nil, nil, err
 		}
 
-		if stock < item.Quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s: available %d, requested %d", productName, stock, item.Quantity)
+		unit, err := resolveUnit(tx, item.ProductID, item.Unit, product.baseUnit, product.price)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		subtotal := price * item.Quantity
-		totalAmount += subtotal
+		if scale != nil && scale.Price != nil {
+			item.Quantity = quantityForAmount(*scale.Price, unit.price, product.precision)
+		}
+		if err := CheckPrecision(item.Quantity, product.precision, product.name); err != nil {
+			return nil, nil, err
+		}
+		baseQuantity := RoundQuantity(item.Quantity * float64(unit.factor))
 
-		details = append(details, models.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
-		})
+		if product.stock < baseQuantity {
+			return nil, nil, fmt.Errorf("insufficient stock for product %s: available %g %s, requested %g %s", product.name, product.stock, product.baseUnit, baseQuantity, product.baseUnit)
+		}
+
+		var picks []batchPick
+		if product.trackExpiry {
+			if picks, err = pickBatches(tx, item.ProductID, req.StoreID, product.name, baseQuantity); err != nil {
+				return nil, nil, err
+			}
+		}
+		batches = append(batches, picks)
+
+		listPrice := unit.price
+		var priceList *PriceListEntry
+		if scale == nil || scale.Price == nil {
+			entries, err := priceListEntries(tx, item.ProductID)
+			if err != nil {
+				return nil, nil, err
+			}
+			entries = BelowUnitPrice(entries, unit.price, unit.factor)
+			if priceList = ChoosePriceList(entries, buyer, baseQuantity, now); priceList != nil {
+				listPrice = priceList.Price * unit.factor
+			}
+		}
 
-		_, err = tx.Exec(
-			`UPDATE products SET stock = stock - $1 WHERE id = $2`,
-			item.Quantity, item.ProductID,
-		)
+		detail, err := r.priceLine(tx, item, product.name, listPrice, approval)
 		if err != nil {
-			return nil, fmt.Errorf("failed to update stock for product %d: %w", item.ProductID, err)
+			return nil, nil, err
+		}
+		if priceList != nil {
+			detail.PriceListID = &priceList.PriceListID
+			detail.PriceList = priceList.Name
+		}
+		if scale != nil && scale.Price != nil && item.OverridePrice == nil {
+			detail.Subtotal = *scale.Price - detail.Discount
+		}
+		detail.Unit = unit.name
+		detail.BaseQuantity = baseQuantity
+		totalAmount += detail.Subtotal
+		details = append(details, *detail)
+
+		if err := deductStock(tx, item.ProductID, req.StoreID, baseQuantity); err != nil {
+			return nil, nil, err
+		}
+
+		if product.crossesMinStock(baseQuantity) {
+			alerts = append(alerts, models.StockAlert{
+				ProductID:   item.ProductID,
+				ProductName: product.name,
+				StoreID:     req.StoreID,
+				Stock:       RoundQuantity(product.stock - baseQuantity),
+				MinStock:    product.minStock,
+				ReorderQty:  product.reorderQty,
+			})
 		}
 	}
 
+	payments, change, err := resolvePayments(totalAmount, req.Payments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pointsRedeemed, pointsEarned int
+	pointsAmount := paymentTotal(payments, models.PaymentMethodPoints)
+	if pointsAmount > 0 {
+		if customer == nil {
+			return nil, nil, fmt.Errorf("customer_id is required to pay with points")
+		}
+		if r.cfg.PointValue <= 0 {
+			return nil, nil, fmt.Errorf("points redemption is disabled")
+		}
+		if pointsAmount%r.cfg.PointValue != 0 {
+			return nil, nil, fmt.Errorf("points payment must be a multiple of %d", r.cfg.PointValue)
+		}
+		pointsRedeemed = pointsAmount / r.cfg.PointValue
+		if pointsRedeemed > customer.points {
+			return nil, nil, fmt.Errorf("insufficient points: available %d, requested %d", customer.points, pointsRedeemed)
+		}
+	}
+
+	creditAmount := paymentTotal(payments, models.PaymentMethodCredit)
+	if creditAmount > 0 {
+		if customer == nil {
+			return nil, nil, fmt.Errorf("customer_id is required for credit sales")
+		}
+		if customer.outstandingCredit+creditAmount > customer.creditLimit {
+			return nil, nil, fmt.Errorf("credit limit exceeded: limit %d, outstanding %d, requested %d", customer.creditLimit, customer.outstandingCredit, creditAmount)
+		}
+	}
+
+	createdAt := now
+	redemptions, err := redeemVouchers(tx, payments, createdAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if customer != nil && r.cfg.PointsEarnUnit > 0 {
+		pointsEarned = PointsEarningAmount(payments) / r.cfg.PointsEarnUnit * customer.multiplierPercent / 100
+	}
+
+	businessDate := day.Date(createdAt)
+	seqStore, seqDate := InvoiceSequence(r.cfg.InvoiceFormat, invoiceStoreCode, businessDate)
+	seq, err := nextInvoiceNumber(tx, seqStore, seqDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	invoiceNumber := FormatInvoiceNumber(r.cfg.InvoiceFormat, invoiceStoreCode, businessDate, seq, r.cfg.InvoiceDigits)
+
 	var transactionID int
 	err = tx.QueryRow(
-		`INSERT INTO transactions (total_amount, created_at) VALUES ($1, $2) RETURNING id`,
-		totalAmount, time.Now(),
+		`INSERT INTO transactions (invoice_number, store_id, total_amount, customer_id, cashier_id, points_earned, points_redeemed, change_amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		invoiceNumber, req.StoreID, totalAmount, req.CustomerID, cashierID, pointsEarned, pointsRedeemed, change, createdAt,
 	).Scan(&transactionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
 	for i := range details {
-		_, err = tx.Exec(
-			`INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4)`,
-			transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal,
-		)
+		err = tx.QueryRow(
+			`INSERT INTO transaction_details (transaction_id, product_id, quantity, unit, base_quantity, original_price, unit_price, discount, subtotal,
+				override_reason, approved_by, price_list_id, price_list)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, NULLIF($13, '')) RETURNING id`,
+			transactionID, details[i].ProductID, details[i].Quantity, details[i].Unit, details[i].BaseQuantity, details[i].OriginalPrice, details[i].UnitPrice,
+			details[i].Discount, details[i].Subtotal, details[i].OverrideReason, details[i].ApprovedBy, details[i].PriceListID, details[i].PriceList,
+		).Scan(&details[i].ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create transaction detail: %w", err)
+			return nil, nil, fmt.Errorf("failed to create transaction detail: %w", err)
 		}
 		details[i].TransactionID = transactionID
+
+		if err := insertBatchPicks(tx, "transaction_detail_batches", "transaction_detail_id", details[i].ID, batches[i]); err != nil {
+			return nil, nil, err
+		}
+
+		if err := recordMovement(tx, details[i].ProductID, req.StoreID, -details[i].BaseQuantity, "sale", "transaction", &transactionID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := insertPayments(tx, transactionID, payments); err != nil {
+		return nil, nil, err
+	}
+
+	if err := insertVoucherRedemptions(tx, transactionID, redemptions); err != nil {
+		return nil, nil, err
+	}
+
+	if creditAmount > 0 {
+		if err := createReceivable(tx, customer.id, transactionID, creditAmount); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if customer != nil {
+		if pointsRedeemed > 0 {
+			if err := addPoints(tx, customer.id, &transactionID, -pointsRedeemed, fmt.Sprintf("Redeemed on transaction #%d", transactionID)); err != nil {
+				return nil, nil, err
+			}
+		}
+		if pointsEarned > 0 {
+			if err := addPoints(tx, customer.id, &transactionID, pointsEarned, fmt.Sprintf("Earned on transaction #%d", transactionID)); err != nil {
+				return nil, nil, err
+			}
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i := range alerts {
+		alerts[i].TransactionID = transactionID
+		alerts[i].InvoiceNumber = invoiceNumber
+		alerts[i].CreatedAt = createdAt
 	}
 
 	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		CreatedAt:   time.Now(),
-		Details:     details,
-	}, nil
+		ID:             transactionID,
+		InvoiceNumber:  invoiceNumber,
+		StoreID:        req.StoreID,
+		TotalAmount:    totalAmount,
+		CustomerID:     req.CustomerID,
+		CashierID:      cashierID,
+		PointsEarned:   pointsEarned,
+		PointsRedeemed: pointsRedeemed,
+		Change:         change,
+		CreatedAt:      createdAt,
+		Details:        details,
+		Payments:       payments,
+	}, alerts, nil
 }
 
-func (r *TransactionRepository) GetDailyReport(date time.Time) (*models.SalesReport, error) {
-	report := &models.SalesReport{}
+type priceApproval struct {
+	key       string
+	pin       string
+	managerID *int
+}
+
+// approve resolves the manager PIN the first time an approval is needed and
+// reuses that manager for every other line in the same checkout.
+func (a *priceApproval) approve(tx *sql.Tx, productName string) (*int, error) {
+	if a.managerID != nil {
+		return a.managerID, nil
+	}
+	if a.pin == "" {
+		return nil, fmt.Errorf("manager approval required for price change on product %s", productName)
+	}
+
+	id, err := findManagerByPIN(tx, a.key, a.pin)
+	if err != nil {
+		return nil, err
+	}
+	a.managerID = &id
+	return a.managerID, nil
+}
+
+func (r *TransactionRepository) priceLine(tx *sql.Tx, item models.CheckoutItem, productName string, listPrice int, approval *priceApproval) (*models.TransactionDetail, error) {
+	unitPrice := listPrice
+	if item.OverridePrice != nil {
+		if *item.OverridePrice < 0 {
+			return nil, fmt.Errorf("override price for product %s cannot be negative", productName)
+		}
+		unitPrice = *item.OverridePrice
+	}
+	if item.Discount < 0 {
+		return nil, fmt.Errorf("discount for product %s cannot be negative", productName)
+	}
+
+	gross := LineAmount(unitPrice, item.Quantity, r.cfg.RupiahRounding)
+	if item.Discount > gross {
+		return nil, fmt.Errorf("discount for product %s exceeds line amount %d", productName, gross)
+	}
+
+	detail := &models.TransactionDetail{
+		ProductID:     item.ProductID,
+		ProductName:   productName,
+		Quantity:      item.Quantity,
+		OriginalPrice: listPrice,
+		UnitPrice:     unitPrice,
+		Discount:      item.Discount,
+		Subtotal:      gross - item.Discount,
+	}
+	if item.OverridePrice == nil && item.Discount == 0 {
+		return detail, nil
+	}
+
+	if item.Reason == "" {
+		return nil, fmt.Errorf("reason is required to override the price of product %s", productName)
+	}
+	detail.OverrideReason = item.Reason
+
+	listAmount := LineAmount(listPrice, item.Quantity, r.cfg.RupiahRounding)
+	reduction := listAmount - detail.Subtotal
+	if reduction > 0 && reduction*100 > listAmount*r.cfg.OverrideApprovalPercent {
+		managerID, err := approval.approve(tx, productName)
+		if err != nil {
+			return nil, err
+		}
+		detail.ApprovedBy = managerID
+	}
+
+	return detail, nil
+}
+
+func (r *TransactionRepository) GetAll(filter models.TransactionFilter) ([]models.Transaction, error) {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return nil, err
+	}
+	var from, to *time.Time
+	if filter.StartDate != nil {
+		start := day.Start(*filter.StartDate)
+		from = &start
+	}
+	if filter.EndDate != nil {
+		end := day.Start(filter.EndDate.AddDate(0, 0, 1))
+		to = &end
+	}
+
+	rows, err := r.db.Query(
+		`SELECT id, COALESCE(invoice_number, ''), store_id, total_amount, customer_id, cashier_id, points_earned, points_redeemed, change_amount, created_at,
+			voided_at, voided_by, COALESCE(void_reason, '')
+		FROM transactions
+		WHERE ($1 = '' OR invoice_number ILIKE '%' || $1 || '%')
+		AND ($2::TIMESTAMPTZ IS NULL OR created_at >= $2)
+		AND ($3::TIMESTAMPTZ IS NULL OR created_at < $3)
+		AND ($4::INT IS NULL OR store_id = $4)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5`,
+		filter.InvoiceNumber, from, to, filter.StoreID, filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(&t.ID, &t.InvoiceNumber, &t.StoreID, &t.TotalAmount, &t.CustomerID, &t.CashierID, &t.PointsEarned, &t.PointsRedeemed, &t.Change, &t.CreatedAt,
+			&t.VoidedAt, &t.VoidedBy, &t.VoidReason); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
 
+func (r *TransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	var t models.Transaction
 	err := r.db.QueryRow(
-		`SELECT COALESCE(SUM(total_amount), 0), COUNT(*) FROM transactions WHERE DATE(created_at) = DATE($1)`,
-		date,
-	).Scan(&report.TotalRevenue, &report.TotalTransaksi)
+		`SELECT id, COALESCE(invoice_number, ''), store_id, total_amount, customer_id, cashier_id, points_earned, points_redeemed, change_amount, created_at,
+			voided_at, voided_by, COALESCE(void_reason, '')
+		FROM transactions WHERE id = $1`,
+		id,
+	).Scan(&t.ID, &t.InvoiceNumber, &t.StoreID, &t.TotalAmount, &t.CustomerID, &t.CashierID, &t.PointsEarned, &t.PointsRedeemed, &t.Change, &t.CreatedAt,
+		&t.VoidedAt, &t.VoidedBy, &t.VoidReason)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get daily report: %w", err)
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
-	var productName string
-	var qtyTerjual int
-	err = r.db.QueryRow(
-		`SELECT p.name, SUM(td.quantity) as qty_terjual
+	if t.Details, err = getTransactionDetails(r.db, t.ID); err != nil {
+		return nil, err
+	}
+	if t.Payments, err = getTransactionPayments(r.db, t.ID); err != nil {
+		return nil, err
+	}
+	if t.Refunds, err = getTransactionRefunds(r.db, t.ID); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func getTransactionDetails(db *sql.DB, transactionID int) ([]models.TransactionDetail, error) {
+	rows, err := db.Query(
+		`SELECT td.id, td.transaction_id, td.product_id, COALESCE(p.name, ''), td.quantity, COALESCE(td.unit, ''), td.base_quantity,
+			COALESCE(td.original_price, `+priceAtSaleSQL+`, ROUND(td.subtotal / NULLIF(td.quantity, 0))::INT, 0),
+			COALESCE(td.unit_price, ROUND(td.subtotal / NULLIF(td.quantity, 0))::INT, 0),
+			td.discount, td.subtotal, COALESCE(td.override_reason, ''), td.approved_by, td.price_list_id, COALESCE(td.price_list, '')
 		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
 		JOIN transactions t ON td.transaction_id = t.id
-		WHERE DATE(t.created_at) = DATE($1)
-		GROUP BY p.id, p.name
-		ORDER BY qty_terjual DESC
-		LIMIT 1`,
-		date,
-	).Scan(&productName, &qtyTerjual)
+		LEFT JOIN products p ON td.product_id = p.id
+		WHERE td.transaction_id = $1
+		ORDER BY td.id`,
+		transactionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction details: %w", err)
+	}
+	defer rows.Close()
 
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get top product: %w", err)
+	var details []models.TransactionDetail
+	for rows.Next() {
+		var d models.TransactionDetail
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.Unit, &d.BaseQuantity,
+			&d.OriginalPrice, &d.UnitPrice, &d.Discount, &d.Subtotal, &d.OverrideReason, &d.ApprovedBy, &d.PriceListID, &d.PriceList); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction detail: %w", err)
+		}
+		details = append(details, d)
 	}
 
-	if err == nil {
-		report.ProdukTerlaris = &models.ProdukTerlaris{
-			Nama:       productName,
-			QtyTerjual: qtyTerjual,
+	return details, rows.Err()
+}
+
+func getTransactionPayments(db *sql.DB, transactionID int) ([]models.TransactionPayment, error) {
+	rows, err := db.Query(
+		`SELECT id, transaction_id, method, amount, COALESCE(reference, '')
+		FROM transaction_payments WHERE transaction_id = $1 ORDER BY id`,
+		transactionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []models.TransactionPayment
+	for rows.Next() {
+		var p models.TransactionPayment
+		if err := rows.Scan(&p.ID, &p.TransactionID, &p.Method, &p.Amount, &p.Reference); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction payment: %w", err)
 		}
+		payments = append(payments, p)
 	}
 
-	return report, nil
+	return payments, rows.Err()
 }
 
-func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time) (*models.SalesReport, error) {
+// GetDailyReport reports on the business day that is running at instant at.
+func (r *TransactionRepository) GetDailyReport(at time.Time, storeID *int) (*models.SalesReport, error) {
+	day, err := NewBusinessDay(r.cfg)
+	if err != nil {
+		return nil, err
+	}
+	date := day.Date(at)
+	from, to := day.Range(date, date)
+
 	report := &models.SalesReport{}
+	err = r.db.QueryRow(
+		`SELECT COALESCE(SUM(`+netSaleSQL+`), 0), COUNT(*) FROM transactions t
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL AND ($3::INT IS NULL OR t.store_id = $3)`,
+		from, to, storeID,
+	).Scan(&report.TotalRevenue, &report.TotalTransaksi)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily report: %w", err)
+	}
 
-	err := r.db.QueryRow(
-		`SELECT COALESCE(SUM(total_amount), 0), COUNT(*) FROM transactions WHERE DATE(created_at) BETWEEN DATE($1) AND DATE($2)`,
-		startDate, endDate,
+	if report.ProdukTerlaris, err = r.topProduct(date, date, storeID); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetReportByDateRange reads closed days from daily_sales_summary as far as
+// it reaches and the rest from the transactions themselves.
+func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time, storeID *int) (*models.SalesReport, error) {
+	split, err := r.splitSales(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SalesReport{}
+	err = r.db.QueryRow(
+		`SELECT COALESCE(SUM(revenue), 0), COALESCE(SUM(transactions), 0) FROM (
+			SELECT revenue, transaction_count AS transactions FROM daily_sales_summary
+			WHERE product_id IS NULL AND business_date BETWEEN $1::DATE AND $2::DATE AND ($5::INT IS NULL OR store_id = $5)
+			UNION ALL
+			SELECT `+netSaleSQL+`, 1 FROM transactions t
+			WHERE t.created_at >= $3 AND t.created_at < $4 AND t.voided_at IS NULL AND ($5::INT IS NULL OR t.store_id = $5)
+		) sales`,
+		split.SummaryFrom, split.SummaryTo, split.LiveFrom, split.LiveTo, storeID,
 	).Scan(&report.TotalRevenue, &report.TotalTransaksi)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get report: %w", err)
 	}
 
-	var productName string
-	var qtyTerjual int
-	err = r.db.QueryRow(
-		`SELECT p.name, SUM(td.quantity) as qty_terjual
-		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
-		JOIN transactions t ON td.transaction_id = t.id
-		WHERE DATE(t.created_at) BETWEEN DATE($1) AND DATE($2)
-		GROUP BY p.id, p.name
-		ORDER BY qty_terjual DESC
-		LIMIT 1`,
-		startDate, endDate,
-	).Scan(&productName, &qtyTerjual)
+	if report.ProdukTerlaris, err = r.topProduct(startDate, endDate, storeID); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
 
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get top product: %w", err)
+func (r *TransactionRepository) GetStoreBreakdown(startDate, endDate time.Time) ([]models.StoreSalesReport, error) {
+	split, err := r.splitSales(startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
-	if err == nil {
-		report.ProdukTerlaris = &models.ProdukTerlaris{
-			Nama:       productName,
-			QtyTerjual: qtyTerjual,
+	rows, err := r.db.Query(
+		`SELECT sales.store_id, COALESCE(s.name, $5), SUM(sales.revenue), SUM(sales.transactions)
+		FROM (
+			SELECT store_id, revenue, transaction_count AS transactions FROM daily_sales_summary
+			WHERE product_id IS NULL AND business_date BETWEEN $1::DATE AND $2::DATE
+			UNION ALL
+			SELECT t.store_id, `+netSaleSQL+`, 1 FROM transactions t
+			WHERE t.created_at >= $3 AND t.created_at < $4 AND t.voided_at IS NULL
+		) sales
+		LEFT JOIN stores s ON sales.store_id = s.id
+		GROUP BY sales.store_id, s.name
+		ORDER BY SUM(sales.revenue) DESC`,
+		split.SummaryFrom, split.SummaryTo, split.LiveFrom, split.LiveTo, r.cfg.StoreName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	var breakdown []models.StoreSalesReport
+	for rows.Next() {
+		var s models.StoreSalesReport
+		if err := rows.Scan(&s.StoreID, &s.StoreName, &s.TotalRevenue, &s.TotalTransaksi); err != nil {
+			return nil, fmt.Errorf("failed to scan store breakdown: %w", err)
 		}
+		breakdown = append(breakdown, s)
 	}
 
-	return report, nil
+	return breakdown, rows.Err()
 }
@@ -0,0 +1,411 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+)
+
+type TransferRepository struct {
+	db *sql.DB
+}
+
+func NewTransferRepository(db *sql.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+func (r *TransferRepository) GetAll(status string) ([]models.StockTransfer, error) {
+	rows, err := r.db.Query(
+		`SELECT t.id, t.from_store_id, fs.name, t.to_store_id, ts.name, t.status, t.has_discrepancy,
+			COALESCE(t.notes, ''), t.dispatched_at, t.received_at
+		FROM stock_transfers t
+		JOIN stores fs ON t.from_store_id = fs.id
+		JOIN stores ts ON t.to_store_id = ts.id
+		WHERE ($1 = '' OR t.status = $1)
+		ORDER BY t.dispatched_at DESC, t.id DESC`,
+		status,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []models.StockTransfer
+	for rows.Next() {
+		var t models.StockTransfer
+		if err := rows.Scan(&t.ID, &t.FromStoreID, &t.FromStoreName, &t.ToStoreID, &t.ToStoreName, &t.Status,
+			&t.HasDiscrepancy, &t.Notes, &t.DispatchedAt, &t.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (r *TransferRepository) GetByID(id int) (*models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := r.db.QueryRow(
+		`SELECT t.id, t.from_store_id, fs.name, t.to_store_id, ts.name, t.status, t.has_discrepancy,
+			COALESCE(t.notes, ''), t.dispatched_at, t.received_at
+		FROM stock_transfers t
+		JOIN stores fs ON t.from_store_id = fs.id
+		JOIN stores ts ON t.to_store_id = ts.id
+		WHERE t.id = $1`,
+		id,
+	).Scan(&t.ID, &t.FromStoreID, &t.FromStoreName, &t.ToStoreID, &t.ToStoreName, &t.Status,
+		&t.HasDiscrepancy, &t.Notes, &t.DispatchedAt, &t.ReceivedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer: %w", err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT i.id, i.transfer_id, i.product_id, p.name, i.quantity_sent, i.quantity_received, COALESCE(i.discrepancy_note, '')
+		FROM stock_transfer_items i
+		JOIN products p ON i.product_id = p.id
+		WHERE i.transfer_id = $1
+		ORDER BY i.id`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.StockTransferItem
+		if err := rows.Scan(&item.ID, &item.TransferID, &item.ProductID, &item.ProductName, &item.QuantitySent,
+			&item.QuantityReceived, &item.DiscrepancyNote); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer item: %w", err)
+		}
+		if item.QuantityReceived != nil {
+			item.Discrepancy = *item.QuantityReceived - item.QuantitySent
+		}
+		t.Items = append(t.Items, item)
+	}
+
+	return &t, rows.Err()
+}
+
+func (r *TransferRepository) Dispatch(req *models.CreateTransferRequest) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, storeID := range []int{req.FromStoreID, req.ToStoreID} {
+		if _, err := storeCode(tx, storeID); err != nil {
+			return 0, err
+		}
+	}
+
+	var transferID int
+	err = tx.QueryRow(
+		`INSERT INTO stock_transfers (from_store_id, to_store_id, notes) VALUES ($1, $2, NULLIF($3, '')) RETURNING id`,
+		req.FromStoreID, req.ToStoreID, req.Notes,
+	).Scan(&transferID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	for _, item := range req.Items {
+		product, err := lockProductStock(tx, item.ProductID, &req.FromStoreID)
+		if err != nil {
+			return 0, err
+		}
+
+		unit, err := resolveUnit(tx, item.ProductID, item.Unit, product.baseUnit, product.price)
+		if err != nil {
+			return 0, err
+		}
+		if err := CheckPrecision(item.Quantity, product.precision, product.name); err != nil {
+			return 0, err
+		}
+		quantity := RoundQuantity(item.Quantity * float64(unit.factor))
+
+		if product.stock < quantity {
+			return 0, fmt.Errorf("insufficient stock for product %s: available %g %s, requested %g %s", product.name, product.stock, product.baseUnit, quantity, product.baseUnit)
+		}
+
+		if err := deductStock(tx, item.ProductID, &req.FromStoreID, quantity); err != nil {
+			return 0, err
+		}
+
+		var itemID int
+		err = tx.QueryRow(
+			`INSERT INTO stock_transfer_items (transfer_id, product_id, quantity_sent) VALUES ($1, $2, $3) RETURNING id`,
+			transferID, item.ProductID, quantity,
+		).Scan(&itemID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create transfer item: %w", err)
+		}
+
+		if product.trackExpiry {
+			picks, err := pickBatches(tx, item.ProductID, &req.FromStoreID, product.name, quantity)
+			if err != nil {
+				return 0, err
+			}
+			if err := insertBatchPicks(tx, "stock_transfer_item_batches", "transfer_item_id", itemID, picks); err != nil {
+				return 0, err
+			}
+		}
+
+		if err := recordMovement(tx, item.ProductID, &req.FromStoreID, -quantity, "transfer_out", "transfer", &transferID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return transferID, nil
+}
+
+func (r *TransferRepository) Receive(transferID int, req *models.ReceiveTransferRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var toStoreID int
+	var status string
+	err = tx.QueryRow(
+		`SELECT to_store_id, status FROM stock_transfers WHERE id = $1 FOR UPDATE`,
+		transferID,
+	).Scan(&toStoreID, &status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("transfer not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get transfer: %w", err)
+	}
+	if status != models.TransferStatusDispatched {
+		return fmt.Errorf("transfer %d has already been %s", transferID, status)
+	}
+
+	received := make(map[int]models.ReceiveTransferItem, len(req.Items))
+	for _, item := range req.Items {
+		received[item.ProductID] = item
+	}
+
+	rows, err := tx.Query(
+		`SELECT id, product_id, quantity_sent FROM stock_transfer_items WHERE transfer_id = $1 ORDER BY id`,
+		transferID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get transfer items: %w", err)
+	}
+
+	type sentItem struct {
+		id        int
+		productID int
+		quantity  float64
+	}
+	var sent []sentItem
+	for rows.Next() {
+		var s sentItem
+		if err := rows.Scan(&s.id, &s.productID, &s.quantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan transfer item: %w", err)
+		}
+		sent = append(sent, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sentProducts := make(map[int]bool, len(sent))
+	for _, s := range sent {
+		sentProducts[s.productID] = true
+	}
+	for productID := range received {
+		if !sentProducts[productID] {
+			return fmt.Errorf("product %d is not part of transfer %d", productID, transferID)
+		}
+	}
+
+	var hasDiscrepancy bool
+	for _, s := range sent {
+		quantity, note := s.quantity, ""
+		if item, ok := received[s.productID]; ok {
+			quantity, note = RoundQuantity(item.QuantityReceived), item.Note
+		}
+		if quantity < 0 {
+			return fmt.Errorf("quantity_received for product %d cannot be negative", s.productID)
+		}
+		if quantity != s.quantity {
+			hasDiscrepancy = true
+			if note == "" {
+				return fmt.Errorf("note is required when received quantity of product %d differs from sent quantity %g", s.productID, s.quantity)
+			}
+		}
+
+		_, err = tx.Exec(
+			`UPDATE stock_transfer_items SET quantity_received = $1, discrepancy_note = NULLIF($2, '') WHERE id = $3`,
+			quantity, note, s.id,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update transfer item: %w", err)
+		}
+
+		if quantity == 0 {
+			continue
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO store_products (store_id, product_id, stock) VALUES ($1, $2, $3)
+			ON CONFLICT (store_id, product_id) DO UPDATE SET stock = store_products.stock + EXCLUDED.stock`,
+			toStoreID, s.productID, quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update stock for product %d: %w", s.productID, err)
+		}
+
+		if err := recordMovement(tx, s.productID, &toStoreID, quantity, "transfer_in", "transfer", &transferID); err != nil {
+			return err
+		}
+
+		if err := receiveTransferBatches(tx, s.id, s.productID, toStoreID, quantity); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE stock_transfers SET status = $1, has_discrepancy = $2, received_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		models.TransferStatusReceived, hasDiscrepancy, transferID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update transfer: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// receiveTransferBatches recreates the batches picked at dispatch in the
+// destination store, filling the received quantity earliest expiry first. Any
+// surplus over what was sent joins the latest-expiring batch.
+func receiveTransferBatches(tx *sql.Tx, transferItemID, productID, toStoreID int, quantity float64) error {
+	rows, err := tx.Query(
+		`SELECT b.batch_code, to_char(b.expiry_date, 'YYYY-MM-DD'), tb.quantity
+		FROM stock_transfer_item_batches tb
+		JOIN product_batches b ON tb.batch_id = b.id
+		WHERE tb.transfer_item_id = $1
+		ORDER BY b.expiry_date NULLS LAST, b.id`,
+		transferItemID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get transfer batches: %w", err)
+	}
+
+	type sentBatch struct {
+		code     string
+		expiry   *string
+		quantity float64
+	}
+	var sent []sentBatch
+	for rows.Next() {
+		var b sentBatch
+		if err := rows.Scan(&b.code, &b.expiry, &b.quantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan transfer batch: %w", err)
+		}
+		sent = append(sent, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range sent {
+		if quantity == 0 {
+			break
+		}
+		n := b.quantity
+		if n > quantity {
+			n = quantity
+		}
+		if _, err := addBatch(tx, productID, &toStoreID, b.code, b.expiry, n); err != nil {
+			return err
+		}
+		quantity = RoundQuantity(quantity - n)
+	}
+
+	if quantity > 0 && len(sent) > 0 {
+		last := sent[len(sent)-1]
+		if _, err := addBatch(tx, productID, &toStoreID, last.code, last.expiry, quantity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *TransferRepository) GetInTransit() ([]models.InTransitStock, error) {
+	rows, err := r.db.Query(
+		`SELECT i.product_id, p.name, t.to_store_id, s.name, SUM(i.quantity_sent)
+		FROM stock_transfer_items i
+		JOIN stock_transfers t ON i.transfer_id = t.id
+		JOIN products p ON i.product_id = p.id
+		JOIN stores s ON t.to_store_id = s.id
+		WHERE t.status = $1
+		GROUP BY i.product_id, p.name, t.to_store_id, s.name
+		ORDER BY s.name, p.name`,
+		models.TransferStatusDispatched,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get in-transit stock: %w", err)
+	}
+	defer rows.Close()
+
+	var stock []models.InTransitStock
+	for rows.Next() {
+		var s models.InTransitStock
+		if err := rows.Scan(&s.ProductID, &s.ProductName, &s.ToStoreID, &s.ToStoreName, &s.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan in-transit stock: %w", err)
+		}
+		stock = append(stock, s)
+	}
+
+	return stock, rows.Err()
+}
+
+func (r *TransferRepository) GetMovements(productID, storeID *int, limit int) ([]models.StockMovement, error) {
+	rows, err := r.db.Query(
+		`SELECT m.id, m.product_id, p.name, m.store_id, m.quantity, m.reason, COALESCE(m.reference_type, ''), m.reference_id, m.created_at
+		FROM stock_movements m
+		JOIN products p ON m.product_id = p.id
+		WHERE ($1::INT IS NULL OR m.product_id = $1)
+		AND ($2::INT IS NULL OR m.store_id = $2)
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $3`,
+		productID, storeID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []models.StockMovement
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.ProductName, &m.StoreID, &m.Quantity, &m.Reason,
+			&m.ReferenceType, &m.ReferenceID, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, rows.Err()
+}
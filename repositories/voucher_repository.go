@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"andre_kasir_api/models"
+	"database/sql"
+	"fmt"
+)
+
+type VoucherRepository struct {
+	db *sql.DB
+}
+
+func NewVoucherRepository(db *sql.DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+func (r *VoucherRepository) GetAll(voucherType string) ([]models.Voucher, error) {
+	rows, err := r.db.Query(
+		`SELECT id, code, type, initial_value, balance, max_uses, uses, expires_at, active, created_at
+		FROM vouchers
+		WHERE ($1 = '' OR type = $1)
+		ORDER BY id`,
+		voucherType,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vouchers: %w", err)
+	}
+	defer rows.Close()
+
+	var vouchers []models.Voucher
+	for rows.Next() {
+		var v models.Voucher
+		if err := rows.Scan(&v.ID, &v.Code, &v.Type, &v.InitialValue, &v.Balance, &v.MaxUses, &v.Uses, &v.ExpiresAt, &v.Active, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan voucher: %w", err)
+		}
+		vouchers = append(vouchers, v)
+	}
+
+	return vouchers, rows.Err()
+}
+
+func (r *VoucherRepository) GetByCode(code string) (*models.Voucher, error) {
+	var v models.Voucher
+	err := r.db.QueryRow(
+		`SELECT id, code, type, initial_value, balance, max_uses, uses, expires_at, active, created_at
+		FROM vouchers WHERE code = $1`,
+		code,
+	).Scan(&v.ID, &v.Code, &v.Type, &v.InitialValue, &v.Balance, &v.MaxUses, &v.Uses, &v.ExpiresAt, &v.Active, &v.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voucher: %w", err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT id, voucher_id, COALESCE(transaction_id, 0), amount, created_at
+		FROM voucher_redemptions WHERE voucher_id = $1 ORDER BY created_at, id`,
+		v.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voucher redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rd models.VoucherRedemption
+		if err := rows.Scan(&rd.ID, &rd.VoucherID, &rd.TransactionID, &rd.Amount, &rd.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan voucher redemption: %w", err)
+		}
+		v.Redemptions = append(v.Redemptions, rd)
+	}
+
+	return &v, rows.Err()
+}
+
+// Create reports false when the code is already taken so the caller can retry
+// with a freshly generated one.
+func (r *VoucherRepository) Create(voucher *models.Voucher) (bool, error) {
+	err := r.db.QueryRow(
+		`INSERT INTO vouchers (code, type, initial_value, balance, max_uses, expires_at)
+		VALUES ($1, $2, $3, $3, $4, $5)
+		ON CONFLICT (tenant_id, code) DO NOTHING
+		RETURNING id, balance, uses, active, created_at`,
+		voucher.Code, voucher.Type, voucher.InitialValue, voucher.MaxUses, voucher.ExpiresAt,
+	).Scan(&voucher.ID, &voucher.Balance, &voucher.Uses, &voucher.Active, &voucher.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create voucher: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *VoucherRepository) Deactivate(code string) error {
+	result, err := r.db.Exec(`UPDATE vouchers SET active = FALSE WHERE code = $1`, code)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate voucher: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("voucher not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/handlers"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"andre_kasir_api/storage"
+	"database/sql"
+	"net/http"
+)
+
+// newRouter wires the API for a single tenant; db must be connected with that
+// tenant's app.tenant_id so row level security scopes every query.
+func newRouter(db *sql.DB, cfg *config.Config, alerter *services.StockAlerter, images storage.Storage) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	productRepo := repositories.NewProductRepository(db)
+	categoryRepo := repositories.NewCategoryRepository(db)
+	transactionRepo := repositories.NewTransactionRepository(db, cfg)
+	customerRepo := repositories.NewCustomerRepository(db)
+	receivableRepo := repositories.NewReceivableRepository(db)
+	voucherRepo := repositories.NewVoucherRepository(db)
+	employeeRepo := repositories.NewEmployeeRepository(db, cfg)
+	storeRepo := repositories.NewStoreRepository(db)
+	transferRepo := repositories.NewTransferRepository(db)
+	inventoryRepo := repositories.NewInventoryRepository(db)
+	priceListRepo := repositories.NewPriceListRepository(db)
+
+	productService := services.NewProductService(productRepo)
+	productImageService := services.NewProductImageService(productRepo, images, cfg)
+	categoryService := services.NewCategoryService(categoryRepo)
+	transactionService := services.NewTransactionService(transactionRepo, cfg, alerter)
+	customerService := services.NewCustomerService(customerRepo)
+	receivableService := services.NewReceivableService(receivableRepo)
+	voucherService := services.NewVoucherService(voucherRepo)
+	employeeService := services.NewEmployeeService(employeeRepo)
+	storeService := services.NewStoreService(storeRepo)
+	transferService := services.NewTransferService(transferRepo)
+	inventoryService := services.NewInventoryService(inventoryRepo, cfg)
+	priceListService := services.NewPriceListService(priceListRepo)
+
+	productHandler := handlers.NewProductHandler(productService, productImageService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	checkoutHandler := handlers.NewCheckoutHandler(transactionService)
+	reportHandler := handlers.NewReportHandler(transactionService)
+	customerHandler := handlers.NewCustomerHandler(customerService, receivableService)
+	receivableHandler := handlers.NewReceivableHandler(receivableService)
+	voucherHandler := handlers.NewVoucherHandler(voucherService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	employeeHandler := handlers.NewEmployeeHandler(employeeService)
+	storeHandler := handlers.NewStoreHandler(storeService)
+	transferHandler := handlers.NewTransferHandler(transferService)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	priceListHandler := handlers.NewPriceListHandler(priceListService)
+
+	mux.HandleFunc("/api/produk/", productHandler.HandleProduct)
+	mux.HandleFunc("/api/produk", productHandler.HandleProducts)
+	mux.HandleFunc("/api/categories/", categoryHandler.HandleCategory)
+	mux.HandleFunc("/api/categories", categoryHandler.HandleCategories)
+	mux.HandleFunc("/api/customers/", customerHandler.HandleCustomer)
+	mux.HandleFunc("/api/customers", customerHandler.HandleCustomers)
+	mux.HandleFunc("/api/member-tiers", customerHandler.HandleTiers)
+	mux.HandleFunc("/api/receivables/", receivableHandler.HandleReceivable)
+	mux.HandleFunc("/api/receivables", receivableHandler.HandleReceivables)
+	mux.HandleFunc("/api/vouchers/", voucherHandler.HandleVoucher)
+	mux.HandleFunc("/api/vouchers", voucherHandler.HandleVouchers)
+	mux.HandleFunc("/api/transactions/", transactionHandler.HandleTransaction)
+	mux.HandleFunc("/api/transactions", transactionHandler.HandleTransactions)
+	mux.HandleFunc("/api/employees/", employeeHandler.HandleEmployee)
+	mux.HandleFunc("/api/employees", employeeHandler.HandleEmployees)
+	mux.HandleFunc("/api/stores/", storeHandler.HandleStore)
+	mux.HandleFunc("/api/stores", storeHandler.HandleStores)
+	mux.HandleFunc("/api/transfers/", transferHandler.HandleTransfer)
+	mux.HandleFunc("/api/transfers", transferHandler.HandleTransfers)
+	mux.HandleFunc("/api/stock-movements", transferHandler.HandleMovements)
+	mux.HandleFunc("/api/inventory/", inventoryHandler.HandleInventory)
+	mux.HandleFunc("/api/price-lists/", priceListHandler.HandlePriceList)
+	mux.HandleFunc("/api/price-lists", priceListHandler.HandlePriceLists)
+	mux.HandleFunc("/api/checkout", checkoutHandler.HandleCheckout)
+	mux.HandleFunc("/api/report/hari-ini", reportHandler.HandleReport)
+	mux.HandleFunc("/api/report/breakdown", reportHandler.HandleReport)
+	mux.HandleFunc("/api/report/products", reportHandler.HandleReport)
+	mux.HandleFunc("/api/report/basket", reportHandler.HandleReport)
+	mux.HandleFunc("/api/report/dead-stock", reportHandler.HandleReport)
+	mux.HandleFunc("/api/report/inventory", reportHandler.HandleReport)
+	mux.HandleFunc("/api/report/forecast", reportHandler.HandleReport)
+	mux.HandleFunc("/api/report/x", reportHandler.HandleRegister)
+	mux.HandleFunc("/api/report/z", reportHandler.HandleRegister)
+	mux.HandleFunc("/api/report/z/", reportHandler.HandleRegister)
+	mux.HandleFunc("/api/report", reportHandler.HandleReport)
+
+	return mux
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+const defaultBasketMinCount = 2
+
+func (s *TransactionService) GetBasketAnalysis(startDate, endDate time.Time, storeID, productID, minCount, limit *int) (*models.BasketAnalysis, error) {
+	m := defaultBasketMinCount
+	if minCount != nil {
+		m = *minCount
+	}
+	if m < 1 {
+		return nil, fmt.Errorf("min_count must be at least 1")
+	}
+	n := defaultRankLimit
+	if limit != nil {
+		n = *limit
+	}
+	if n < 1 || n > maxRankLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxRankLimit)
+	}
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
+
+	total, err := s.repo.CountTransactions(startDate, endDate, storeID)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := s.repo.GetBasketPairs(startDate, endDate, storeID, productID, m, n)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pairs {
+		ScoreBasketPair(&pairs[i], total)
+	}
+
+	return &models.BasketAnalysis{
+		StartDate:    startDate.Format("2006-01-02"),
+		EndDate:      endDate.Format("2006-01-02"),
+		StoreID:      storeID,
+		ProductID:    productID,
+		MinCount:     m,
+		Transactions: total,
+		Pairs:        pairs,
+	}, nil
+}
+
+// ScoreBasketPair fills in the pair's support, confidence and lift from its
+// sale counts, out of total sales.
+func ScoreBasketPair(p *models.BasketPair, total int) {
+	if total == 0 || p.ProductTransactions == 0 || p.WithProductTransactions == 0 {
+		return
+	}
+	together := float64(p.Transactions)
+	p.Support = roundRatio(together / float64(total))
+	p.Confidence = roundRatio(together / float64(p.ProductTransactions))
+	p.ReverseConfidence = roundRatio(together / float64(p.WithProductTransactions))
+	p.Lift = roundRatio(together * float64(total) / (float64(p.ProductTransactions) * float64(p.WithProductTransactions)))
+}
+
+func roundRatio(r float64) float64 {
+	return math.Round(r*10000) / 10000
+}
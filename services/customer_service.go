@@ -0,0 +1,52 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+)
+
+type CustomerService struct {
+	repo *repositories.CustomerRepository
+}
+
+func NewCustomerService(repo *repositories.CustomerRepository) *CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+func (s *CustomerService) GetAll(search string) ([]models.Customer, error) {
+	return s.repo.GetAll(search)
+}
+
+func (s *CustomerService) GetByID(id int) (*models.Customer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *CustomerService) Create(customer *models.Customer) error {
+	if customer.Tier == "" {
+		customer.Tier = "regular"
+	}
+	return s.repo.Create(customer)
+}
+
+func (s *CustomerService) Update(customer *models.Customer) error {
+	if customer.Tier == "" {
+		customer.Tier = "regular"
+	}
+	return s.repo.Update(customer)
+}
+
+func (s *CustomerService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *CustomerService) GetTiers() ([]models.MemberTier, error) {
+	return s.repo.GetTiers()
+}
+
+func (s *CustomerService) GetTransactions(customerID int) ([]models.Transaction, error) {
+	return s.repo.GetTransactions(customerID)
+}
+
+func (s *CustomerService) GetPointsLedger(customerID int) ([]models.PointsLedgerEntry, error) {
+	return s.repo.GetPointsLedger(customerID)
+}
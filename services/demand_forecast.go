@@ -0,0 +1,166 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	defaultForecastDays  = 14
+	maxForecastDays      = 90
+	defaultForecastWeeks = 8
+	maxForecastWeeks     = 52
+	// forecastAverageDays is how many of the latest days of history the
+	// moving average spans.
+	forecastAverageDays = 28
+)
+
+// GetDemandForecast forecasts each product's demand for the next days
+// business days, starting today, from its sales in the weeks before today.
+// Products are listed by days of cover, those running out first at the top.
+func (s *TransactionService) GetDemandForecast(days, weeks, storeID, productID *int) (*models.DemandForecast, error) {
+	n := defaultForecastDays
+	if days != nil {
+		n = *days
+	}
+	if n < 1 || n > maxForecastDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", maxForecastDays)
+	}
+	w := defaultForecastWeeks
+	if weeks != nil {
+		w = *weeks
+	}
+	if w < 1 || w > maxForecastWeeks {
+		return nil, fmt.Errorf("weeks must be between 1 and %d", maxForecastWeeks)
+	}
+
+	day, err := repositories.NewBusinessDay(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	today := day.Date(time.Now())
+	historyStart := today.AddDate(0, 0, -7*w)
+
+	products, err := s.repo.GetForecastProducts(storeID, productID)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := s.repo.GetDailyProductSales(historyStart, today.AddDate(0, 0, -1), storeID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	dayIndex := map[string]int{}
+	for i := 0; i < 7*w; i++ {
+		dayIndex[historyStart.AddDate(0, 0, i).Format("2006-01-02")] = i
+	}
+	history := map[int][]float64{}
+	for _, sale := range sales {
+		i, ok := dayIndex[sale.Date.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		if history[sale.ProductID] == nil {
+			history[sale.ProductID] = make([]float64, 7*w)
+		}
+		history[sale.ProductID][i] += sale.Quantity
+	}
+
+	forecast := &models.DemandForecast{
+		StartDate:    today.Format("2006-01-02"),
+		EndDate:      today.AddDate(0, 0, n-1).Format("2006-01-02"),
+		Days:         n,
+		HistoryWeeks: w,
+		StoreID:      storeID,
+		Products:     products,
+	}
+	for i := range forecast.Products {
+		p := &forecast.Products[i]
+		sold := history[p.ProductID]
+		if sold == nil {
+			sold = make([]float64, 7*w)
+		}
+
+		p.MovingAverage = roundQuantity(MovingAverage(sold, forecastAverageDays))
+		p.Daily = make([]models.DailyForecast, n)
+		total := 0.0
+		for d, q := range ForecastDemand(sold, today, n) {
+			total += q
+			p.Daily[d] = models.DailyForecast{Date: today.AddDate(0, 0, d).Format("2006-01-02"), Quantity: roundQuantity(q)}
+		}
+
+		avgDaily := total / float64(n)
+		p.ForecastTotal = roundQuantity(total)
+		p.ForecastDaily = roundQuantity(avgDaily)
+		if avgDaily > 0 {
+			cover := math.Round(math.Max(p.Stock, 0)/avgDaily*10) / 10
+			p.DaysOfCover = &cover
+		}
+		p.SuggestedQty = SuggestReorder(p.Stock, p.MinStock, p.ReorderQty, avgDaily, n)
+	}
+
+	slices.SortStableFunc(forecast.Products, func(a, b models.ProductForecast) int {
+		switch {
+		case a.DaysOfCover == nil && b.DaysOfCover == nil:
+			return 0
+		case a.DaysOfCover == nil:
+			return 1
+		case b.DaysOfCover == nil:
+			return -1
+		}
+		return cmp.Compare(*a.DaysOfCover, *b.DaysOfCover)
+	})
+
+	return forecast, nil
+}
+
+// MovingAverage is the average daily quantity over the last days of history.
+func MovingAverage(history []float64, days int) float64 {
+	recent := history[max(0, len(history)-days):]
+	if len(recent) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, q := range recent {
+		total += q
+	}
+	return total / float64(len(recent))
+}
+
+// ForecastDemand projects the quantity sold on each of the days business days
+// from start, given history, the quantities sold on each day just before
+// start, oldest first. The level is the moving average of the last four weeks
+// of history, and each day's level is scaled by how its weekday sold against
+// the whole of history.
+func ForecastDemand(history []float64, start time.Time, days int) []float64 {
+	level := MovingAverage(history, forecastAverageDays)
+	mean := MovingAverage(history, len(history))
+
+	var sold [7]float64
+	var count [7]int
+	for i, q := range history {
+		weekday := start.AddDate(0, 0, i-len(history)).Weekday()
+		sold[weekday] += q
+		count[weekday]++
+	}
+
+	forecast := make([]float64, days)
+	for i := range forecast {
+		weekday := start.AddDate(0, 0, i).Weekday()
+		index := 1.0
+		if mean > 0 && count[weekday] > 0 {
+			index = sold[weekday] / float64(count[weekday]) / mean
+		}
+		forecast[i] = level * index
+	}
+	return forecast
+}
+
+func roundQuantity(q float64) float64 {
+	return math.Round(q*100) / 100
+}
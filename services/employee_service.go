@@ -0,0 +1,93 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+)
+
+type EmployeeService struct {
+	repo *repositories.EmployeeRepository
+}
+
+func NewEmployeeService(repo *repositories.EmployeeRepository) *EmployeeService {
+	return &EmployeeService{repo: repo}
+}
+
+func (s *EmployeeService) GetAll() ([]models.Employee, error) {
+	return s.repo.GetAll()
+}
+
+func (s *EmployeeService) GetByID(id int) (*models.Employee, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *EmployeeService) Create(employee *models.Employee) error {
+	if employee.PIN == "" {
+		return fmt.Errorf("pin is required")
+	}
+	if err := validateEmployee(employee); err != nil {
+		return err
+	}
+	if err := s.approve(employee); err != nil {
+		return err
+	}
+	return s.repo.Create(employee)
+}
+
+func (s *EmployeeService) Update(employee *models.Employee) error {
+	if err := validateEmployee(employee); err != nil {
+		return err
+	}
+	if err := s.approve(employee); err != nil {
+		return err
+	}
+	return s.repo.Update(employee)
+}
+
+func (s *EmployeeService) Delete(id int, managerPIN string) error {
+	if err := s.approve(&models.Employee{ManagerPIN: managerPIN}); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// approve checks the manager PIN that comes with a change to employees, who
+// hold the PINs that approve overrides and voids. Only the first manager can
+// be set up without one.
+func (s *EmployeeService) approve(employee *models.Employee) error {
+	pin := employee.ManagerPIN
+	employee.ManagerPIN = ""
+	if pin != "" {
+		return s.repo.CheckManagerPIN(pin)
+	}
+
+	hasManager, err := s.repo.HasActiveManager()
+	if err != nil {
+		return err
+	}
+	if hasManager {
+		return fmt.Errorf("manager PIN is required to change employees")
+	}
+	return nil
+}
+
+func validateEmployee(employee *models.Employee) error {
+	if employee.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch employee.Role {
+	case "":
+		employee.Role = models.EmployeeRoleCashier
+	case models.EmployeeRoleCashier, models.EmployeeRoleManager:
+	default:
+		return fmt.Errorf("role must be %q or %q", models.EmployeeRoleCashier, models.EmployeeRoleManager)
+	}
+
+	if employee.PIN != "" && (len(employee.PIN) < 4 || len(employee.PIN) > 8) {
+		return fmt.Errorf("pin must be 4 to 8 characters")
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type InventoryService struct {
+	repo *repositories.InventoryRepository
+	cfg  *config.Config
+}
+
+func NewInventoryService(repo *repositories.InventoryRepository, cfg *config.Config) *InventoryService {
+	return &InventoryService{repo: repo, cfg: cfg}
+}
+
+func (s *InventoryService) GetLowStock(storeID *int) ([]models.LowStockItem, error) {
+	return s.stockLevels(storeID, true)
+}
+
+func (s *InventoryService) GetReorderSuggestions(storeID *int) ([]models.LowStockItem, error) {
+	items, err := s.stockLevels(storeID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]models.LowStockItem, 0, len(items))
+	for _, item := range items {
+		if item.SuggestedQty > 0 {
+			suggestions = append(suggestions, item)
+		}
+	}
+	return suggestions, nil
+}
+
+func (s *InventoryService) stockLevels(storeID *int, lowOnly bool) ([]models.LowStockItem, error) {
+	days := s.cfg.ReorderVelocityDays
+	if days <= 0 {
+		days = 30
+	}
+
+	items, err := s.repo.GetStockLevels(storeID, time.Now().AddDate(0, 0, -days), lowOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		avgDaily := item.SoldLastDays / float64(days)
+		item.AvgDailySales = math.Round(avgDaily*100) / 100
+		if item.SoldLastDays > 0 {
+			cover := math.Round(item.Stock/avgDaily*10) / 10
+			item.DaysOfCover = &cover
+		}
+		item.SuggestedQty = SuggestReorder(item.Stock, item.MinStock, item.ReorderQty, avgDaily, s.cfg.ReorderCoverDays)
+	}
+
+	return items, nil
+}
+
+func (s *InventoryService) GetBatches(productID, storeID *int) ([]models.ProductBatch, error) {
+	return s.repo.GetBatches(productID, storeID)
+}
+
+func (s *InventoryService) ReceiveBatch(req *models.ReceiveBatchRequest) (*models.ProductBatch, error) {
+	req.BatchCode = strings.TrimSpace(req.BatchCode)
+	if req.BatchCode == "" {
+		return nil, fmt.Errorf("batch_code is required")
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0")
+	}
+	if req.ExpiryDate != nil {
+		if _, err := time.Parse("2006-01-02", *req.ExpiryDate); err != nil {
+			return nil, fmt.Errorf("invalid expiry_date format, use YYYY-MM-DD")
+		}
+	}
+
+	id, err := s.repo.ReceiveBatch(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetBatch(id)
+}
+
+func (s *InventoryService) ReceiveStock(req *models.StockChangeRequest) (*models.StockChange, error) {
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0")
+	}
+	return s.repo.ReceiveStock(req)
+}
+
+func (s *InventoryService) CountStock(req *models.StockChangeRequest) (*models.StockChange, error) {
+	if req.Quantity < 0 {
+		return nil, fmt.Errorf("quantity cannot be negative")
+	}
+	return s.repo.CountStock(req)
+}
+
+func (s *InventoryService) GetExpiring(storeID *int, days int) ([]models.ExpiringBatch, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("days cannot be negative")
+	}
+	return s.repo.GetExpiring(storeID, days)
+}
+
+// SuggestReorder returns how many units to order so that stock covers
+// coverDays of sales at avgDaily on top of the minimum stock. reorderQty is
+// the smallest order worth placing and is suggested whenever stock is at or
+// below its minimum.
+func SuggestReorder(stock, minStock, reorderQty, avgDaily float64, coverDays int) float64 {
+	target := minStock + math.Ceil(avgDaily*float64(coverDays))
+	need := math.Ceil(target - stock)
+	if need <= 0 {
+		if minStock > 0 && stock <= minStock {
+			return reorderQty
+		}
+		return 0
+	}
+	if need < reorderQty {
+		need = reorderQty
+	}
+	return need
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+	"time"
+)
+
+// NewInventoryValuation validates the parameters and returns an empty
+// valuation, ready for StreamInventoryValuation. With an asOfDate the
+// valuation is taken at the close of that business day, otherwise now.
+func (s *TransactionService) NewInventoryValuation(asOfDate *time.Time, storeID *int, groupBy string) (*models.InventoryValuation, error) {
+	if groupBy == "" {
+		groupBy = models.ValuationByCategory
+	}
+	if groupBy != models.ValuationByCategory && groupBy != models.ValuationByStore {
+		return nil, fmt.Errorf("group_by must be category or store")
+	}
+
+	asOf := time.Now()
+	if asOfDate != nil {
+		day, err := repositories.NewBusinessDay(s.cfg)
+		if err != nil {
+			return nil, err
+		}
+		if closing := day.Start(asOfDate.AddDate(0, 0, 1)); closing.Before(asOf) {
+			asOf = closing
+		} else if day.Start(*asOfDate).After(asOf) {
+			return nil, fmt.Errorf("as_of must not be in the future")
+		}
+	}
+
+	return &models.InventoryValuation{AsOf: asOf, GroupBy: groupBy, StoreID: storeID, Groups: []models.InventoryValuationGroup{}}, nil
+}
+
+// StreamInventoryValuation hands the valuation's products to fn one by one,
+// ordered by group, adding them to its TotalValue but not to its Groups.
+func (s *TransactionService) StreamInventoryValuation(v *models.InventoryValuation, fn func(models.InventoryValuationItem) error) error {
+	return s.repo.EachInventoryValue(v.AsOf, v.StoreID, v.GroupBy, func(item models.InventoryValuationItem) error {
+		v.TotalValue += item.Value
+		return fn(item)
+	})
+}
+
+func (s *TransactionService) GetInventoryValuation(asOfDate *time.Time, storeID *int, groupBy string) (*models.InventoryValuation, error) {
+	v, err := s.NewInventoryValuation(asOfDate, storeID, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.StreamInventoryValuation(v, func(item models.InventoryValuationItem) error {
+		key, label := ValuationGroup(v.GroupBy, item)
+		if n := len(v.Groups); n == 0 || v.Groups[n-1].Key != key {
+			v.Groups = append(v.Groups, models.InventoryValuationGroup{Key: key, Label: label, Products: []models.InventoryValuationItem{}})
+		}
+		group := &v.Groups[len(v.Groups)-1]
+		group.Quantity += item.Quantity
+		group.Value += item.Value
+		group.Products = append(group.Products, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// ValuationGroup is the key and label of the group item falls in: its
+// category or store ID ("" for none) and name.
+func ValuationGroup(groupBy string, item models.InventoryValuationItem) (string, string) {
+	id, label := item.CategoryID, item.Category
+	if groupBy == models.ValuationByStore {
+		id, label = item.StoreID, item.Store
+	}
+	if id == nil {
+		return "", label
+	}
+	return fmt.Sprint(*id), label
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+	"strings"
+)
+
+type PriceListService struct {
+	repo *repositories.PriceListRepository
+}
+
+func NewPriceListService(repo *repositories.PriceListRepository) *PriceListService {
+	return &PriceListService{repo: repo}
+}
+
+func (s *PriceListService) GetAll() ([]models.PriceList, error) {
+	return s.repo.GetAll()
+}
+
+func (s *PriceListService) GetByID(id int) (*models.PriceList, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *PriceListService) Create(list *models.PriceList) error {
+	if err := validatePriceList(list); err != nil {
+		return err
+	}
+	return s.repo.Create(list)
+}
+
+func (s *PriceListService) Update(list *models.PriceList) error {
+	if err := validatePriceList(list); err != nil {
+		return err
+	}
+	return s.repo.Update(list)
+}
+
+func (s *PriceListService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *PriceListService) SetItems(priceListID int, items []models.PriceListItem) error {
+	seen := make(map[models.PriceListItem]bool, len(items))
+	for _, item := range items {
+		if item.ProductID <= 0 {
+			return fmt.Errorf("product_id is required")
+		}
+		if item.Price < 0 {
+			return fmt.Errorf("price for product %d cannot be negative", item.ProductID)
+		}
+		if item.MinQuantity < 0 {
+			return fmt.Errorf("min_quantity for product %d cannot be negative", item.ProductID)
+		}
+		key := models.PriceListItem{ProductID: item.ProductID, MinQuantity: item.MinQuantity}
+		if seen[key] {
+			return fmt.Errorf("product %d has more than one price for min_quantity %g", item.ProductID, item.MinQuantity)
+		}
+		seen[key] = true
+	}
+	return s.repo.SetItems(priceListID, items)
+}
+
+func validatePriceList(list *models.PriceList) error {
+	list.Name = strings.TrimSpace(list.Name)
+	if list.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if list.Audience == "" {
+		list.Audience = models.PriceListAudienceAll
+	}
+	switch list.Audience {
+	case models.PriceListAudienceAll, models.PriceListAudienceStaff:
+		if list.MemberTier != "" {
+			return fmt.Errorf("member_tier only applies to the member audience")
+		}
+	case models.PriceListAudienceMember:
+	default:
+		return fmt.Errorf("audience must be one of all, member, staff")
+	}
+
+	if (list.StartTime == "") != (list.EndTime == "") {
+		return fmt.Errorf("start_time and end_time must be set together")
+	}
+	if list.StartTime != "" {
+		if _, err := repositories.ParseClock(list.StartTime); err != nil {
+			return err
+		}
+		if _, err := repositories.ParseClock(list.EndTime); err != nil {
+			return err
+		}
+		if list.StartTime == list.EndTime {
+			return fmt.Errorf("start_time and end_time cannot be equal")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"andre_kasir_api/repositories"
+	"log"
+	"time"
+)
+
+// PriceScheduler applies scheduled price changes in the background once they
+// take effect. Checkout applies a product's due change itself, so the
+// interval only bounds how stale product listings can be.
+type PriceScheduler struct {
+	interval time.Duration
+	repos    func() ([]*repositories.ProductRepository, error)
+}
+
+// NewPriceScheduler runs every interval over the repositories returned by
+// repos, which is called on each run so newly provisioned tenants are picked up.
+func NewPriceScheduler(interval time.Duration, repos func() ([]*repositories.ProductRepository, error)) *PriceScheduler {
+	return &PriceScheduler{interval: interval, repos: repos}
+}
+
+func (s *PriceScheduler) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *PriceScheduler) RunOnce() {
+	repos, err := s.repos()
+	if err != nil {
+		log.Printf("scheduled prices: %v", err)
+		return
+	}
+	for _, repo := range repos {
+		n, err := repo.ApplyDuePrices()
+		if err != nil {
+			log.Printf("scheduled prices: %v", err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("scheduled prices: updated %d products", n)
+		}
+	}
+}
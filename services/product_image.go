@@ -0,0 +1,216 @@
+package services
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/storage"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"time"
+)
+
+const maxImageDimension = 6000
+
+type ProductImageService struct {
+	repo          *repositories.ProductRepository
+	store         storage.Storage
+	maxBytes      int
+	thumbnailSize int
+}
+
+func NewProductImageService(repo *repositories.ProductRepository, store storage.Storage, cfg *config.Config) *ProductImageService {
+	return &ProductImageService{repo: repo, store: store, maxBytes: cfg.ImageMaxBytes, thumbnailSize: cfg.ThumbnailSize}
+}
+
+func (s *ProductImageService) MaxBytes() int {
+	return s.maxBytes
+}
+
+// ProcessedImage is a validated upload and the JPEG thumbnail made from it.
+type ProcessedImage struct {
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Thumbnail   []byte
+}
+
+// ProcessImage checks that data is a JPEG, PNG or GIF of sensible dimensions
+// and renders a thumbnail that fits in a size by size square. Transparent
+// areas are flattened onto white.
+func ProcessImage(data []byte, size int) (*ProcessedImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("file is not a supported image (jpeg, png or gif)")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, fmt.Errorf("image dimensions %dx%d are outside 1-%d pixels", cfg.Width, cfg.Height, maxImageDimension)
+	}
+
+	p := &ProcessedImage{Width: cfg.Width, Height: cfg.Height}
+	switch format {
+	case "jpeg":
+		p.ContentType, p.Ext = "image/jpeg", "jpg"
+	case "png":
+		p.ContentType, p.Ext = "image/png", "png"
+	case "gif":
+		p.ContentType, p.Ext = "image/gif", "gif"
+	default:
+		return nil, fmt.Errorf("unsupported image format %s", format)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Thumbnail(src, size), &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	p.Thumbnail = buf.Bytes()
+	return p, nil
+}
+
+// Thumbnail scales src down, keeping its aspect ratio, to fit in a size by
+// size square by averaging the source pixels each output pixel covers. Images
+// already small enough are only flattened.
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if size > 0 && (sw > size || sh > size) {
+		if sw >= sh {
+			dw, dh = size, max(1, sh*size/sw)
+		} else {
+			dw, dh = max(1, sw*size/sh), size
+		}
+	}
+
+	flat := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, b.Min, draw.Over)
+	if dw == sw && dh == sh {
+		return flat
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+			var r, g, bl, n int
+			for sy := y0; sy < y1; sy++ {
+				row := flat.Pix[sy*flat.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[sx*4])
+					g += int(row[sx*4+1])
+					bl += int(row[sx*4+2])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+func (s *ProductImageService) Get(productID int) (*models.ProductImage, error) {
+	return s.repo.GetImage(productID)
+}
+
+// Read returns the bytes and content type of img, or of its thumbnail.
+func (s *ProductImageService) Read(img *models.ProductImage, thumbnail bool) ([]byte, string, error) {
+	if thumbnail {
+		data, err := s.store.Get(img.ThumbnailKey)
+		return data, "image/jpeg", err
+	}
+	data, err := s.store.Get(img.Key)
+	return data, img.ContentType, err
+}
+
+// Upload stores a new image for the product and removes the one it replaces.
+func (s *ProductImageService) Upload(productID int, data []byte) (*models.ProductImage, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+	if s.maxBytes > 0 && len(data) > s.maxBytes {
+		return nil, fmt.Errorf("image exceeds %d bytes", s.maxBytes)
+	}
+
+	processed, err := ProcessImage(data, s.thumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := s.repo.ImageKeyPrefix(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	img := &models.ProductImage{
+		ProductID:    productID,
+		Key:          fmt.Sprintf("%s/%d.%s", prefix, now.UnixNano(), processed.Ext),
+		ContentType:  processed.ContentType,
+		ThumbnailKey: fmt.Sprintf("%s/%d_thumb.jpg", prefix, now.UnixNano()),
+		UpdatedAt:    now,
+	}
+
+	if err := s.store.Put(img.Key, img.ContentType, data); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(img.ThumbnailKey, "image/jpeg", processed.Thumbnail); err != nil {
+		s.RemoveStored(&models.ProductImage{Key: img.Key})
+		return nil, err
+	}
+
+	old, err := s.repo.SetImage(img)
+	if err != nil {
+		s.RemoveStored(img)
+		return nil, err
+	}
+	s.RemoveStored(old)
+
+	return img, nil
+}
+
+func (s *ProductImageService) Delete(productID int) error {
+	old, err := s.repo.ClearImage(productID)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return fmt.Errorf("product image not found")
+	}
+	s.RemoveStored(old)
+	return nil
+}
+
+// RemoveStored deletes img's files from storage. Failures only leave orphaned
+// files behind, so they are logged rather than returned.
+func (s *ProductImageService) RemoveStored(img *models.ProductImage) {
+	if img == nil {
+		return
+	}
+	for _, key := range []string{img.Key, img.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.store.Delete(key); err != nil {
+			log.Printf("failed to remove product image %s: %v", key, err)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"andre_kasir_api/models"
 	"andre_kasir_api/repositories"
+	"fmt"
+	"strings"
 )
 
 type ProductService struct {
@@ -22,13 +24,107 @@ func (s *ProductService) GetByID(id int) (*models.Product, error) {
 }
 
 func (s *ProductService) Create(product *models.Product) error {
+	if product.BaseUnit == "" {
+		product.BaseUnit = "pcs"
+	}
+	if err := validateProduct(product); err != nil {
+		return err
+	}
 	return s.repo.Create(product)
 }
 
 func (s *ProductService) Update(product *models.Product) error {
+	if err := validateProduct(product); err != nil {
+		return err
+	}
 	return s.repo.Update(product)
 }
 
 func (s *ProductService) Delete(id int) error {
 	return s.repo.Delete(id)
 }
+
+func (s *ProductService) GetUnits(productID int) ([]models.ProductUnit, error) {
+	return s.repo.GetUnits(productID)
+}
+
+func (s *ProductService) SetUnit(unit *models.ProductUnit) error {
+	unit.Unit = strings.TrimSpace(unit.Unit)
+	unit.Barcode = strings.TrimSpace(unit.Barcode)
+	if unit.Unit == "" {
+		return fmt.Errorf("unit is required")
+	}
+	if unit.Factor <= 0 {
+		return fmt.Errorf("factor must be greater than 0")
+	}
+	if unit.Price != nil && *unit.Price < 0 {
+		return fmt.Errorf("price cannot be negative")
+	}
+
+	product, err := s.repo.GetByID(unit.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return fmt.Errorf("product not found")
+	}
+	if unit.Unit == product.BaseUnit && unit.Factor != 1 {
+		return fmt.Errorf("base unit %s must have factor 1", product.BaseUnit)
+	}
+
+	return s.repo.SetUnit(unit)
+}
+
+func (s *ProductService) RemoveUnit(productID int, unit string) error {
+	return s.repo.RemoveUnit(productID, unit)
+}
+
+func (s *ProductService) GetPrices(productID int) ([]models.ProductPrice, error) {
+	return s.repo.GetPrices(productID)
+}
+
+func (s *ProductService) SchedulePrice(price *models.ProductPrice) error {
+	if price.Price < 0 {
+		return fmt.Errorf("price cannot be negative")
+	}
+	if price.EffectiveFrom.IsZero() {
+		return fmt.Errorf("effective_from is required")
+	}
+
+	product, err := s.repo.GetByID(price.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return fmt.Errorf("product not found")
+	}
+
+	return s.repo.SchedulePrice(price)
+}
+
+func (s *ProductService) CancelPrice(productID, priceID int) error {
+	return s.repo.CancelPrice(productID, priceID)
+}
+
+func validateProduct(product *models.Product) error {
+	if product.MinStock < 0 || product.ReorderQty < 0 {
+		return fmt.Errorf("min_stock and reorder_qty cannot be negative")
+	}
+	if product.CostPrice < 0 {
+		return fmt.Errorf("cost_price cannot be negative")
+	}
+	product.BaseUnit = strings.TrimSpace(product.BaseUnit)
+	if product.BaseUnit == "" {
+		return fmt.Errorf("base_unit cannot be empty")
+	}
+	if product.Precision < 0 || product.Precision > 3 {
+		return fmt.Errorf("quantity_precision must be between 0 and 3")
+	}
+	if product.Stock > 0 {
+		if err := repositories.CheckPrecision(product.Stock, product.Precision, product.Name); err != nil {
+			return err
+		}
+	}
+	product.PLU = strings.TrimSpace(product.PLU)
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const receiptWidth = 32
+
+func RenderReceipt(storeName string, t *models.Transaction) string {
+	var b strings.Builder
+	line := strings.Repeat("-", receiptWidth)
+
+	b.WriteString(centerText(storeName) + "\n")
+	b.WriteString(centerText(t.InvoiceNumber) + "\n")
+	b.WriteString(centerText(t.CreatedAt.Format("02/01/2006 15:04")) + "\n")
+	b.WriteString(line + "\n")
+
+	for _, d := range t.Details {
+		b.WriteString(d.ProductName + "\n")
+		qty := strconv.FormatFloat(d.Quantity, 'f', -1, 64)
+		if d.Unit != "" {
+			qty += " " + d.Unit
+		}
+		b.WriteString(receiptRow(fmt.Sprintf("  %s x %s", qty, FormatRupiah(d.UnitPrice)), FormatRupiah(d.Subtotal+d.Discount)))
+		if d.Discount > 0 {
+			b.WriteString(receiptRow("  Diskon", "-"+FormatRupiah(d.Discount)))
+		}
+	}
+
+	b.WriteString(line + "\n")
+	b.WriteString(receiptRow("TOTAL", FormatRupiah(t.TotalAmount)))
+	for _, p := range t.Payments {
+		amount := p.Amount
+		if p.Method == models.PaymentMethodCash {
+			amount += t.Change
+		}
+		b.WriteString(receiptRow(strings.ToUpper(p.Method), FormatRupiah(amount)))
+	}
+	if t.Change > 0 {
+		b.WriteString(receiptRow("KEMBALI", FormatRupiah(t.Change)))
+	}
+	if t.PointsEarned > 0 || t.PointsRedeemed > 0 {
+		b.WriteString(line + "\n")
+		b.WriteString(receiptRow("Poin didapat", strconv.Itoa(t.PointsEarned)))
+		b.WriteString(receiptRow("Poin dipakai", strconv.Itoa(t.PointsRedeemed)))
+	}
+	b.WriteString(line + "\n")
+	b.WriteString(centerText("Terima kasih") + "\n")
+
+	return b.String()
+}
+
+func FormatRupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String()
+}
+
+func receiptRow(left, right string) string {
+	gap := receiptWidth - len(left) - len(right)
+	if gap < 1 {
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right + "\n"
+}
+
+func centerText(s string) string {
+	if len(s) >= receiptWidth {
+		return s
+	}
+	return strings.Repeat(" ", (receiptWidth-len(s))/2) + s
+}
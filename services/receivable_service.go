@@ -0,0 +1,62 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+	"time"
+)
+
+type ReceivableService struct {
+	repo *repositories.ReceivableRepository
+}
+
+func NewReceivableService(repo *repositories.ReceivableRepository) *ReceivableService {
+	return &ReceivableService{repo: repo}
+}
+
+func (s *ReceivableService) GetAll(customerID *int, openOnly bool) ([]models.Receivable, error) {
+	return s.repo.GetAll(customerID, openOnly)
+}
+
+func (s *ReceivableService) GetByID(id int) (*models.Receivable, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *ReceivableService) GetPayments(receivableID int) ([]models.ReceivablePayment, error) {
+	return s.repo.GetPayments(receivableID)
+}
+
+func (s *ReceivableService) AddPayment(receivableID int, req *models.RepaymentRequest) (*models.ReceivablePayment, error) {
+	if err := validateRepayment(req); err != nil {
+		return nil, err
+	}
+	return s.repo.AddPayment(receivableID, req)
+}
+
+func (s *ReceivableService) RepayCustomer(customerID int, req *models.RepaymentRequest) ([]models.ReceivablePayment, error) {
+	if err := validateRepayment(req); err != nil {
+		return nil, err
+	}
+	return s.repo.RepayCustomer(customerID, req)
+}
+
+func (s *ReceivableService) GetAging() (*models.AgingReport, error) {
+	return s.repo.GetAging(time.Now())
+}
+
+func validateRepayment(req *models.RepaymentRequest) error {
+	if req.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+
+	switch req.Method {
+	case "":
+		req.Method = models.PaymentMethodCash
+	case models.PaymentMethodCash, models.PaymentMethodDebit, models.PaymentMethodQRIS, models.PaymentMethodTransfer:
+	default:
+		return fmt.Errorf("unsupported repayment method %q", req.Method)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+var compareToOptions = []string{models.CompareToPreviousPeriod, models.CompareToSamePeriodLastYear}
+
+// ComparisonPeriod is the period a report from startDate to endDate is set
+// against: the same number of days just before it, or the same dates a year
+// earlier.
+func ComparisonPeriod(startDate, endDate time.Time, compareTo string) (time.Time, time.Time) {
+	if compareTo == models.CompareToSamePeriodLastYear {
+		return lastYear(startDate), lastYear(endDate)
+	}
+	days := int(math.Round(endDate.Sub(startDate).Hours()/24)) + 1
+	return startDate.AddDate(0, 0, -days), startDate.AddDate(0, 0, -1)
+}
+
+// lastYear moves date back a year, turning 29 February into 28 February
+// rather than 1 March.
+func lastYear(date time.Time) time.Time {
+	earlier := date.AddDate(-1, 0, 0)
+	if earlier.Day() != date.Day() {
+		earlier = earlier.AddDate(0, 0, -earlier.Day())
+	}
+	return earlier
+}
+
+func salesDelta(current, previous int) models.SalesDelta {
+	d := models.SalesDelta{Current: current, Previous: previous, Change: current - previous}
+	if previous != 0 {
+		percent := math.Round(float64(d.Change)*1000/math.Abs(float64(previous))) / 10
+		d.ChangePercent = &percent
+	}
+	return d
+}
+
+func periodSales(startDate, endDate time.Time, report *models.SalesReport) models.PeriodSales {
+	p := models.PeriodSales{
+		StartDate:    startDate.Format("2006-01-02"),
+		EndDate:      endDate.Format("2006-01-02"),
+		Revenue:      report.TotalRevenue,
+		Transactions: report.TotalTransaksi,
+	}
+	if p.Transactions > 0 {
+		p.AverageBasket = p.Revenue / p.Transactions
+	}
+	return p
+}
+
+func (s *TransactionService) compareSales(report *models.SalesReport, startDate, endDate time.Time, storeID *int, compareTo string) (*models.SalesComparison, error) {
+	prevStart, prevEnd := ComparisonPeriod(startDate, endDate, compareTo)
+	previous, err := s.repo.GetReportByDateRange(prevStart, prevEnd, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comparison period: %w", err)
+	}
+
+	c := &models.SalesComparison{
+		CompareTo: compareTo,
+		Current:   periodSales(startDate, endDate, report),
+		Previous:  periodSales(prevStart, prevEnd, previous),
+	}
+	c.Revenue = salesDelta(c.Current.Revenue, c.Previous.Revenue)
+	c.Transactions = salesDelta(c.Current.Transactions, c.Previous.Transactions)
+	c.AverageBasket = salesDelta(c.Current.AverageBasket, c.Previous.AverageBasket)
+
+	current, err := s.repo.GetSalesBreakdown(startDate, endDate, storeID, models.BreakdownByCategory)
+	if err != nil {
+		return nil, err
+	}
+	earlier, err := s.repo.GetSalesBreakdown(prevStart, prevEnd, storeID, models.BreakdownByCategory)
+	if err != nil {
+		return nil, err
+	}
+	c.Categories = CompareCategories(current, earlier)
+
+	return c, nil
+}
+
+// CompareCategories pairs up category sales from both periods, best selling
+// first. Categories that only sold in the earlier period come last.
+func CompareCategories(current, previous []models.SalesBreakdownItem) []models.CategoryComparison {
+	earlier := map[string]models.SalesBreakdownItem{}
+	for _, item := range previous {
+		earlier[item.Key] = item
+	}
+
+	categories := []models.CategoryComparison{}
+	add := func(item models.SalesBreakdownItem, currentRevenue, previousRevenue int) {
+		cat := models.CategoryComparison{Name: item.Label, Revenue: salesDelta(currentRevenue, previousRevenue)}
+		if id, err := strconv.Atoi(item.Key); err == nil {
+			cat.CategoryID = &id
+		}
+		categories = append(categories, cat)
+	}
+
+	for _, item := range current {
+		add(item, item.Revenue, earlier[item.Key].Revenue)
+		delete(earlier, item.Key)
+	}
+	for _, item := range previous {
+		if _, ok := earlier[item.Key]; ok {
+			add(item, 0, item.Revenue)
+		}
+	}
+
+	return categories
+}
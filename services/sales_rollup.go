@@ -0,0 +1,62 @@
+package services
+
+import (
+	"andre_kasir_api/repositories"
+	"log"
+	"time"
+)
+
+// SalesRollup keeps daily_sales_summary up to date in the background. Reports
+// read the summary only as far as it has been rolled up, so the interval
+// decides how soon after a business day closes reports stop scanning it.
+type SalesRollup struct {
+	interval time.Duration
+	repos    func() ([]*repositories.TransactionRepository, error)
+}
+
+// NewSalesRollup runs every interval over the repositories returned by repos,
+// which is called on each run so newly provisioned tenants are picked up.
+func NewSalesRollup(interval time.Duration, repos func() ([]*repositories.TransactionRepository, error)) *SalesRollup {
+	return &SalesRollup{interval: interval, repos: repos}
+}
+
+func (s *SalesRollup) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *SalesRollup) RunOnce() {
+	s.run("sales rollup", (*repositories.TransactionRepository).RollUpSales)
+}
+
+// Rebuild discards every summary and rolls all closed days up again.
+func (s *SalesRollup) Rebuild() {
+	s.run("sales summary rebuild", (*repositories.TransactionRepository).RebuildSalesSummary)
+}
+
+func (s *SalesRollup) run(name string, rollUp func(*repositories.TransactionRepository, time.Time) (int, error)) {
+	repos, err := s.repos()
+	if err != nil {
+		log.Printf("%s: %v", name, err)
+		return
+	}
+	for _, repo := range repos {
+		days, err := rollUp(repo, time.Now())
+		if err != nil {
+			log.Printf("%s: %v", name, err)
+			continue
+		}
+		if days > 0 {
+			log.Printf("%s: summarized %d days", name, days)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const stockAlertQueueSize = 256
+
+type AlertNotifier interface {
+	Notify(alert models.StockAlert) error
+}
+
+// StockAlerter delivers low-stock alerts in the background so notifier
+// latency never holds up a checkout.
+type StockAlerter struct {
+	notifiers []AlertNotifier
+	queue     chan models.StockAlert
+}
+
+func NewStockAlerter(notifiers ...AlertNotifier) *StockAlerter {
+	return &StockAlerter{notifiers: notifiers, queue: make(chan models.StockAlert, stockAlertQueueSize)}
+}
+
+// NewStockAlerterFromConfig builds an alerter for the channels listed in
+// LOW_STOCK_ALERTS (comma separated: log, webhook, email).
+func NewStockAlerterFromConfig(cfg *config.Config) (*StockAlerter, error) {
+	var notifiers []AlertNotifier
+	for _, channel := range strings.Split(cfg.LowStockAlerts, ",") {
+		switch strings.TrimSpace(channel) {
+		case "":
+		case "log":
+			notifiers = append(notifiers, LogNotifier{})
+		case "webhook":
+			if cfg.LowStockWebhookURL == "" {
+				return nil, fmt.Errorf("LOW_STOCK_WEBHOOK_URL is required for webhook alerts")
+			}
+			notifiers = append(notifiers, &WebhookNotifier{URL: cfg.LowStockWebhookURL, Client: &http.Client{Timeout: 10 * time.Second}})
+		case "email":
+			if cfg.AlertEmailTo == "" {
+				return nil, fmt.Errorf("ALERT_EMAIL_TO is required for email alerts")
+			}
+			notifiers = append(notifiers, &EmailNotifier{Addr: cfg.SMTPAddr, From: cfg.AlertEmailFrom, To: strings.Split(cfg.AlertEmailTo, ",")})
+		default:
+			return nil, fmt.Errorf("unknown low stock alert channel %q", channel)
+		}
+	}
+	return NewStockAlerter(notifiers...), nil
+}
+
+func (a *StockAlerter) Start() {
+	go func() {
+		for alert := range a.queue {
+			for _, n := range a.notifiers {
+				if err := n.Notify(alert); err != nil {
+					log.Printf("low stock alert for product %d failed: %v", alert.ProductID, err)
+				}
+			}
+		}
+	}()
+}
+
+func (a *StockAlerter) Enqueue(alerts []models.StockAlert) {
+	if len(a.notifiers) == 0 {
+		return
+	}
+	for _, alert := range alerts {
+		select {
+		case a.queue <- alert:
+		default:
+			log.Printf("low stock alert queue full, dropping alert for product %d", alert.ProductID)
+		}
+	}
+}
+
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(alert models.StockAlert) error {
+	log.Print(alertSubject(alert))
+	return nil
+}
+
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n *WebhookNotifier) Notify(alert models.StockAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type EmailNotifier struct {
+	Addr string
+	From string
+	To   []string
+}
+
+func (n *EmailNotifier) Notify(alert models.StockAlert) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", alertSubject(alert))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "Product: %s (ID %d)\r\n", alert.ProductName, alert.ProductID)
+	if alert.StoreID != nil {
+		fmt.Fprintf(&msg, "Store ID: %d\r\n", *alert.StoreID)
+	}
+	fmt.Fprintf(&msg, "Stock: %g\r\nMinimum stock: %g\r\nReorder quantity: %g\r\n", alert.Stock, alert.MinStock, alert.ReorderQty)
+	fmt.Fprintf(&msg, "Triggered by: %s at %s\r\n", alert.InvoiceNumber, alert.CreatedAt.Format(time.RFC3339))
+
+	if err := smtp.SendMail(n.Addr, nil, n.From, n.To, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send alert email: %w", err)
+	}
+	return nil
+}
+
+func alertSubject(alert models.StockAlert) string {
+	return fmt.Sprintf("Low stock: %s has %g left (minimum %g)", alert.ProductName, alert.Stock, alert.MinStock)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+)
+
+type StoreService struct {
+	repo *repositories.StoreRepository
+}
+
+func NewStoreService(repo *repositories.StoreRepository) *StoreService {
+	return &StoreService{repo: repo}
+}
+
+func (s *StoreService) GetAll() ([]models.Store, error) {
+	return s.repo.GetAll()
+}
+
+func (s *StoreService) GetByID(id int) (*models.Store, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *StoreService) Create(store *models.Store) error {
+	if store.Code == "" || store.Name == "" {
+		return fmt.Errorf("code and name are required")
+	}
+	return s.repo.Create(store)
+}
+
+func (s *StoreService) Update(store *models.Store) error {
+	if store.Code == "" || store.Name == "" {
+		return fmt.Errorf("code and name are required")
+	}
+	return s.repo.Update(store)
+}
+
+func (s *StoreService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *StoreService) GetProducts(storeID int) ([]models.StoreProduct, error) {
+	return s.repo.GetProducts(storeID)
+}
+
+func (s *StoreService) SetProduct(product *models.StoreProduct) error {
+	if product.Stock < 0 {
+		return fmt.Errorf("stock cannot be negative")
+	}
+	if product.PriceOverride != nil && *product.PriceOverride < 0 {
+		return fmt.Errorf("price_override cannot be negative")
+	}
+	return s.repo.SetProduct(product)
+}
+
+func (s *StoreService) RemoveProduct(storeID, productID int) error {
+	return s.repo.RemoveProduct(storeID, productID)
+}
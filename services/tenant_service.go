@@ -0,0 +1,159 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{1,61}[a-z0-9])?$`)
+
+type TenantService struct {
+	repo *repositories.TenantRepository
+}
+
+func NewTenantService(repo *repositories.TenantRepository) *TenantService {
+	return &TenantService{repo: repo}
+}
+
+func (s *TenantService) GetAll() ([]models.Tenant, error) {
+	return s.repo.GetAll()
+}
+
+func (s *TenantService) GetByID(id int) (*models.Tenant, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TenantService) Provision(req *models.ProvisionTenantRequest) (*models.TenantCredentials, error) {
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	if !tenantSlugPattern.MatchString(req.Slug) {
+		return nil, fmt.Errorf("slug must be 3-63 lowercase letters, digits or dashes and usable as a subdomain")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	existing, err := s.repo.GetBySlug(req.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("slug %q is already taken", req.Slug)
+	}
+
+	token, err := generateTenantToken()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := models.TenantCredentials{Tenant: models.Tenant{Slug: req.Slug, Name: req.Name}, Token: token}
+	if err := s.repo.Create(&creds.Tenant, token); err != nil {
+		return nil, err
+	}
+
+	return &creds, nil
+}
+
+func (s *TenantService) RotateToken(id int) (*models.TenantCredentials, error) {
+	tenant, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return nil, fmt.Errorf("tenant not found")
+	}
+
+	token, err := generateTenantToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetToken(id, token); err != nil {
+		return nil, err
+	}
+
+	return &models.TenantCredentials{Tenant: *tenant, Token: token}, nil
+}
+
+func (s *TenantService) SetActive(id int, active bool) error {
+	return s.repo.SetActive(id, active)
+}
+
+// Resolve finds the tenant for a request from its API token, falling back to
+// the subdomain of host under baseDomain. A token that belongs to a different
+// tenant than the subdomain is rejected.
+func (s *TenantService) Resolve(token, host, baseDomain string) (*models.Tenant, error) {
+	var byToken, bySlug *models.Tenant
+	var err error
+
+	if token != "" {
+		byToken, err = s.repo.GetByToken(token)
+		if err != nil {
+			return nil, err
+		}
+		if byToken == nil {
+			return nil, fmt.Errorf("invalid tenant token")
+		}
+	}
+
+	if slug := TenantSubdomain(host, baseDomain); slug != "" {
+		bySlug, err = s.repo.GetBySlug(slug)
+		if err != nil {
+			return nil, err
+		}
+		if bySlug == nil && byToken == nil {
+			return nil, fmt.Errorf("tenant %q not found", slug)
+		}
+	}
+
+	tenant := byToken
+	if tenant == nil {
+		tenant = bySlug
+	}
+	if tenant == nil {
+		return nil, fmt.Errorf("tenant token or subdomain is required")
+	}
+	if byToken != nil && bySlug != nil && byToken.ID != bySlug.ID {
+		return nil, fmt.Errorf("tenant token does not match subdomain")
+	}
+	if !tenant.Active {
+		return nil, fmt.Errorf("tenant %q is inactive", tenant.Slug)
+	}
+
+	return tenant, nil
+}
+
+// TenantSubdomain returns the first label of host when host is a direct
+// subdomain of baseDomain, e.g. "toko-a" for "toko-a.kasir.example:5001".
+func TenantSubdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+
+	slug := strings.TrimSuffix(host, suffix)
+	if strings.Contains(slug, ".") {
+		return ""
+	}
+	return slug
+}
+
+func generateTenantToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate tenant token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,27 +1,259 @@
 package services
 
 import (
+	"andre_kasir_api/config"
 	"andre_kasir_api/models"
 	"andre_kasir_api/repositories"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo    *repositories.TransactionRepository
+	cfg     *config.Config
+	alerter *StockAlerter
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo *repositories.TransactionRepository, cfg *config.Config, alerter *StockAlerter) *TransactionService {
+	return &TransactionService{repo: repo, cfg: cfg, alerter: alerter}
 }
 
 func (s *TransactionService) Checkout(req *models.CheckoutRequest) (*models.Transaction, error) {
-	return s.repo.Checkout(req)
+	transaction, alerts, err := s.repo.Checkout(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.alerter != nil {
+		s.alerter.Enqueue(alerts)
+	}
+	return transaction, nil
+}
+
+func (s *TransactionService) GetAll(filter models.TransactionFilter) ([]models.Transaction, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.repo.GetAll(filter)
+}
+
+func (s *TransactionService) GetByID(id int) (*models.Transaction, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TransactionService) Refund(transactionID int, req *models.RefundRequest) (*models.Refund, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items cannot be empty")
+	}
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity for transaction detail %d must be greater than 0", item.TransactionDetailID)
+		}
+	}
+
+	return s.repo.Refund(transactionID, req)
+}
+
+func (s *TransactionService) Void(transactionID int, req *models.VoidRequest) (*models.Refund, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	if req.ManagerPIN == "" {
+		return nil, fmt.Errorf("manager_pin is required to void a transaction")
+	}
+
+	return s.repo.Void(transactionID, req)
+}
+
+func (s *TransactionService) GetReceipt(id int) (string, error) {
+	transaction, err := s.repo.GetByID(id)
+	if err != nil || transaction == nil {
+		return "", err
+	}
+	return RenderReceipt(s.cfg.StoreName, transaction), nil
+}
+
+func (s *TransactionService) GetDailyReport(storeID *int) (*models.SalesReport, error) {
+	return s.repo.GetDailyReport(time.Now(), storeID)
+}
+
+func (s *TransactionService) GetReportByDateRange(startDate, endDate time.Time, storeID *int, groupByStore bool, compareTo string) (*models.SalesReport, error) {
+	if compareTo != "" && !slices.Contains(compareToOptions, compareTo) {
+		return nil, fmt.Errorf("compare_to must be one of %s", strings.Join(compareToOptions, ", "))
+	}
+	if compareTo != "" && endDate.Before(startDate) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
+
+	report, err := s.repo.GetReportByDateRange(startDate, endDate, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if groupByStore {
+		if report.PerStore, err = s.repo.GetStoreBreakdown(startDate, endDate); err != nil {
+			return nil, err
+		}
+	}
+
+	if compareTo != "" {
+		if report.Comparison, err = s.compareSales(report, startDate, endDate, storeID, compareTo); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+func validateBreakdown(startDate, endDate time.Time, groupBy string) error {
+	if !slices.Contains(models.BreakdownGroups, groupBy) {
+		return fmt.Errorf("group_by must be one of %s", strings.Join(models.BreakdownGroups, ", "))
+	}
+	if endDate.Before(startDate) {
+		return fmt.Errorf("end_date must not be before start_date")
+	}
+	return nil
+}
+
+func (s *TransactionService) GetSalesBreakdown(startDate, endDate time.Time, storeID *int, groupBy string) (*models.SalesBreakdown, error) {
+	if err := validateBreakdown(startDate, endDate, groupBy); err != nil {
+		return nil, err
+	}
+
+	series, err := s.repo.GetSalesBreakdown(startDate, endDate, storeID, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SalesBreakdown{
+		GroupBy:   groupBy,
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Series:    series,
+	}, nil
+}
+
+// StreamSalesBreakdown hands the breakdown to fn row by row, for exports.
+func (s *TransactionService) StreamSalesBreakdown(startDate, endDate time.Time, storeID *int, groupBy string, fn func(models.SalesBreakdownItem) error) error {
+	if err := validateBreakdown(startDate, endDate, groupBy); err != nil {
+		return err
+	}
+	return s.repo.EachSalesBreakdownItem(startDate, endDate, storeID, groupBy, fn)
+}
+
+const (
+	defaultRankLimit     = 10
+	maxRankLimit         = 100
+	defaultDeadStockDays = 30
+)
+
+func (s *TransactionService) RankProducts(startDate, endDate time.Time, storeID *int, by, order string, limit *int) (*models.ProductRanking, error) {
+	if by == "" {
+		by = models.RankByQuantity
+	}
+	if by != models.RankByQuantity && by != models.RankByRevenue {
+		return nil, fmt.Errorf("by must be quantity or revenue")
+	}
+	if order == "" {
+		order = models.RankTop
+	}
+	if order != models.RankTop && order != models.RankBottom {
+		return nil, fmt.Errorf("order must be top or bottom")
+	}
+	n := defaultRankLimit
+	if limit != nil {
+		n = *limit
+	}
+	if n < 1 || n > maxRankLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxRankLimit)
+	}
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
+
+	products, err := s.repo.RankProducts(startDate, endDate, storeID, by, order, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProductRanking{
+		By:        by,
+		Order:     order,
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Products:  products,
+	}, nil
+}
+
+// NewDeadStockReport validates days and returns an empty report covering
+// them, ready for StreamDeadStock.
+func (s *TransactionService) NewDeadStockReport(days *int) (*models.DeadStockReport, error) {
+	n := defaultDeadStockDays
+	if days != nil {
+		n = *days
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("days must be at least 1")
+	}
+
+	return &models.DeadStockReport{Days: n, Since: time.Now().AddDate(0, 0, -n)}, nil
+}
+
+// StreamDeadStock hands the report's products to fn one by one, adding them
+// to its TotalValue but not to its Products.
+func (s *TransactionService) StreamDeadStock(report *models.DeadStockReport, storeID *int, fn func(models.DeadStockItem) error) error {
+	return s.repo.EachDeadStockItem(report.Since, storeID, func(item models.DeadStockItem) error {
+		report.TotalValue += item.StockValue
+		return fn(item)
+	})
+}
+
+func (s *TransactionService) GetDeadStock(days *int, storeID *int) (*models.DeadStockReport, error) {
+	report, err := s.NewDeadStockReport(days)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Products = []models.DeadStockItem{}
+	err = s.StreamDeadStock(report, storeID, func(item models.DeadStockItem) error {
+		report.Products = append(report.Products, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+const defaultZReportLimit = 30
+
+func (s *TransactionService) GetXReport(storeID *int) (*models.RegisterReport, error) {
+	return s.repo.GetXReport(storeID)
+}
+
+func (s *TransactionService) CloseZReport(storeID *int) (*models.RegisterReport, error) {
+	return s.repo.CloseZReport(storeID)
 }
 
-func (s *TransactionService) GetDailyReport() (*models.SalesReport, error) {
-	return s.repo.GetDailyReport(time.Now())
+func (s *TransactionService) GetZReports(storeID *int, limit *int) ([]models.RegisterReport, error) {
+	n := defaultZReportLimit
+	if limit != nil {
+		n = *limit
+	}
+	if n < 1 || n > maxRankLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxRankLimit)
+	}
+	return s.repo.GetZReports(storeID, n)
 }
 
-func (s *TransactionService) GetReportByDateRange(startDate, endDate time.Time) (*models.SalesReport, error) {
-	return s.repo.GetReportByDateRange(startDate, endDate)
+func (s *TransactionService) GetZReport(id int) (*models.RegisterReport, error) {
+	return s.repo.GetZReport(id)
 }
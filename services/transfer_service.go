@@ -0,0 +1,67 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+)
+
+type TransferService struct {
+	repo *repositories.TransferRepository
+}
+
+func NewTransferService(repo *repositories.TransferRepository) *TransferService {
+	return &TransferService{repo: repo}
+}
+
+func (s *TransferService) GetAll(status string) ([]models.StockTransfer, error) {
+	return s.repo.GetAll(status)
+}
+
+func (s *TransferService) GetByID(id int) (*models.StockTransfer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TransferService) Dispatch(req *models.CreateTransferRequest) (*models.StockTransfer, error) {
+	if req.FromStoreID == req.ToStoreID {
+		return nil, fmt.Errorf("from_store_id and to_store_id must be different")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items cannot be empty")
+	}
+
+	seen := make(map[int]bool, len(req.Items))
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity for product %d must be greater than 0", item.ProductID)
+		}
+		if seen[item.ProductID] {
+			return nil, fmt.Errorf("product %d is listed more than once", item.ProductID)
+		}
+		seen[item.ProductID] = true
+	}
+
+	id, err := s.repo.Dispatch(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *TransferService) Receive(id int, req *models.ReceiveTransferRequest) (*models.StockTransfer, error) {
+	if err := s.repo.Receive(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *TransferService) GetInTransit() ([]models.InTransitStock, error) {
+	return s.repo.GetInTransit()
+}
+
+func (s *TransferService) GetMovements(productID, storeID *int, limit int) ([]models.StockMovement, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.GetMovements(productID, storeID, limit)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	voucherCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	voucherCodeLength   = 10
+	maxVoucherBatch     = 500
+)
+
+type VoucherService struct {
+	repo *repositories.VoucherRepository
+}
+
+func NewVoucherService(repo *repositories.VoucherRepository) *VoucherService {
+	return &VoucherService{repo: repo}
+}
+
+func (s *VoucherService) GetAll(voucherType string) ([]models.Voucher, error) {
+	return s.repo.GetAll(voucherType)
+}
+
+func (s *VoucherService) GetByCode(code string) (*models.Voucher, error) {
+	return s.repo.GetByCode(strings.ToUpper(code))
+}
+
+func (s *VoucherService) Deactivate(code string) error {
+	return s.repo.Deactivate(strings.ToUpper(code))
+}
+
+func (s *VoucherService) Generate(req *models.GenerateVouchersRequest) ([]models.Voucher, error) {
+	var prefix string
+	switch req.Type {
+	case models.VoucherTypeVoucher:
+		prefix = "VC"
+		if req.MaxUses == nil {
+			single := 1
+			req.MaxUses = &single
+		}
+	case models.VoucherTypeGiftCard:
+		prefix = "GC"
+	default:
+		return nil, fmt.Errorf("type must be %q or %q", models.VoucherTypeVoucher, models.VoucherTypeGiftCard)
+	}
+
+	if req.Value <= 0 {
+		return nil, fmt.Errorf("value must be greater than 0")
+	}
+	if req.Count <= 0 {
+		req.Count = 1
+	}
+	if req.Count > maxVoucherBatch {
+		return nil, fmt.Errorf("count must not exceed %d", maxVoucherBatch)
+	}
+	if req.MaxUses != nil && *req.MaxUses <= 0 {
+		return nil, fmt.Errorf("max_uses must be greater than 0")
+	}
+
+	vouchers := make([]models.Voucher, 0, req.Count)
+	for len(vouchers) < req.Count {
+		code, err := generateVoucherCode(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		v := models.Voucher{
+			Code:         code,
+			Type:         req.Type,
+			InitialValue: req.Value,
+			MaxUses:      req.MaxUses,
+			ExpiresAt:    req.ExpiresAt,
+		}
+		created, err := s.repo.Create(&v)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			vouchers = append(vouchers, v)
+		}
+	}
+
+	return vouchers, nil
+}
+
+func generateVoucherCode(prefix string) (string, error) {
+	max := big.NewInt(int64(len(voucherCodeAlphabet)))
+	code := make([]byte, voucherCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate voucher code: %w", err)
+		}
+		code[i] = voucherCodeAlphabet[n.Int64()]
+	}
+	return prefix + "-" + string(code), nil
+}
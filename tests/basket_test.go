@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"testing"
+	"time"
+)
+
+func TestScoreBasketPair(t *testing.T) {
+	pair := models.BasketPair{Transactions: 5, ProductTransactions: 20, WithProductTransactions: 10}
+	services.ScoreBasketPair(&pair, 100)
+	if pair.Support != 0.05 || pair.Confidence != 0.25 || pair.ReverseConfidence != 0.5 || pair.Lift != 2.5 {
+		t.Errorf("expected support 0.05, confidence 0.25 and 0.5, lift 2.5, got %+v", pair)
+	}
+
+	empty := models.BasketPair{Transactions: 1}
+	services.ScoreBasketPair(&empty, 0)
+	if empty.Support != 0 || empty.Lift != 0 {
+		t.Errorf("expected no scores without sales, got %+v", empty)
+	}
+}
+
+// TestBasketPairs needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestBasketPairs(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.StoreTimezone = "Asia/Jakarta"
+	cfg.BusinessDayCutoff = "04:00"
+	products := repositories.NewProductRepository(db)
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	day, err := repositories.NewBusinessDay(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	for _, name := range []string{"Kopi Bubuk", "Gula Aren", "Krimer"} {
+		product := models.Product{Name: name, Price: 10000, Stock: 50}
+		if err := products.Create(&product); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, product.ID)
+	}
+	coffee, sugar, creamer := ids[0], ids[1], ids[2]
+
+	for _, basket := range [][]int{{coffee, sugar}, {coffee, sugar}, {coffee, creamer}, {sugar}} {
+		var items []models.CheckoutItem
+		for _, id := range basket {
+			items = append(items, models.CheckoutItem{ProductID: id, Quantity: 1})
+		}
+		if _, _, err := transactions.Checkout(&models.CheckoutRequest{Items: items}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	today := day.Date(time.Now())
+	pairs, err := transactions.GetBasketPairs(today, today, nil, &coffee, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("expected coffee to pair with sugar and creamer, got %+v", pairs)
+	}
+	if p := pairs[0]; p.WithProductID != sugar || p.Transactions != 2 || p.ProductTransactions != 3 || p.WithProductTransactions != 3 {
+		t.Errorf("expected sugar bought with coffee twice, got %+v", p)
+	}
+	if p := pairs[1]; p.WithProductID != creamer || p.Transactions != 1 || p.WithProductTransactions != 1 {
+		t.Errorf("expected creamer bought with coffee once, got %+v", p)
+	}
+
+	pairs, err = transactions.GetBasketPairs(today, today, nil, &coffee, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 || pairs[0].WithProductID != sugar {
+		t.Errorf("expected only sugar at a minimum of two sales together, got %+v", pairs)
+	}
+}
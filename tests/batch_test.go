@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBatches needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestBatches(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	products := repositories.NewProductRepository(db)
+	inventory := repositories.NewInventoryRepository(db)
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	product := models.Product{Name: "Susu UHT", Price: 7000, Stock: 5, TrackExpiry: true}
+	if err := products.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(step string, want map[string]float64) {
+		t.Helper()
+		batches, err := inventory.GetBatches(&product.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]float64{}
+		for _, b := range batches {
+			got[b.BatchCode] += b.Quantity
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("%s: expected batches %v, got %v", step, want, got)
+		}
+	}
+	expect("initial stock", map[string]float64{"UNDATED": 5})
+
+	date := func(days int) *string {
+		d := time.Now().AddDate(0, 0, days).Format("2006-01-02")
+		return &d
+	}
+	for _, b := range []models.ReceiveBatchRequest{
+		{ProductID: product.ID, BatchCode: "EXPIRED", ExpiryDate: date(-2), Quantity: 3},
+		{ProductID: product.ID, BatchCode: "SOON", ExpiryDate: date(5), Quantity: 2},
+		{ProductID: product.ID, BatchCode: "LATER", ExpiryDate: date(30), Quantity: 4},
+	} {
+		if _, err := inventory.ReceiveBatch(&b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Sales take the earliest unexpired expiry first and undated stock last.
+	sale, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect("sale", map[string]float64{"UNDATED": 5, "EXPIRED": 3, "LATER": 3})
+
+	// 11 in stock, but only 8 of it unexpired.
+	_, _, err = transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 9}}})
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected a sale needing expired stock to be refused, got %v", err)
+	}
+
+	// Counting the shelf down writes off the expired batch first.
+	product.Stock = 8
+	if err := products.Update(&product); err != nil {
+		t.Fatal(err)
+	}
+	expect("stock adjustment", map[string]float64{"UNDATED": 5, "LATER": 3})
+
+	if _, err := transactions.Refund(sale.ID, &models.RefundRequest{
+		Reason: "Kemasan rusak",
+		Items:  []models.RefundItemRequest{{TransactionDetailID: sale.Details[0].ID, Quantity: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expect("refund", map[string]float64{"UNDATED": 5, "LATER": 4})
+
+	// Turning tracking on puts the untracked stock in an undated batch.
+	untracked := models.Product{Name: "Keju Slice", Price: 15000, Stock: 6}
+	if err := products.Create(&untracked); err != nil {
+		t.Fatal(err)
+	}
+	untracked.TrackExpiry = true
+	if err := products.Update(&untracked); err != nil {
+		t.Fatal(err)
+	}
+	batches, err := inventory.GetBatches(&untracked.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || batches[0].ExpiryDate != nil || batches[0].Quantity != 6 {
+		t.Errorf("expected one undated batch of 6, got %+v", batches)
+	}
+}
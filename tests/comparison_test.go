@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/services"
+	"testing"
+	"time"
+)
+
+func TestComparisonPeriod(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	cases := []struct {
+		start, end, compareTo string
+		wantStart, wantEnd    string
+	}{
+		{"2026-03-01", "2026-03-31", models.CompareToPreviousPeriod, "2026-01-29", "2026-02-28"},
+		{"2026-03-10", "2026-03-10", models.CompareToPreviousPeriod, "2026-03-09", "2026-03-09"},
+		{"2026-03-01", "2026-03-31", models.CompareToSamePeriodLastYear, "2025-03-01", "2025-03-31"},
+		{"2028-02-01", "2028-02-29", models.CompareToSamePeriodLastYear, "2027-02-01", "2027-02-28"},
+	}
+	for _, c := range cases {
+		start, end := services.ComparisonPeriod(date(c.start), date(c.end), c.compareTo)
+		if got := start.Format("2006-01-02") + " " + end.Format("2006-01-02"); got != c.wantStart+" "+c.wantEnd {
+			t.Errorf("%s %s to %s: expected %s to %s, got %s", c.compareTo, c.start, c.end, c.wantStart, c.wantEnd, got)
+		}
+	}
+}
+
+func TestCompareCategories(t *testing.T) {
+	current := []models.SalesBreakdownItem{
+		{Key: "2", Label: "Minuman", Revenue: 150000},
+		{Key: "", Label: "Uncategorized", Revenue: 5000},
+	}
+	previous := []models.SalesBreakdownItem{
+		{Key: "3", Label: "Roti", Revenue: 40000},
+		{Key: "2", Label: "Minuman", Revenue: 120000},
+	}
+
+	got := services.CompareCategories(current, previous)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 categories, got %+v", got)
+	}
+
+	drinks := got[0]
+	if drinks.CategoryID == nil || *drinks.CategoryID != 2 || drinks.Revenue.Change != 30000 {
+		t.Errorf("unexpected drinks comparison: %+v", drinks)
+	}
+	if drinks.Revenue.ChangePercent == nil || *drinks.Revenue.ChangePercent != 25 {
+		t.Errorf("expected drinks to be up 25%%, got %v", drinks.Revenue.ChangePercent)
+	}
+
+	if got[1].CategoryID != nil || got[1].Revenue.ChangePercent != nil {
+		t.Errorf("expected a new uncategorized row without a percentage, got %+v", got[1])
+	}
+
+	bread := got[2]
+	if bread.Name != "Roti" || bread.Revenue.Current != 0 || bread.Revenue.Change != -40000 || *bread.Revenue.ChangePercent != -100 {
+		t.Errorf("expected a category that stopped selling to come last at -100%%, got %+v", bread)
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"andre_kasir_api/config"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := config.Config{InvoiceFormat: "INV/{STORE}/{YYYY}/{MM}/{DD}/{SEQ}", RupiahRounding: "half_even", PINLookupKey: "0123456789abcdef"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected a valid config, got %v", err)
+	}
+
+	noSeq := valid
+	noSeq.InvoiceFormat = "INV/{STORE}/{YYYY}"
+	if err := noSeq.Validate(); err == nil {
+		t.Error("expected an invoice format without {SEQ} to be rejected")
+	}
+
+	for _, format := range []string{"INV/{DD}/{SEQ}", "INV/{MM}/{DD}/{SEQ}", "INV/{YYYY}/{DD}/{SEQ}", "INV/{MM}/{SEQ}"} {
+		c := valid
+		c.InvoiceFormat = format
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected %s, which repeats numbers, to be rejected", format)
+		}
+	}
+	for _, format := range []string{"INV/{YY}{MM}{DD}/{SEQ}", "INV/{YYYY}/{MM}/{SEQ}", "INV/{YYYY}/{SEQ}", "INV/{SEQ}"} {
+		c := valid
+		c.InvoiceFormat = format
+		if err := c.Validate(); err != nil {
+			t.Errorf("expected %s to be accepted, got %v", format, err)
+		}
+	}
+
+	for _, key := range []string{"", "short"} {
+		c := valid
+		c.PINLookupKey = key
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected PIN_LOOKUP_KEY %q to be rejected", key)
+		}
+	}
+
+	for _, mode := range []string{"", "half-up", "nearest"} {
+		c := valid
+		c.RupiahRounding = mode
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected RUPIAH_ROUNDING %q to be rejected", mode)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCreditSales needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestCreditSales(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.PointsEarnUnit = 1000
+	cfg.PointValue = 10
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	receivables := repositories.NewReceivableRepository(db)
+
+	product := models.Product{Name: "Semen 50kg", Price: 30000, Stock: 100}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	customer := models.Customer{Name: "Toko Bangunan Jaya", Tier: "regular", CreditLimit: 100000}
+	if err := repositories.NewCustomerRepository(db).Create(&customer); err != nil {
+		t.Fatal(err)
+	}
+
+	checkout := func(payments ...models.CheckoutPayment) (*models.Transaction, error) {
+		trx, _, err := transactions.Checkout(&models.CheckoutRequest{
+			Items:      []models.CheckoutItem{{ProductID: product.ID, Quantity: 2}},
+			CustomerID: &customer.ID,
+			Payments:   payments,
+		})
+		return trx, err
+	}
+
+	first, err := checkout(models.CheckoutPayment{Method: models.PaymentMethodCredit, Amount: 60000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.PointsEarned != 0 {
+		t.Errorf("expected a sale on credit to earn no points, got %d", first.PointsEarned)
+	}
+
+	_, err = checkout(models.CheckoutPayment{Method: models.PaymentMethodCredit, Amount: 60000})
+	if err == nil || !strings.Contains(err.Error(), "credit limit exceeded") {
+		t.Fatalf("expected credit beyond the limit to be refused, got %v", err)
+	}
+
+	// Credit right up to the limit is allowed.
+	if _, err := checkout(
+		models.CheckoutPayment{Method: models.PaymentMethodCash, Amount: 20000},
+		models.CheckoutPayment{Method: models.PaymentMethodCredit, Amount: 40000},
+	); err != nil {
+		t.Fatal(err)
+	}
+	_, err = checkout(
+		models.CheckoutPayment{Method: models.PaymentMethodCash, Amount: 59999},
+		models.CheckoutPayment{Method: models.PaymentMethodCredit, Amount: 1},
+	)
+	if err == nil || !strings.Contains(err.Error(), "credit limit exceeded") {
+		t.Fatalf("expected credit past a fully used limit to be refused, got %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE receivables SET created_at = created_at - INTERVAL '45 days' WHERE transaction_id = $1`, first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	aging := func(want models.AgingRow) {
+		t.Helper()
+		report, err := receivables.GetAging(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got models.AgingRow
+		for _, row := range report.Customers {
+			if row.CustomerID == customer.ID {
+				got = row
+			}
+		}
+		if got.Days0To30 != want.Days0To30 || got.Days31To60 != want.Days31To60 || got.Over60 != want.Over60 {
+			t.Errorf("expected aging %d/%d/%d, got %d/%d/%d", want.Days0To30, want.Days31To60, want.Over60, got.Days0To30, got.Days31To60, got.Over60)
+		}
+	}
+	aging(models.AgingRow{Days0To30: 40000, Days31To60: 60000})
+
+	// Repayments settle the oldest credit first.
+	payments, err := receivables.RepayCustomer(customer.ID, &models.RepaymentRequest{Amount: 70000, Method: models.PaymentMethodCash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 2 || payments[0].Amount != 60000 || payments[1].Amount != 10000 {
+		t.Errorf("expected 60000 then 10000 repaid, got %+v", payments)
+	}
+	aging(models.AgingRow{Days0To30: 30000})
+
+	if _, err := receivables.RepayCustomer(customer.ID, &models.RepaymentRequest{Amount: 30001, Method: models.PaymentMethodCash}); err == nil {
+		t.Error("expected repaying more than is outstanding to be refused")
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestEmployeePINs needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestEmployeePINs(t *testing.T) {
+	tenant := newTestTenant(t)
+	db := tenant.db
+	employees := repositories.NewEmployeeRepository(db, tenant.cfg)
+	service := services.NewEmployeeService(employees)
+
+	managerPIN, cashierPIN, legacyPIN := tenant.pin(), tenant.pin(), tenant.pin()
+
+	manager := models.Employee{Name: "Rina", Role: models.EmployeeRoleManager, PIN: managerPIN}
+	if err := employees.Create(&manager); err != nil {
+		t.Fatal(err)
+	}
+	var hash string
+	var keyed bool
+	if err := db.QueryRow(`SELECT pin_hash, pin_lookup IS NOT NULL FROM employees WHERE id = $1`, manager.ID).Scan(&hash, &keyed); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$2") || !keyed {
+		t.Errorf("expected a bcrypt PIN hash with a lookup key, got %q (keyed %v)", hash, keyed)
+	}
+
+	cashier := models.Employee{Name: "Joko", PIN: cashierPIN}
+	if err := service.Create(&cashier); err == nil || !strings.Contains(err.Error(), "manager PIN") {
+		t.Fatalf("expected adding an employee without a manager PIN to be refused, got %v", err)
+	}
+	cashier.ManagerPIN = cashierPIN
+	if err := service.Create(&cashier); err == nil || !strings.Contains(err.Error(), "manager PIN") {
+		t.Fatalf("expected adding an employee with a wrong manager PIN to be refused, got %v", err)
+	}
+
+	twin := models.Employee{Name: "Joni", PIN: managerPIN, ManagerPIN: managerPIN}
+	if err := service.Create(&twin); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Fatalf("expected a PIN another employee uses to be refused, got %v", err)
+	}
+
+	cashier = models.Employee{Name: "Joko", PIN: cashierPIN, ManagerPIN: managerPIN}
+	if err := service.Create(&cashier); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cashier cannot make themselves a manager.
+	promoted := models.Employee{ID: cashier.ID, Name: cashier.Name, Role: models.EmployeeRoleManager, Active: true, ManagerPIN: cashierPIN}
+	if err := service.Update(&promoted); err == nil || !strings.Contains(err.Error(), "manager PIN") {
+		t.Fatalf("expected a cashier's PIN not to approve a role change, got %v", err)
+	}
+	if err := service.Delete(manager.ID, cashierPIN); err == nil {
+		t.Fatal("expected a cashier's PIN not to approve deactivating a manager")
+	}
+
+	// PINs hashed before bcrypt still work and are moved to bcrypt on use.
+	salt := "0123456789abcdef"
+	sum := sha256.Sum256([]byte(salt + legacyPIN))
+	var legacyID int
+	err := db.QueryRow(
+		`INSERT INTO employees (name, role, pin_salt, pin_hash) VALUES ('Lama', $1, $2, $3) RETURNING id`,
+		models.EmployeeRoleManager, salt, hex.EncodeToString(sum[:]),
+	).Scan(&legacyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := employees.CheckManagerPIN(legacyPIN); err != nil {
+		t.Fatalf("expected a SHA-256 PIN to still be accepted, got %v", err)
+	}
+	if err := db.QueryRow(`SELECT pin_salt || pin_hash, pin_lookup IS NOT NULL FROM employees WHERE id = $1`, legacyID).Scan(&hash, &keyed); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$2") || !keyed {
+		t.Errorf("expected the PIN to be rehashed with bcrypt and keyed, got %q (keyed %v)", hash, keyed)
+	}
+
+	// The lookup key alone finds the employee, so a wrong key finds no one.
+	rekeyed := repositories.NewEmployeeRepository(db, &config.Config{PINLookupKey: "another-pin-lookup-key"})
+	if err := rekeyed.CheckManagerPIN(managerPIN); err == nil {
+		t.Error("expected a PIN keyed with another PIN_LOOKUP_KEY not to be found")
+	}
+}
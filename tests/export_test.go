@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"andre_kasir_api/export"
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportTable = export.Table{
+	Title:   "Sales by product",
+	Notes:   []string{"Period: 2026-03-01 to 2026-03-31"},
+	Columns: []export.Column{{Name: "Product", Width: 20}, {Name: "Quantity"}, {Name: "Revenue"}, {Name: "Last sold"}},
+}
+
+func writeExportRows(t *testing.T, format string, n int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := export.New(format, &buf, exportTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("%s: expected nothing written before the first row", format)
+	}
+
+	soldAt := time.Date(2026, 3, 2, 14, 5, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		if err := w.Row(fmt.Sprintf("Kopi \"Gayo\" & Co #%d", i), 1.5, 27000, &soldAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Row("Teh (tawar)", 2, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExportCSV(t *testing.T) {
+	got := string(writeExportRows(t, export.FormatCSV, 1))
+	want := "Product,Quantity,Revenue,Last sold\n" +
+		"\"Kopi \"\"Gayo\"\" & Co #0\",1.5,27000,2026-03-02 14:05:00\n" +
+		"Teh (tawar),2,,\n"
+	if got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	data := writeExportRows(t, export.FormatXLSX, 2)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(body)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("expected part %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Sales by product"`) {
+		t.Errorf("expected the sheet to be named after the title: %s", parts["xl/workbook.xml"])
+	}
+
+	var sheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Rows) != 4 {
+		t.Fatalf("expected a header and 3 rows, got %d", len(sheet.Rows))
+	}
+	first := sheet.Rows[1].Cells
+	if first[0].Type != "inlineStr" || first[0].Inline != `Kopi "Gayo" & Co #0` {
+		t.Errorf("expected an inline string in A2, got %+v", first[0])
+	}
+	if first[2].Ref != "C2" || first[2].Type != "" || first[2].Value != "27000" {
+		t.Errorf("expected a number in C2, got %+v", first[2])
+	}
+	if last := sheet.Rows[3].Cells; len(last) != 2 {
+		t.Errorf("expected empty cells to be left out, got %+v", last)
+	}
+}
+
+func TestExportPDF(t *testing.T) {
+	data := writeExportRows(t, export.FormatPDF, 150)
+	pdf := string(data)
+
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatal("expected a PDF header and trailer")
+	}
+	if !strings.Contains(pdf, "(Teh \\(tawar\\)) Tj") {
+		t.Error("expected parentheses in text to be escaped")
+	}
+
+	count := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindStringSubmatch(pdf)
+	if count == nil || count[1] == "1" {
+		t.Errorf("expected 150 rows to take several pages, got %v", count)
+	}
+
+	// Every cross-reference entry has to point at its object.
+	start, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(pdf)[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(pdf[start:], "\n")
+	size, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for id := 1; id < size; id++ {
+		offset, _ := strconv.Atoi(lines[2+id][:10])
+		if want := fmt.Sprintf("%d 0 obj", id); !strings.HasPrefix(pdf[offset:], want) {
+			t.Errorf("xref entry %d points at %q", id, pdf[offset:offset+12])
+		}
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	if _, err := export.New("docx", io.Discard, exportTable); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMovingAverage(t *testing.T) {
+	if got := services.MovingAverage([]float64{1, 2, 3, 4}, 2); got != 3.5 {
+		t.Errorf("expected 3.5, got %v", got)
+	}
+	if got := services.MovingAverage([]float64{1, 2}, 28); got != 1.5 {
+		t.Errorf("expected the whole history when it is shorter, got %v", got)
+	}
+	if got := services.MovingAverage(nil, 28); got != 0 {
+		t.Errorf("expected 0 without history, got %v", got)
+	}
+}
+
+func TestForecastDemand(t *testing.T) {
+	start := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	history := make([]float64, 28)
+	for i := range history {
+		history[i] = 2
+		if start.AddDate(0, 0, i-len(history)).Weekday() == time.Saturday {
+			history[i] = 10
+		}
+	}
+
+	forecast := services.ForecastDemand(history, start, 14)
+	if len(forecast) != 14 {
+		t.Fatalf("expected 14 days, got %d", len(forecast))
+	}
+	for i, q := range forecast {
+		want := 2.0
+		if start.AddDate(0, 0, i).Weekday() == time.Saturday {
+			want = 10
+		}
+		if math.Abs(q-want) > 1e-9 {
+			t.Errorf("day %d (%s): expected %v, got %v", i, start.AddDate(0, 0, i).Weekday(), want, q)
+		}
+	}
+
+	for i, q := range services.ForecastDemand(make([]float64, 28), start, 7) {
+		if q != 0 {
+			t.Errorf("day %d: expected no demand without sales, got %v", i, q)
+		}
+	}
+}
+
+// TestDailyProductSales needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestDailyProductSales(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.StoreTimezone = "Asia/Jakarta"
+	cfg.BusinessDayCutoff = "04:00"
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	day, err := repositories.NewBusinessDay(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := models.Product{Name: "Gula Pasir", Price: 17000, Stock: 40}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	for _, quantity := range []float64{2, 3} {
+		sale, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: quantity}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`UPDATE transactions SET created_at = created_at - INTERVAL '2 days' WHERE id = $1`, sale.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	sold := day.Date(now.AddDate(0, 0, -2))
+	sales, err := transactions.GetDailyProductSales(day.Date(now).AddDate(0, 0, -7), day.Date(now).AddDate(0, 0, -1), nil, &product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sales) != 1 || sales[0].Date.Format("2006-01-02") != sold.Format("2006-01-02") || sales[0].Quantity != 5 {
+		t.Fatalf("expected 5 sold on %s, got %+v", sold.Format("2006-01-02"), sales)
+	}
+}
@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"andre_kasir_api/services"
+	"testing"
+)
+
+func TestSuggestReorder(t *testing.T) {
+	cases := []struct {
+		name                        string
+		stock, minStock, reorderQty float64
+		avgDaily                    float64
+		coverDays                   int
+		want                        float64
+	}{
+		{"WellStocked", 100, 10, 24, 2, 14, 0},
+		{"CoverShortfall", 30, 10, 0, 2, 14, 8},
+		{"ShortfallBelowReorderQty", 30, 10, 24, 2, 14, 24},
+		{"AtMinimumWithoutSales", 10, 10, 24, 0, 14, 24},
+		{"NoMinimumNoSales", 0, 0, 24, 0, 14, 0},
+		{"FractionalVelocityRoundsUp", 0, 0, 0, 0.1, 14, 2},
+		{"FractionalStock", 2.25, 1, 0, 0.5, 14, 6},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := services.SuggestReorder(c.stock, c.minStock, c.reorderQty, c.avgDaily, c.coverDays)
+			if got != c.want {
+				t.Errorf("expected %g, got %g", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"testing"
+	"time"
+)
+
+func TestValuationGroup(t *testing.T) {
+	category, store := 3, 7
+	item := models.InventoryValuationItem{CategoryID: &category, Category: "Bakery", StoreID: &store, Store: "Cabang Barat"}
+
+	if key, label := services.ValuationGroup(models.ValuationByCategory, item); key != "3" || label != "Bakery" {
+		t.Errorf("expected category 3 Bakery, got %q %q", key, label)
+	}
+	if key, label := services.ValuationGroup(models.ValuationByStore, item); key != "7" || label != "Cabang Barat" {
+		t.Errorf("expected store 7 Cabang Barat, got %q %q", key, label)
+	}
+
+	item.StoreID, item.Store = nil, "Toko Utama"
+	if key, label := services.ValuationGroup(models.ValuationByStore, item); key != "" || label != "Toko Utama" {
+		t.Errorf("expected the main store without a key, got %q %q", key, label)
+	}
+}
+
+// TestInventoryValuation needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestInventoryValuation(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.StoreName = "Toko Utama"
+	cfg.StoreTimezone = "Asia/Jakarta"
+	cfg.BusinessDayCutoff = "04:00"
+	products := repositories.NewProductRepository(db)
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	product := models.Product{Name: "Tepung Terigu", Price: 15000, CostPrice: 11000, Stock: 10}
+	if err := products.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var then time.Time
+	if err := db.QueryRow(`SELECT clock_timestamp()`).Scan(&then); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := products.GetByID(product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.CostPrice = 12000
+	if err := products.Update(current); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 3}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	valued := func(asOf time.Time) models.InventoryValuationItem {
+		t.Helper()
+		var found models.InventoryValuationItem
+		err := transactions.EachInventoryValue(asOf, nil, models.ValuationByCategory, func(item models.InventoryValuationItem) error {
+			if item.ProductID == product.ID && item.StoreID == nil {
+				found = item
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	if item := valued(time.Now().Add(time.Minute)); item.Quantity != 5 || item.UnitCost != 12000 || item.Value != 60000 {
+		t.Errorf("expected 5 at 12000 now, got %+v", item)
+	}
+	if item := valued(then); item.Quantity != 8 || item.UnitCost != 11000 || item.Value != 88000 {
+		t.Errorf("expected 8 at 11000 before the cost changed, got %+v", item)
+	}
+}
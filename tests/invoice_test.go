@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInvoiceNumber(t *testing.T) {
+	date := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	t.Run("DefaultFormat", func(t *testing.T) {
+		got := repositories.FormatInvoiceNumber("INV/{STORE}/{YYYY}/{MM}/{DD}/{SEQ}", "MAIN", date, 1, 4)
+		if got != "INV/MAIN/2026/10/18/0001" {
+			t.Errorf("expected INV/MAIN/2026/10/18/0001, got %s", got)
+		}
+	})
+
+	t.Run("StoreCodeAndShortYear", func(t *testing.T) {
+		got := repositories.FormatInvoiceNumber("{STORE}-{YY}{MM}{DD}-{SEQ}", "JKT01", date, 42, 5)
+		if got != "JKT01-261018-00042" {
+			t.Errorf("expected JKT01-261018-00042, got %s", got)
+		}
+	})
+
+	t.Run("SequenceWiderThanPadding", func(t *testing.T) {
+		got := repositories.FormatInvoiceNumber("INV/{SEQ}", "MAIN", date, 12345, 4)
+		if got != "INV/12345" {
+			t.Errorf("expected INV/12345, got %s", got)
+		}
+	})
+}
+
+func TestInvoiceSequence(t *testing.T) {
+	date := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		format, store string
+		date          time.Time
+	}{
+		{"INV/{STORE}/{YYYY}/{MM}/{DD}/{SEQ}", "JKT01", date},
+		{"INV/{YYYY}/{MM}/{DD}/{SEQ}", "", date},
+		{"{STORE}-{YY}{MM}-{SEQ}", "JKT01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{"INV/{YYYY}/{SEQ}", "", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"INV/{SEQ}", "", time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		store, day := repositories.InvoiceSequence(c.format, "JKT01", date)
+		if store != c.store || !day.Equal(c.date) {
+			t.Errorf("%s: expected the %q sequence of %s, got %q of %s", c.format, c.store, c.date.Format("2006-01-02"), store, day.Format("2006-01-02"))
+		}
+	}
+}
+
+// TestInvoiceNumbersGapFree needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestInvoiceNumbersGapFree(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.InvoiceFormat = "{STORE}-{SEQ}"
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	product := models.Product{Name: "Pulpen", Price: 3000, Stock: 100}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	checkout := func(quantity float64) (*models.Transaction, error) {
+		trx, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: quantity}}})
+		return trx, err
+	}
+
+	if _, err := checkout(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkout(1000); err == nil {
+		t.Fatal("expected a checkout beyond stock to fail")
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var numbers []string
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			trx, err := checkout(1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			numbers = append(numbers, trx.InvoiceNumber)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// The failed checkout gives its number back, and concurrent ones never share one.
+	slices.Sort(numbers)
+	want := []string{"MAIN-0002", "MAIN-0003", "MAIN-0004", "MAIN-0005", "MAIN-0006"}
+	if !slices.Equal(numbers, want) {
+		t.Errorf("expected invoices %v, got %v", want, numbers)
+	}
+
+	found, err := transactions.GetAll(models.TransactionFilter{InvoiceNumber: want[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].InvoiceNumber != want[2] {
+		t.Errorf("expected to find invoice %s, got %+v", want[2], found)
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	cases := map[int]string{
+		0:       "0",
+		500:     "500",
+		3500:    "3.500",
+		1250000: "1.250.000",
+		-7000:   "-7.000",
+	}
+
+	for amount, want := range cases {
+		if got := services.FormatRupiah(amount); got != want {
+			t.Errorf("FormatRupiah(%d): expected %s, got %s", amount, want, got)
+		}
+	}
+}
+
+func TestRenderReceiptUnits(t *testing.T) {
+	trx := &models.Transaction{
+		InvoiceNumber: "INV/2026/10/18/0001",
+		TotalAmount:   26000,
+		CreatedAt:     time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC),
+		Details: []models.TransactionDetail{
+			{ProductName: "Indomie Goreng", Quantity: 2, Unit: "pack", UnitPrice: 12000, Subtotal: 24000},
+			{ProductName: "Permen", Quantity: 4, UnitPrice: 500, Subtotal: 2000},
+		},
+	}
+
+	receipt := services.RenderReceipt("Kasir API", trx)
+	for _, want := range []string{"  2 pack x 12.000", "  4 x 500"} {
+		if !strings.Contains(receipt, want) {
+			t.Errorf("expected receipt to contain %q, got:\n%s", want, receipt)
+		}
+	}
+}
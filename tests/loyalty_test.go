@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"strings"
+	"testing"
+)
+
+// TestLoyaltyPoints needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestLoyaltyPoints(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.PointsEarnUnit = 1000
+	cfg.PointValue = 10
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	customers := repositories.NewCustomerRepository(db)
+
+	product := models.Product{Name: "Beras 5kg", Price: 25000, Stock: 100}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	customer := models.Customer{Name: "Siti", Tier: "regular"}
+	if err := customers.Create(&customer); err != nil {
+		t.Fatal(err)
+	}
+
+	checkout := func(quantity float64, payments ...models.CheckoutPayment) (*models.Transaction, error) {
+		trx, _, err := transactions.Checkout(&models.CheckoutRequest{
+			Items:      []models.CheckoutItem{{ProductID: product.ID, Quantity: quantity}},
+			CustomerID: &customer.ID,
+			Payments:   payments,
+		})
+		return trx, err
+	}
+	balance := func() int {
+		t.Helper()
+		c, err := customers.GetByID(customer.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Points
+	}
+
+	t.Run("Earn", func(t *testing.T) {
+		trx, err := checkout(2, models.CheckoutPayment{Method: models.PaymentMethodCash, Amount: 50000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trx.PointsEarned != 50 || balance() != 50 {
+			t.Errorf("expected 50 points for 50000 at the regular tier, got %d with a balance of %d", trx.PointsEarned, balance())
+		}
+	})
+
+	t.Run("Redeem", func(t *testing.T) {
+		// 30 points pay 300; only the 24700 paid in cash earns.
+		trx, err := checkout(1,
+			models.CheckoutPayment{Method: models.PaymentMethodPoints, Amount: 300},
+			models.CheckoutPayment{Method: models.PaymentMethodCash, Amount: 24700},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trx.PointsRedeemed != 30 || trx.PointsEarned != 24 || balance() != 44 {
+			t.Errorf("expected 30 points redeemed and 24 earned for a balance of 44, got %d, %d and %d", trx.PointsRedeemed, trx.PointsEarned, balance())
+		}
+
+		_, err = checkout(1,
+			models.CheckoutPayment{Method: models.PaymentMethodPoints, Amount: 1000},
+			models.CheckoutPayment{Method: models.PaymentMethodCash, Amount: 24000},
+		)
+		if err == nil || !strings.Contains(err.Error(), "insufficient points") {
+			t.Fatalf("expected redeeming more points than the balance to be refused, got %v", err)
+		}
+		if balance() != 44 {
+			t.Errorf("expected a refused sale to leave the balance at 44, got %d", balance())
+		}
+	})
+
+	t.Run("TierChange", func(t *testing.T) {
+		customer.Tier = "gold"
+		if err := customers.Update(&customer); err != nil {
+			t.Fatal(err)
+		}
+		trx, err := checkout(1, models.CheckoutPayment{Method: models.PaymentMethodCash, Amount: 25000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trx.PointsEarned != 50 || balance() != 94 {
+			t.Errorf("expected gold to earn double, 50 points for 25000, got %d with a balance of %d", trx.PointsEarned, balance())
+		}
+	})
+
+	ledger, err := customers.GetPointsLedger(customer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := 0
+	for _, e := range ledger {
+		sum += e.Points
+	}
+	if sum != balance() {
+		t.Errorf("expected the points ledger to add up to the balance %d, got %d", balance(), sum)
+	}
+}
@@ -0,0 +1,22 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"testing"
+)
+
+func TestPointsEarningAmount(t *testing.T) {
+	payments := []models.TransactionPayment{
+		{Method: models.PaymentMethodCash, Amount: 20000},
+		{Method: models.PaymentMethodQRIS, Amount: 15000},
+		{Method: models.PaymentMethodGiftCard, Amount: 10000},
+		{Method: models.PaymentMethodPoints, Amount: 5000},
+		{Method: models.PaymentMethodVoucher, Amount: 25000},
+		{Method: models.PaymentMethodCredit, Amount: 50000},
+	}
+
+	if got := repositories.PointsEarningAmount(payments); got != 45000 {
+		t.Errorf("expected 45000 of the sale to earn points, got %d", got)
+	}
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"testing"
+	"time"
+)
+
+func TestChoosePriceList(t *testing.T) {
+	entries := []repositories.PriceListEntry{
+		{PriceListID: 1, Name: "Grosir", Audience: models.PriceListAudienceAll, Priority: 10, MinQuantity: 12, Price: 9000},
+		{PriceListID: 1, Name: "Grosir", Audience: models.PriceListAudienceAll, Priority: 10, MinQuantity: 48, Price: 8500},
+		{PriceListID: 2, Name: "Member Gold", Audience: models.PriceListAudienceMember, MemberTier: "gold", Priority: 20, Price: 9200},
+		{PriceListID: 3, Name: "Staff", Audience: models.PriceListAudienceStaff, Priority: 30, Price: 8000},
+		{PriceListID: 4, Name: "Happy Hour", Audience: models.PriceListAudienceAll, Priority: 5, StartTime: "15:00", EndTime: "17:00", Price: 9500},
+	}
+	noon := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	afternoon := time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		buyer    repositories.PriceBuyer
+		quantity float64
+		at       time.Time
+		want     string
+		price    int
+	}{
+		{"NoListApplies", repositories.PriceBuyer{}, 1, noon, "", 0},
+		{"TimeWindow", repositories.PriceBuyer{}, 1, afternoon, "Happy Hour", 9500},
+		{"QuantityBreak", repositories.PriceBuyer{}, 12, noon, "Grosir", 9000},
+		{"LargestBreak", repositories.PriceBuyer{}, 60, noon, "Grosir", 8500},
+		{"WholesaleOutranksHappyHour", repositories.PriceBuyer{}, 12, afternoon, "Grosir", 9000},
+		{"MemberTier", repositories.PriceBuyer{Member: true, MemberTier: "gold"}, 12, noon, "Member Gold", 9200},
+		{"OtherTier", repositories.PriceBuyer{Member: true, MemberTier: "silver"}, 1, noon, "", 0},
+		{"Staff", repositories.PriceBuyer{Staff: true, Member: true, MemberTier: "gold"}, 1, noon, "Staff", 8000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := repositories.ChoosePriceList(entries, c.buyer, c.quantity, c.at)
+			var name string
+			var price int
+			if got != nil {
+				name, price = got.Name, got.Price
+			}
+			if name != c.want || price != c.price {
+				t.Errorf("expected %q at %d, got %q at %d", c.want, c.price, name, price)
+			}
+		})
+	}
+}
+
+func TestInTimeWindow(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 2, h, m, 0, 0, time.UTC) }
+	cases := []struct {
+		start, end string
+		at         time.Time
+		want       bool
+	}{
+		{"", "", at(3, 0), true},
+		{"08:00", "10:00", at(8, 0), true},
+		{"08:00", "10:00", at(10, 0), false},
+		{"22:00", "02:00", at(23, 30), true},
+		{"22:00", "02:00", at(1, 59), true},
+		{"22:00", "02:00", at(12, 0), false},
+	}
+
+	for _, c := range cases {
+		if got := repositories.InTimeWindow(c.start, c.end, c.at); got != c.want {
+			t.Errorf("InTimeWindow(%s, %s, %s): expected %v, got %v", c.start, c.end, c.at.Format("15:04"), c.want, got)
+		}
+	}
+}
+
+func TestBelowUnitPrice(t *testing.T) {
+	entries := []repositories.PriceListEntry{
+		{PriceListID: 1, Name: "Member", Price: 4500},
+		{PriceListID: 2, Name: "Grosir", MinQuantity: 48, Price: 4000},
+	}
+
+	// 24 pcs at 4500 is 108000, dearer than the 100000 a carton sells for.
+	if got := repositories.BelowUnitPrice(entries, 100000, 24); len(got) != 1 || got[0].Name != "Grosir" {
+		t.Errorf("expected only Grosir to beat the carton price, got %+v", got)
+	}
+	if got := repositories.BelowUnitPrice(entries, 5000, 1); len(got) != 2 {
+		t.Errorf("expected both lists to beat the pcs price, got %+v", got)
+	}
+	if got := repositories.BelowUnitPrice(entries, 4000, 1); len(got) != 0 {
+		t.Errorf("expected no list to beat a store price of 4000, got %+v", got)
+	}
+}
+
+// TestPriceListPrecedence needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestPriceListPrecedence(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	products := repositories.NewProductRepository(db)
+
+	product := models.Product{Name: "Mi Instan", Price: 5000, Stock: 200, BaseUnit: "pcs"}
+	if err := products.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	cartonPrice := 100000
+	if err := products.SetUnit(&models.ProductUnit{ProductID: product.ID, Unit: "dus", Factor: 24, Price: &cartonPrice}); err != nil {
+		t.Fatal(err)
+	}
+	customer := models.Customer{Name: "Wati", Tier: "regular"}
+	if err := repositories.NewCustomerRepository(db).Create(&customer); err != nil {
+		t.Fatal(err)
+	}
+	lists := repositories.NewPriceListRepository(db)
+	member := models.PriceList{Name: "Member", Audience: models.PriceListAudienceMember, Priority: 10}
+	if err := lists.Create(&member); err != nil {
+		t.Fatal(err)
+	}
+	if err := lists.SetItems(member.ID, []models.PriceListItem{{ProductID: product.ID, Price: 4500}}); err != nil {
+		t.Fatal(err)
+	}
+
+	line := func(unit string) models.TransactionDetail {
+		t.Helper()
+		trx, _, err := transactions.Checkout(&models.CheckoutRequest{
+			Items:      []models.CheckoutItem{{ProductID: product.ID, Quantity: 1, Unit: unit}},
+			CustomerID: &customer.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return trx.Details[0]
+	}
+
+	if pcs := line(""); pcs.Subtotal != 4500 || pcs.PriceListID == nil || *pcs.PriceListID != member.ID {
+		t.Errorf("expected a member to pay the list's 4500 a pcs, got %+v", pcs)
+	}
+	// The list would make the carton 108000; its own price stays.
+	if carton := line("dus"); carton.Subtotal != 100000 || carton.PriceListID != nil {
+		t.Errorf("expected the carton to keep its 100000 without the list, got %+v", carton)
+	}
+}
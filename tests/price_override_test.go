@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"strings"
+	"testing"
+)
+
+// TestPriceOverrideApproval needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestPriceOverrideApproval(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.OverrideApprovalPercent = 10
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	employees := repositories.NewEmployeeRepository(db, cfg)
+
+	product := models.Product{Name: "Kemeja Batik", Price: 10000, Stock: 100}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	managerPIN, cashierPIN := tenant.pin(), tenant.pin()
+	manager := models.Employee{Name: "Agus", Role: models.EmployeeRoleManager, PIN: managerPIN}
+	if err := employees.Create(&manager); err != nil {
+		t.Fatal(err)
+	}
+	cashier := models.Employee{Name: "Putri", Role: models.EmployeeRoleCashier, PIN: cashierPIN}
+	if err := employees.Create(&cashier); err != nil {
+		t.Fatal(err)
+	}
+
+	checkout := func(item models.CheckoutItem, pin string) (*models.TransactionDetail, error) {
+		item.ProductID, item.Quantity = product.ID, 1
+		trx, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{item}, ManagerPIN: pin})
+		if err != nil {
+			return nil, err
+		}
+		return &trx.Details[0], nil
+	}
+	price := func(p int) *int { return &p }
+
+	t.Run("AtThreshold", func(t *testing.T) {
+		detail, err := checkout(models.CheckoutItem{Discount: 1000, Reason: "Cacat jahitan"}, "")
+		if err != nil {
+			t.Fatalf("expected a 10%% discount not to need approval, got %v", err)
+		}
+		if detail.Subtotal != 9000 || detail.ApprovedBy != nil {
+			t.Errorf("expected 9000 without approval, got %d approved by %v", detail.Subtotal, detail.ApprovedBy)
+		}
+	})
+
+	t.Run("JustAboveThreshold", func(t *testing.T) {
+		for _, item := range []models.CheckoutItem{
+			{Discount: 1001, Reason: "Cacat jahitan"},
+			{OverridePrice: price(8999), Reason: "Harga grosir"},
+		} {
+			if _, err := checkout(item, ""); err == nil || !strings.Contains(err.Error(), "manager approval required") {
+				t.Errorf("expected %+v to need approval, got %v", item, err)
+			}
+		}
+	})
+
+	t.Run("CashierCannotApprove", func(t *testing.T) {
+		if _, err := checkout(models.CheckoutItem{Discount: 1001, Reason: "Cacat jahitan"}, cashierPIN); err == nil || !strings.Contains(err.Error(), "invalid manager PIN") {
+			t.Errorf("expected a cashier's PIN to be refused, got %v", err)
+		}
+	})
+
+	t.Run("ManagerApproves", func(t *testing.T) {
+		detail, err := checkout(models.CheckoutItem{OverridePrice: price(8999), Reason: "Harga grosir"}, managerPIN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detail.Subtotal != 8999 || detail.ApprovedBy == nil || *detail.ApprovedBy != manager.ID {
+			t.Errorf("expected 8999 approved by manager %d, got %d approved by %v", manager.ID, detail.Subtotal, detail.ApprovedBy)
+		}
+	})
+
+	t.Run("ReasonRequired", func(t *testing.T) {
+		if _, err := checkout(models.CheckoutItem{Discount: 500}, ""); err == nil || !strings.Contains(err.Error(), "reason is required") {
+			t.Errorf("expected a discount without a reason to be refused, got %v", err)
+		}
+	})
+}
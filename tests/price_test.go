@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"testing"
+	"time"
+)
+
+// TestPriceHistory needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestPriceHistory(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	products := repositories.NewProductRepository(db)
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	product := models.Product{Name: "Gula Pasir", Price: 15000, Stock: 10}
+	if err := products.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ScheduledPriceWaits", func(t *testing.T) {
+		price := models.ProductPrice{ProductID: product.ID, Price: 18000, EffectiveFrom: time.Now().Add(time.Hour)}
+		if err := products.SchedulePrice(&price); err != nil {
+			t.Fatal(err)
+		}
+		if price.AppliedAt != nil {
+			t.Error("expected a future price to stay scheduled")
+		}
+		got, err := products.GetByID(product.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Price != 15000 {
+			t.Errorf("expected price 15000 before the change takes effect, got %d", got.Price)
+		}
+		if err := products.CancelPrice(product.ID, price.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	trx, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("DuePriceApplied", func(t *testing.T) {
+		price := models.ProductPrice{ProductID: product.ID, Price: 16000, EffectiveFrom: time.Now().Add(-time.Minute)}
+		if err := products.SchedulePrice(&price); err != nil {
+			t.Fatal(err)
+		}
+		got, err := products.GetByID(product.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Price != 16000 {
+			t.Errorf("expected price 16000, got %d", got.Price)
+		}
+	})
+
+	t.Run("RefundUsesSalePrice", func(t *testing.T) {
+		refund, err := transactions.Refund(trx.ID, &models.RefundRequest{
+			Reason: "Kemasan rusak",
+			Items:  []models.RefundItemRequest{{TransactionDetailID: trx.Details[0].ID, Quantity: 1}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if refund.TotalAmount != 15000 || refund.Items[0].UnitPrice != 15000 {
+			t.Errorf("expected a refund of 15000 at the sale price, got %d at %d", refund.TotalAmount, refund.Items[0].UnitPrice)
+		}
+
+		if _, err := transactions.Refund(trx.ID, &models.RefundRequest{
+			Reason: "Kemasan rusak",
+			Items:  []models.RefundItemRequest{{TransactionDetailID: trx.Details[0].ID, Quantity: 2}},
+		}); err == nil {
+			t.Error("expected refunding more than was sold to fail")
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLineAmount(t *testing.T) {
+	cases := []struct {
+		price    int
+		quantity float64
+		mode     string
+		want     int
+	}{
+		{12500, 2, repositories.RoundingHalfUp, 25000},
+		{12999, 0.5, repositories.RoundingHalfUp, 6500},
+		{12999, 0.5, repositories.RoundingHalfEven, 6500},
+		{12997, 0.5, repositories.RoundingHalfEven, 6498},
+		{12999, 0.5, repositories.RoundingDown, 6499},
+		{12999, 0.5, repositories.RoundingUp, 6500},
+		{89900, 1.234, repositories.RoundingHalfUp, 110937},
+		{89900, 1.234, repositories.RoundingDown, 110936},
+		{3333, 0.1, repositories.RoundingUp, 334},
+	}
+
+	for _, c := range cases {
+		if got := repositories.LineAmount(c.price, c.quantity, c.mode); got != c.want {
+			t.Errorf("LineAmount(%d, %g, %s): expected %d, got %d", c.price, c.quantity, c.mode, c.want, got)
+		}
+	}
+}
+
+func TestCheckPrecision(t *testing.T) {
+	cases := []struct {
+		quantity  float64
+		precision int
+		ok        bool
+	}{
+		{2, 0, true},
+		{1.5, 0, false},
+		{1.25, 2, true},
+		{1.255, 2, false},
+		{0.333, 3, true},
+		{0, 3, false},
+		{-1, 0, false},
+	}
+
+	for _, c := range cases {
+		err := repositories.CheckPrecision(c.quantity, c.precision, "Beras")
+		if (err == nil) != c.ok {
+			t.Errorf("CheckPrecision(%g, %d): expected ok=%v, got %v", c.quantity, c.precision, c.ok, err)
+		}
+	}
+}
+
+func TestParseScaleBarcode(t *testing.T) {
+	cfg := &config.Config{ScaleWeightPrefixes: "20,21,22,23,24", ScalePricePrefixes: "25,26,27,28,29", ScalePLUDigits: 5}
+
+	t.Run("Weight", func(t *testing.T) {
+		scale, err := repositories.ParseScaleBarcode("2100123012503", cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scale == nil || scale.PLU != "123" || scale.Weight == nil || *scale.Weight != 1.25 || scale.Price != nil {
+			t.Errorf("expected PLU 123 weighing 1.25, got %+v", scale)
+		}
+	})
+
+	t.Run("Price", func(t *testing.T) {
+		scale, err := repositories.ParseScaleBarcode("2600123155007", cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scale == nil || scale.PLU != "123" || scale.Price == nil || *scale.Price != 15500 || scale.Weight != nil {
+			t.Errorf("expected PLU 123 priced 15500, got %+v", scale)
+		}
+	})
+
+	t.Run("NotScale", func(t *testing.T) {
+		for _, code := range []string{"8991234567890", "210012301250", "21001230125A3"} {
+			scale, err := repositories.ParseScaleBarcode(code, cfg)
+			if scale != nil || err != nil {
+				t.Errorf("%s: expected no scale barcode, got %+v, %v", code, scale, err)
+			}
+		}
+	})
+
+	t.Run("BadCheckDigit", func(t *testing.T) {
+		if _, err := repositories.ParseScaleBarcode("2100123012504", cfg); err == nil {
+			t.Error("expected an error for a wrong check digit")
+		}
+	})
+}
+
+// TestScaleWeightPrecision needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestScaleWeightPrecision(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.ScaleWeightPrefixes, cfg.ScalePricePrefixes, cfg.ScalePLUDigits = "20,21,22,23,24", "25,26,27,28,29", 5
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	plu := "10001"
+	product := models.Product{Name: "Daging Sapi", Price: 140000, Stock: 10, BaseUnit: "kg", Precision: 2, PLU: plu}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+
+	barcode := func(grams int) string {
+		code := fmt.Sprintf("21%s%05d", plu, grams)
+		sum := 0
+		for i, c := range code {
+			d := int(c - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return code + fmt.Sprint((10-sum%10)%10)
+	}
+
+	// 1.234 kg does not fit a product weighed to 10 grams.
+	_, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{Barcode: barcode(1234)}}})
+	if err == nil || !strings.Contains(err.Error(), "decimal places") {
+		t.Fatalf("expected a weight finer than the product's precision to be refused, got %v", err)
+	}
+
+	trx, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{Barcode: barcode(1250)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := trx.Details[0]; d.Quantity != 1.25 || d.Subtotal != 175000 {
+		t.Errorf("expected 1.25 kg for 175000, got %g for %d", d.Quantity, d.Subtotal)
+	}
+}
@@ -0,0 +1,317 @@
+package tests
+
+import (
+	"andre_kasir_api/handlers"
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIncludedTax(t *testing.T) {
+	cases := []struct {
+		amount, rate, want int
+	}{
+		{111000, 11, 11000},
+		{100000, 11, 9910},
+		{111000, 0, 0},
+		{0, 11, 0},
+	}
+	for _, c := range cases {
+		if got := repositories.IncludedTax(c.amount, c.rate); got != c.want {
+			t.Errorf("IncludedTax(%d, %d): expected %d, got %d", c.amount, c.rate, c.want, got)
+		}
+	}
+}
+
+// TestRegisterReports needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestRegisterReports(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.TaxRatePercent = 11
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	// An empty first Z report, for the shift to start where it ends.
+	previous, err := transactions.CloseZReport(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := models.Product{Name: "Kopi Susu", Price: 18500, Stock: 50}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	pin := tenant.pin()
+	manager := models.Employee{Name: "Budi", Role: models.EmployeeRoleManager, PIN: pin}
+	if err := repositories.NewEmployeeRepository(db, cfg).Create(&manager); err != nil {
+		t.Fatal(err)
+	}
+
+	sale, _, err := transactions.Checkout(&models.CheckoutRequest{
+		Items:    []models.CheckoutItem{{ProductID: product.ID, Quantity: 4}},
+		Payments: []models.CheckoutPayment{{Method: models.PaymentMethodCash, Amount: 74000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Refund(sale.ID, &models.RefundRequest{
+		Reason: "Tumpah",
+		Items:  []models.RefundItemRequest{{TransactionDetailID: sale.Details[0].ID, Quantity: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	voided, _, err := transactions.Checkout(&models.CheckoutRequest{
+		Items:    []models.CheckoutItem{{ProductID: product.ID, Quantity: 2}},
+		Payments: []models.CheckoutPayment{{Method: models.PaymentMethodCash, Amount: 37000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Void(voided.ID, &models.VoidRequest{Reason: "Salah input", ManagerPIN: "wrong"}); err == nil {
+		t.Fatal("expected a void without a valid manager PIN to be refused")
+	}
+	if _, err := transactions.Void(voided.ID, &models.VoidRequest{Reason: "Salah input", ManagerPIN: pin}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Refund(voided.ID, &models.RefundRequest{
+		Reason: "Tumpah",
+		Items:  []models.RefundItemRequest{{TransactionDetailID: voided.Details[0].ID, Quantity: 1}},
+	}); err == nil {
+		t.Fatal("expected a refund on a voided sale to be refused")
+	}
+
+	x, err := transactions.GetXReport(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.TransactionCount != 2 || x.NetSales != 111000 || x.Refunds != 18500 || x.Voids != 37000 {
+		t.Fatalf("unexpected X totals: %+v", x)
+	}
+	if x.TotalRevenue != 55500 || x.Tax != repositories.IncludedTax(55500, 11) {
+		t.Fatalf("expected revenue 55500 with included tax, got %+v", x)
+	}
+	// The voided sale's cash never stayed in the drawer.
+	if len(x.Payments) != 1 || x.Payments[0].Method != models.PaymentMethodCash ||
+		x.Payments[0].TransactionCount != 1 || x.Payments[0].Amount != 74000 {
+		t.Fatalf("expected 74000 cash from the one sale that was not voided, got %+v", x.Payments)
+	}
+	if x.FirstInvoice != sale.InvoiceNumber || x.LastInvoice != voided.InvoiceNumber {
+		t.Fatalf("expected invoices %s to %s, got %s to %s", sale.InvoiceNumber, voided.InvoiceNumber, x.FirstInvoice, x.LastInvoice)
+	}
+
+	z, err := transactions.CloseZReport(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.Number != previous.Number+1 || z.TotalRevenue != x.TotalRevenue || z.TransactionCount != x.TransactionCount {
+		t.Fatalf("expected Z %d to match the X report, got %+v", previous.Number+1, z)
+	}
+
+	stored, err := transactions.GetZReport(z.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap := stored.PeriodStart.Sub(previous.PeriodEnd).Abs(); len(stored.Payments) != 1 || gap > time.Millisecond {
+		t.Fatalf("unexpected stored Z report: %+v", stored)
+	}
+
+	after, err := transactions.GetXReport(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.TransactionCount != 0 || after.TotalRevenue != 0 {
+		t.Fatalf("expected an empty shift after closing, got %+v", after)
+	}
+
+	_, err = transactions.Void(sale.ID, &models.VoidRequest{Reason: "Terlambat", ManagerPIN: pin})
+	if err == nil || !strings.Contains(err.Error(), "closed by Z report") {
+		t.Fatalf("expected a void after the Z report to be refused, got %v", err)
+	}
+}
+
+// TestVoidsLeaveSalesReports needs a database with init.sql applied,
+// reachable through TEST_DB_CONN.
+func TestVoidsLeaveSalesReports(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.StoreTimezone = "Asia/Jakarta"
+	cfg.BusinessDayCutoff = "04:00"
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	handler := handlers.NewReportHandler(services.NewTransactionService(transactions, cfg, nil))
+	day, err := repositories.NewBusinessDay(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := day.Date(time.Now()).Format("2006-01-02")
+
+	report := func() models.SalesReport {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.HandleReport(rec, httptest.NewRequest(http.MethodGet, "/api/report?start_date="+today+"&end_date="+today, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 from /api/report, got %d: %s", rec.Code, rec.Body)
+		}
+		var r models.SalesReport
+		if err := json.NewDecoder(rec.Body).Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	before := report()
+
+	product := models.Product{Name: "Es Teh Manis", Price: 5000, Stock: 50}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	pin := tenant.pin()
+	manager := models.Employee{Name: "Sari", Role: models.EmployeeRoleManager, PIN: pin}
+	if err := repositories.NewEmployeeRepository(db, cfg).Create(&manager); err != nil {
+		t.Fatal(err)
+	}
+
+	kept, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Refund(kept.ID, &models.RefundRequest{
+		Reason: "Tumpah",
+		Items:  []models.RefundItemRequest{{TransactionDetailID: kept.Details[0].ID, Quantity: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	voided, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 4}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Void(voided.ID, &models.VoidRequest{Reason: "Salah input", ManagerPIN: pin}); err != nil {
+		t.Fatal(err)
+	}
+
+	after := report()
+	if revenue, count := after.TotalRevenue-before.TotalRevenue, after.TotalTransaksi-before.TotalTransaksi; revenue != 10000 || count != 1 {
+		t.Fatalf("expected the kept sale net of its refund (10000 from 1 sale), got %d from %d", revenue, count)
+	}
+
+	start := day.Date(time.Now())
+	ranks, err := transactions.RankProducts(start, start, nil, models.RankByQuantity, models.RankTop, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rank := range ranks {
+		if rank.ProductID == product.ID {
+			if rank.Quantity != 2 || rank.Revenue != 10000 || rank.TransactionCount != 1 {
+				t.Errorf("expected 2 sold for 10000 in 1 sale, got %+v", rank)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected %s in the ranking", product.Name)
+}
+
+// TestRefundsReverseTenders needs a database with init.sql applied,
+// reachable through TEST_DB_CONN.
+func TestRefundsReverseTenders(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.PointsEarnUnit = 1000
+	cfg.PointValue = 10
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	customers := repositories.NewCustomerRepository(db)
+	vouchers := repositories.NewVoucherRepository(db)
+
+	product := models.Product{Name: "Beras 5kg", Price: 10000, Stock: 100}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	customer := models.Customer{Name: "Toko Makmur", Tier: "regular", CreditLimit: 100000}
+	if err := customers.Create(&customer); err != nil {
+		t.Fatal(err)
+	}
+	giftCard := models.Voucher{Code: "RFD", Type: models.VoucherTypeGiftCard, InitialValue: 50000}
+	if _, err := vouchers.Create(&giftCard); err != nil {
+		t.Fatal(err)
+	}
+	pin := tenant.pin()
+	manager := models.Employee{Name: "Dewi", Role: models.EmployeeRoleManager, PIN: pin}
+	if err := repositories.NewEmployeeRepository(db, cfg).Create(&manager); err != nil {
+		t.Fatal(err)
+	}
+
+	// 200 points to spend from an earlier cash sale.
+	if _, _, err := transactions.Checkout(&models.CheckoutRequest{
+		Items:      []models.CheckoutItem{{ProductID: product.ID, Quantity: 20}},
+		CustomerID: &customer.ID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 100 points, 40000 on the gift card, 30000 on credit and 29000 in cash
+	// earning 69 points.
+	sale, _, err := transactions.Checkout(&models.CheckoutRequest{
+		Items:      []models.CheckoutItem{{ProductID: product.ID, Quantity: 10}},
+		CustomerID: &customer.ID,
+		Payments: []models.CheckoutPayment{
+			{Method: models.PaymentMethodPoints, Amount: 1000},
+			{Method: models.PaymentMethodGiftCard, Amount: 40000, Reference: giftCard.Code},
+			{Method: models.PaymentMethodCredit, Amount: 30000},
+			{Method: models.PaymentMethodCash, Amount: 29000},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(when string, points, outstanding, balance, uses int) {
+		t.Helper()
+		c, err := customers.GetByID(customer.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Points != points || c.OutstandingCredit != outstanding {
+			t.Errorf("%s: expected %d points and %d owed, got %d and %d", when, points, outstanding, c.Points, c.OutstandingCredit)
+		}
+		v, err := vouchers.GetByCode(giftCard.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Balance != balance || v.Uses != uses {
+			t.Errorf("%s: expected the gift card at %d with %d use, got %d with %d", when, balance, uses, v.Balance, v.Uses)
+		}
+	}
+	check("after the sale", 169, 30000, 10000, 1)
+
+	// Returning 4 of 10 gives back 40% of every tender: 12000 of the credit,
+	// 40 of the points spent, 28 of the points earned and 16000 on the card.
+	if _, err := transactions.Refund(sale.ID, &models.RefundRequest{
+		Reason: "Rusak",
+		Items:  []models.RefundItemRequest{{TransactionDetailID: sale.Details[0].ID, Quantity: 4}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	check("after the refund", 181, 18000, 26000, 1)
+
+	// Voiding the rest undoes the sale entirely.
+	if _, err := transactions.Void(sale.ID, &models.VoidRequest{Reason: "Batal", ManagerPIN: pin}); err != nil {
+		t.Fatal(err)
+	}
+	check("after the void", 200, 0, 50000, 0)
+
+	ledger, err := customers.GetPointsLedger(customer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum int
+	for _, entry := range ledger {
+		sum += entry.Points
+	}
+	if sum != 200 {
+		t.Errorf("expected the points ledger to add up to 200, got %d from %+v", sum, ledger)
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestSalesBreakdown needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestSalesBreakdown(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	category := models.Category{Name: "Minuman"}
+	if err := repositories.NewCategoryRepository(db).Create(&category); err != nil {
+		t.Fatal(err)
+	}
+	product := models.Product{Name: "Teh Botol", Price: 5000, Stock: 20, CategoryID: &category.ID}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	pin := tenant.pin()
+	cashier := models.Employee{Name: "Sari", Role: models.EmployeeRoleCashier, PIN: pin}
+	if err := repositories.NewEmployeeRepository(db, cfg).Create(&cashier); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := transactions.Checkout(&models.CheckoutRequest{
+		Items:      []models.CheckoutItem{{ProductID: product.ID, Quantity: 3}},
+		CashierPIN: pin,
+		Payments:   []models.CheckoutPayment{{Method: models.PaymentMethodQRIS, Amount: 5000}, {Method: models.PaymentMethodCash, Amount: 20000}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := transactions.Checkout(&models.CheckoutRequest{
+		Items:      []models.CheckoutItem{{ProductID: product.ID, Quantity: 1}},
+		CashierPIN: pin,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	today := time.Now().UTC()
+	find := func(groupBy, key string) models.SalesBreakdownItem {
+		t.Helper()
+		series, err := transactions.GetSalesBreakdown(today, today, nil, groupBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range series {
+			if item.Key == key {
+				return item
+			}
+		}
+		t.Fatalf("expected a %s row for %s, got %+v", groupBy, key, series)
+		return models.SalesBreakdownItem{}
+	}
+
+	for _, groupBy := range []string{models.BreakdownByProduct, models.BreakdownByCategory, models.BreakdownByCashier} {
+		key := map[string]int{
+			models.BreakdownByProduct:  product.ID,
+			models.BreakdownByCategory: category.ID,
+			models.BreakdownByCashier:  cashier.ID,
+		}[groupBy]
+		got := find(groupBy, strconv.Itoa(key))
+		if got.Revenue != 20000 || got.Quantity != 4 || got.TransactionCount != 2 || got.AverageBasket != 10000 {
+			t.Errorf("%s: expected 20000 over 2 transactions with 4 sold, got %+v", groupBy, got)
+		}
+	}
+
+	if got := find(models.BreakdownByHour, today.Format("15")); got.TransactionCount < 2 {
+		t.Errorf("expected this hour to include both transactions, got %+v", got)
+	}
+	if got := find(models.BreakdownByPaymentMethod, models.PaymentMethodQRIS); got.Revenue < 5000 {
+		t.Errorf("expected the qris payment to be counted, got %+v", got)
+	}
+
+	if _, err := transactions.GetSalesBreakdown(today, today, nil, "weekday"); err == nil {
+		t.Error("expected an unknown group_by to be rejected")
+	}
+}
+
+// TestProductRanking needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestProductRanking(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	products := repositories.NewProductRepository(db)
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	seller := models.Product{Name: "Indomie Goreng", Price: 3500, Stock: 50}
+	idle := models.Product{Name: "Payung Lipat", Price: 45000, Stock: 3}
+	for _, p := range []*models.Product{&seller, &idle} {
+		if err := products.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: seller.ID, Quantity: 5}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	today := time.Now().UTC()
+	t.Run("Top", func(t *testing.T) {
+		ranks, err := transactions.RankProducts(today, today, nil, models.RankByQuantity, models.RankTop, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, r := range ranks {
+			if r.Rank != i+1 || r.Quantity <= 0 {
+				t.Errorf("expected only sold products in rank order, got %+v", r)
+			}
+			if r.ProductID == idle.ID {
+				t.Error("expected an unsold product to stay out of the top ranking")
+			}
+		}
+	})
+
+	t.Run("Bottom", func(t *testing.T) {
+		ranks, err := transactions.RankProducts(today, today, nil, models.RankByRevenue, models.RankBottom, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranks) != 1 || ranks[0].Revenue != 0 {
+			t.Errorf("expected an unsold product at the bottom, got %+v", ranks)
+		}
+	})
+
+	t.Run("DeadStock", func(t *testing.T) {
+		items, err := transactions.GetDeadStock(today.AddDate(0, 0, -1), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, item := range items {
+			if item.ProductID == seller.ID {
+				t.Error("expected a product sold today not to be dead stock")
+			}
+			if item.ProductID == idle.ID {
+				found = true
+				if item.StockValue != 135000 || item.LastSoldAt != nil {
+					t.Errorf("expected 3 x 45000 never sold, got %+v", item)
+				}
+			}
+		}
+		if !found {
+			t.Error("expected the unsold product to be listed as dead stock")
+		}
+	})
+}
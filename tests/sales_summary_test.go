@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"testing"
+	"time"
+)
+
+// TestSalesSummary needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestSalesSummary(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.StoreTimezone = "Asia/Jakarta"
+	cfg.BusinessDayCutoff = "04:00"
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	day, err := repositories.NewBusinessDay(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := models.Product{Name: "Roti Sobek", Price: 12000, Stock: 30}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	old, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	voided, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE transactions SET created_at = created_at - INTERVAL '3 days' WHERE id IN ($1, $2)`, old.ID, voided.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	today := day.Date(now)
+	start := today.AddDate(0, 0, -5)
+
+	if _, err := transactions.RebuildSalesSummary(now); err != nil {
+		t.Fatal(err)
+	}
+	if days, err := transactions.RollUpSales(now); err != nil || days != 0 {
+		t.Fatalf("expected nothing left to roll up after a rebuild, got %d days (%v)", days, err)
+	}
+
+	// Refunds and voids of sales on days already rolled up must reach the
+	// summary too.
+	pin := tenant.pin()
+	manager := models.Employee{Name: "Dewi", Role: models.EmployeeRoleManager, PIN: pin}
+	if err := repositories.NewEmployeeRepository(db, cfg).Create(&manager); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Refund(old.ID, &models.RefundRequest{
+		Reason: "Basi",
+		Items:  []models.RefundItemRequest{{TransactionDetailID: old.Details[0].ID, Quantity: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Void(voided.ID, &models.VoidRequest{Reason: "Salah input", ManagerPIN: pin}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(startDate, endDate time.Time) {
+		t.Helper()
+		from, to := day.Range(startDate, endDate)
+		var revenue, count int
+		err := db.QueryRow(
+			`SELECT COALESCE(SUM(t.total_amount - COALESCE((SELECT SUM(rf.total_amount) FROM refunds rf WHERE rf.transaction_id = t.id), 0)), 0), COUNT(*)
+			FROM transactions t WHERE t.created_at >= $1 AND t.created_at < $2 AND t.voided_at IS NULL`,
+			from, to,
+		).Scan(&revenue, &count)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := transactions.GetReportByDateRange(startDate, endDate, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.TotalRevenue != revenue || report.TotalTransaksi != count {
+			t.Errorf("%s to %s: expected %d from %d sales, got %d from %d", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"),
+				revenue, count, report.TotalRevenue, report.TotalTransaksi)
+		}
+	}
+	check(start, today)
+	check(start, today.AddDate(0, 0, -1))
+	check(today, today)
+
+	ranks, err := transactions.RankProducts(start, today, nil, models.RankByQuantity, models.RankTop, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rank := range ranks {
+		if rank.ProductID == product.ID {
+			if rank.Quantity != 3 || rank.TransactionCount != 2 || rank.Revenue != 36000 {
+				t.Errorf("expected the summarized and live sales to add up, got %+v", rank)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected %s in the ranking", product.Name)
+}
+
+// TestCheckoutWaitsForRollup needs a database with init.sql applied,
+// reachable through TEST_DB_CONN.
+func TestCheckoutWaitsForRollup(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.StoreTimezone = "Asia/Jakarta"
+	cfg.BusinessDayCutoff = "04:00"
+	transactions := repositories.NewTransactionRepository(db, cfg)
+
+	product := models.Product{Name: "Kue Lapis", Price: 3000, Stock: 10}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the summary lock the way a rollup does.
+	rollup, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rollup.Rollback()
+	if _, err := rollup.Exec(`SELECT pg_advisory_xact_lock(hashtext('daily_sales_summary:' || current_tenant_id()))`); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := transactions.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 1}}})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected the checkout to wait for the rollup, it finished with %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	if err := rollup.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the checkout to go ahead once the rollup committed")
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"testing"
+	"time"
+)
+
+type recordingNotifier chan models.StockAlert
+
+func (n recordingNotifier) Notify(alert models.StockAlert) error {
+	n <- alert
+	return nil
+}
+
+// TestLowStockAlerts needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestLowStockAlerts(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	products := repositories.NewProductRepository(db)
+	notifier := make(recordingNotifier, 16)
+	alerter := services.NewStockAlerter(notifier)
+	alerter.Start()
+	service := services.NewTransactionService(repositories.NewTransactionRepository(db, cfg), cfg, alerter)
+
+	product := models.Product{Name: "Minyak Goreng", Price: 20000, Stock: 10, MinStock: 5, ReorderQty: 24}
+	if err := products.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	sell := func(quantity float64) *models.Transaction {
+		t.Helper()
+		trx, err := service.Checkout(&models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: quantity}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return trx
+	}
+
+	sell(3)             // 7 left, still above the minimum
+	crossing := sell(2) // 5 left, reaches the minimum
+	sell(1)             // 4 left, already below: no second alert
+	product.Stock = 10  // restocked above the minimum
+	if err := products.Update(&product); err != nil {
+		t.Fatal(err)
+	}
+	recrossing := sell(6) // 4 left, crosses again
+
+	// Alerts are delivered in order, so once the last one arrives every
+	// earlier one has too.
+	var alerts []models.StockAlert
+	timeout := time.After(5 * time.Second)
+	for len(alerts) == 0 || alerts[len(alerts)-1].TransactionID != recrossing.ID {
+		select {
+		case alert := <-notifier:
+			if alert.ProductID == product.ID {
+				alerts = append(alerts, alert)
+			}
+		case <-timeout:
+			t.Fatalf("expected an alert for sale %d, got %+v", recrossing.ID, alerts)
+		}
+	}
+
+	if len(alerts) != 2 {
+		t.Fatalf("expected one alert per crossing, got %+v", alerts)
+	}
+	first := alerts[0]
+	if first.TransactionID != crossing.ID || first.InvoiceNumber != crossing.InvoiceNumber {
+		t.Errorf("expected the first alert from sale %s, got %+v", crossing.InvoiceNumber, first)
+	}
+	if first.Stock != 5 || first.MinStock != 5 || first.ReorderQty != 24 {
+		t.Errorf("expected stock 5 of minimum 5 with reorder 24, got %+v", first)
+	}
+	if alerts[1].Stock != 4 {
+		t.Errorf("expected the second alert at stock 4, got %+v", alerts[1])
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"strings"
+	"testing"
+)
+
+// TestStoreStockAndPrices needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestStoreStockAndPrices(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	cfg.InvoiceFormat = "{STORE}-{SEQ}"
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	products := repositories.NewProductRepository(db)
+	stores := repositories.NewStoreRepository(db)
+
+	product := models.Product{Name: "Teh Botol", Price: 10000, Stock: 50}
+	if err := products.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	discounted := models.Store{Code: "BDG", Name: "Bandung"}
+	regular := models.Store{Code: "SBY", Name: "Surabaya"}
+	for _, s := range []*models.Store{&discounted, &regular} {
+		if err := stores.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	override := 9000
+	for _, sp := range []models.StoreProduct{
+		{StoreID: discounted.ID, ProductID: product.ID, Stock: 3, PriceOverride: &override},
+		{StoreID: regular.ID, ProductID: product.ID, Stock: 10},
+	} {
+		if err := stores.SetProduct(&sp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkout := func(storeID *int, quantity float64) (*models.Transaction, error) {
+		trx, _, err := transactions.Checkout(&models.CheckoutRequest{StoreID: storeID, Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: quantity}}})
+		return trx, err
+	}
+	storeStock := func(storeID int) float64 {
+		t.Helper()
+		items, err := stores.GetProducts(storeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sp := range items {
+			if sp.ProductID == product.ID {
+				return sp.Stock
+			}
+		}
+		t.Fatalf("expected product %d in store %d", product.ID, storeID)
+		return 0
+	}
+
+	trx, err := checkout(&discounted.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trx.TotalAmount != 18000 || !strings.HasPrefix(trx.InvoiceNumber, discounted.Code+"-") {
+		t.Errorf("expected 18000 at the store's price on a %s invoice, got %d on %s", discounted.Code, trx.TotalAmount, trx.InvoiceNumber)
+	}
+
+	if _, err := checkout(&discounted.ID, 2); err == nil || !strings.Contains(err.Error(), "insufficient stock") {
+		t.Fatalf("expected the store's own stock of 1 to limit the sale, got %v", err)
+	}
+
+	trx, err = checkout(&regular.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trx.TotalAmount != 20000 {
+		t.Errorf("expected a store without an override to sell at 20000, got %d", trx.TotalAmount)
+	}
+
+	if got := storeStock(discounted.ID); got != 1 {
+		t.Errorf("expected 1 left in %s, got %g", discounted.Code, got)
+	}
+	if got := storeStock(regular.ID); got != 8 {
+		t.Errorf("expected 8 left in %s, got %g", regular.Code, got)
+	}
+	p, err := products.GetByID(product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Stock != 50 {
+		t.Errorf("expected store sales to leave the product's own stock at 50, got %g", p.Stock)
+	}
+}
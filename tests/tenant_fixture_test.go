@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"andre_kasir_api/config"
+	"andre_kasir_api/database"
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tenantTables lists the tables a tenant's rows live in, children before the
+// tables they reference, so they can be deleted in this order.
+var tenantTables = []string{
+	"refund_items", "z_report_payments", "transaction_detail_batches", "stock_transfer_item_batches",
+	"stock_transfer_items", "stock_transfers", "transaction_details", "transaction_payments",
+	"voucher_redemptions", "customer_points_ledger", "receivable_payments", "receivables",
+	"refunds", "transactions", "z_reports", "vouchers", "daily_sales_summary", "sales_summary_state",
+	"invoice_sequences", "stock_movements", "product_batches", "store_products", "price_list_items",
+	"price_lists", "product_units", "product_prices", "product_costs", "products", "categories",
+	"customers", "employees", "stores",
+}
+
+var testTenants atomic.Int64
+
+// testTenant is a tenant provisioned for one test, so tests neither see each
+// other's rows nor depend on the order they run in.
+type testTenant struct {
+	id   int
+	db   *sql.DB
+	cfg  *config.Config
+	pins int
+}
+
+// newTestTenant provisions a tenant on the database reachable through
+// TEST_DB_CONN, which needs init.sql applied, and skips the test without one.
+// Its rows are deleted when the test ends. cfg holds the settings most tests
+// share; a test sets what else it needs before building repositories on it.
+func newTestTenant(t *testing.T) *testTenant {
+	t.Helper()
+	connStr := os.Getenv("TEST_DB_CONN")
+	if connStr == "" {
+		t.Skip("TEST_DB_CONN not set")
+	}
+
+	adminDB, err := database.InitDB(connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { adminDB.Close() })
+
+	tenants := repositories.NewTenantRepository(adminDB)
+	slug := fmt.Sprintf("test-%d-%d", time.Now().UnixNano(), testTenants.Add(1))
+	creds, err := services.NewTenantService(tenants).Provision(&models.ProvisionTenantRequest{Slug: slug, Name: t.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.InitDB(database.WithTenant(connStr, creds.Tenant.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := &testTenant{
+		id:  creds.Tenant.ID,
+		db:  db,
+		cfg: &config.Config{StoreCode: "MAIN", InvoiceFormat: "INV/{SEQ}", InvoiceDigits: 4, PINLookupKey: "test-pin-lookup-key"},
+	}
+
+	t.Cleanup(func() {
+		defer db.Close()
+
+		// The application role may not delete Z reports, so a tenant that
+		// closed one keeps it, and the stores it names, and is deactivated.
+		kept := false
+		for _, table := range tenantTables {
+			if _, err := db.Exec(`DELETE FROM ` + table); err != nil {
+				t.Logf("keeping %s of tenant %s: %v", table, slug, err)
+				kept = true
+			}
+		}
+		if kept {
+			err = tenants.SetActive(tenant.id, false)
+		} else {
+			_, err = adminDB.Exec(`DELETE FROM tenants WHERE id = $1`, tenant.id)
+		}
+		if err != nil {
+			t.Errorf("failed to remove tenant %s: %v", slug, err)
+		}
+	})
+
+	return tenant
+}
+
+// pin returns a PIN no other employee of the tenant has.
+func (tt *testTenant) pin() string {
+	tt.pins++
+	return fmt.Sprintf("%06d", 100000+tt.pins)
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"strings"
+	"testing"
+)
+
+// TestTransferDiscrepancy needs a database with init.sql applied, reachable
+// through TEST_DB_CONN.
+func TestTransferDiscrepancy(t *testing.T) {
+	tenant := newTestTenant(t)
+	db := tenant.db
+
+	products := repositories.NewProductRepository(db)
+	stores := repositories.NewStoreRepository(db)
+	transfers := repositories.NewTransferRepository(db)
+
+	telur := models.Product{Name: "Telur Ayam", Price: 2500, Stock: 0}
+	gula := models.Product{Name: "Gula Pasir", Price: 16000, Stock: 0}
+	for _, p := range []*models.Product{&telur, &gula} {
+		if err := products.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gudang := models.Store{Code: "GDG", Name: "Gudang"}
+	cabang := models.Store{Code: "CBG", Name: "Cabang"}
+	for _, s := range []*models.Store{&gudang, &cabang} {
+		if err := stores.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, sp := range []models.StoreProduct{
+		{StoreID: gudang.ID, ProductID: telur.ID, Stock: 30},
+		{StoreID: gudang.ID, ProductID: gula.ID, Stock: 10},
+	} {
+		if err := stores.SetProduct(&sp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	storeStock := func(storeID, productID int) float64 {
+		t.Helper()
+		items, err := stores.GetProducts(storeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sp := range items {
+			if sp.ProductID == productID {
+				return sp.Stock
+			}
+		}
+		return 0
+	}
+
+	transferID, err := transfers.Dispatch(&models.CreateTransferRequest{
+		FromStoreID: gudang.ID,
+		ToStoreID:   cabang.ID,
+		Items: []models.CheckoutItem{
+			{ProductID: telur.ID, Quantity: 20},
+			{ProductID: gula.ID, Quantity: 4},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := storeStock(gudang.ID, telur.ID); got != 10 {
+		t.Errorf("expected 10 left in the sending store after dispatch, got %g", got)
+	}
+
+	inTransit, err := transfers.GetInTransit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var travelling float64
+	for _, s := range inTransit {
+		if s.ProductID == telur.ID && s.ToStoreID == cabang.ID {
+			travelling += s.Quantity
+		}
+	}
+	if travelling != 20 {
+		t.Errorf("expected 20 in transit, got %g", travelling)
+	}
+
+	// Two eggs arrived broken; gula is left out and counts as received in full.
+	short := &models.ReceiveTransferRequest{Items: []models.ReceiveTransferItem{{ProductID: telur.ID, QuantityReceived: 18}}}
+	if err := transfers.Receive(transferID, short); err == nil || !strings.Contains(err.Error(), "note is required") {
+		t.Fatalf("expected a short receipt without a note to be refused, got %v", err)
+	}
+	short.Items[0].Note = "2 pecah"
+	if err := transfers.Receive(transferID, short); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := storeStock(cabang.ID, telur.ID); got != 18 {
+		t.Errorf("expected the receiving store to get the 18 counted, got %g", got)
+	}
+	if got := storeStock(cabang.ID, gula.ID); got != 4 {
+		t.Errorf("expected the receiving store to get all 4 of an unlisted item, got %g", got)
+	}
+
+	transfer, err := transfers.GetByID(transferID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Status != models.TransferStatusReceived || !transfer.HasDiscrepancy {
+		t.Errorf("expected a received transfer flagged with a discrepancy, got %+v", transfer)
+	}
+	for _, item := range transfer.Items {
+		switch item.ProductID {
+		case telur.ID:
+			if item.QuantityReceived == nil || *item.QuantityReceived != 18 || item.Discrepancy != -2 || item.DiscrepancyNote != "2 pecah" {
+				t.Errorf("expected 18 of 20 received with the note, got %+v", item)
+			}
+		case gula.ID:
+			if item.QuantityReceived == nil || *item.QuantityReceived != 4 || item.Discrepancy != 0 {
+				t.Errorf("expected all 4 received, got %+v", item)
+			}
+		}
+	}
+
+	movements, err := transfers.GetMovements(&telur.ID, &cabang.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 1 || movements[0].Reason != "transfer_in" || movements[0].Quantity != 18 {
+		t.Errorf("expected one transfer_in movement of 18, got %+v", movements)
+	}
+
+	if err := transfers.Receive(transferID, short); err == nil || !strings.Contains(err.Error(), "already been") {
+		t.Fatalf("expected a second receipt to be refused, got %v", err)
+	}
+	if got := storeStock(cabang.ID, telur.ID); got != 18 {
+		t.Errorf("expected the refused receipt to leave stock at 18, got %g", got)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"andre_kasir_api/handlers"
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"andre_kasir_api/services"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestStockInUnits needs a database with init.sql applied, reachable through
+// TEST_DB_CONN.
+func TestStockInUnits(t *testing.T) {
+	tenant := newTestTenant(t)
+	db := tenant.db
+
+	products := repositories.NewProductRepository(db)
+	inventory := services.NewInventoryService(repositories.NewInventoryRepository(db), nil)
+	transfers := repositories.NewTransferRepository(db)
+
+	product := models.Product{Name: "Air Mineral 600ml", Price: 4000, Stock: 10, BaseUnit: "botol"}
+	if err := products.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+	if err := products.SetUnit(&models.ProductUnit{ProductID: product.ID, Unit: "dus", Factor: 24}); err != nil {
+		t.Fatal(err)
+	}
+
+	received, err := inventory.ReceiveStock(&models.StockChangeRequest{ProductID: product.ID, Quantity: 2, Unit: "dus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Stock != 58 || received.Change != 48 {
+		t.Errorf("expected 2 dus to add 48 for 58 in stock, got %+v", received)
+	}
+
+	counted, err := inventory.CountStock(&models.StockChangeRequest{ProductID: product.ID, Quantity: 2, Unit: "dus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counted.Stock != 48 || counted.Change != -10 {
+		t.Errorf("expected a count of 2 dus to set stock to 48, 10 short, got %+v", counted)
+	}
+
+	if _, err := inventory.ReceiveStock(&models.StockChangeRequest{ProductID: product.ID, Quantity: 1, Unit: "pak"}); err == nil {
+		t.Error("expected a unit the product does not have to be refused")
+	}
+
+	movements, err := transfers.GetMovements(&product.ID, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 3 || movements[0].Reason != "stocktake" || movements[0].Quantity != -10 ||
+		movements[1].Reason != "receive" || movements[1].Quantity != 48 {
+		t.Errorf("expected the initial stock, a receipt of 48 and a stocktake of -10, got %+v", movements)
+	}
+
+	// A store that has never stocked the product starts from nothing.
+	store := models.Store{Code: "UNT", Name: "Cabang Unit"}
+	if err := repositories.NewStoreRepository(db).Create(&store); err != nil {
+		t.Fatal(err)
+	}
+	inStore, err := inventory.ReceiveStock(&models.StockChangeRequest{ProductID: product.ID, StoreID: &store.ID, Quantity: 1, Unit: "dus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inStore.Stock != 24 {
+		t.Errorf("expected the store to hold 24 after receiving 1 dus, got %+v", inStore)
+	}
+}
+
+// TestProductUpdateKeepsOmittedFields needs a database with init.sql
+// applied, reachable through TEST_DB_CONN.
+func TestProductUpdateKeepsOmittedFields(t *testing.T) {
+	tenant := newTestTenant(t)
+	db := tenant.db
+
+	service := services.NewProductService(repositories.NewProductRepository(db))
+	handler := handlers.NewProductHandler(service, nil)
+
+	plu := "00042"
+	product := models.Product{Name: "Daging Sapi", Price: 130000, Stock: 12.5, BaseUnit: "kg", Precision: 3, PLU: plu, TrackExpiry: true}
+	if err := service.Create(&product); err != nil {
+		t.Fatal(err)
+	}
+
+	// A client that predates units sends only the fields it knows.
+	body := `{"name": "Daging Sapi Has", "price": 135000, "stock": 12.5}`
+	rec := httptest.NewRecorder()
+	handler.HandleProduct(rec, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/produk/%d", product.ID), strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 from the update, got %d: %s", rec.Code, rec.Body)
+	}
+
+	stored, err := service.GetByID(product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Daging Sapi Has" || stored.Price != 135000 {
+		t.Errorf("expected the sent fields to be updated, got %+v", stored)
+	}
+	if stored.BaseUnit != "kg" || stored.Precision != 3 || stored.PLU != plu || !stored.TrackExpiry {
+		t.Errorf("expected kg, precision 3, PLU %s and expiry tracking to be kept, got %+v", plu, stored)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.HandleProduct(rec, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/produk/%d", product.ID), strings.NewReader(`{"base_unit": ""}`)))
+	if rec.Code == http.StatusOK {
+		t.Error("expected an explicitly empty base_unit to be refused")
+	}
+}
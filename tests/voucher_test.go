@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"andre_kasir_api/models"
+	"andre_kasir_api/repositories"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentVoucherRedemption needs a database with init.sql applied,
+// reachable through TEST_DB_CONN.
+func TestConcurrentVoucherRedemption(t *testing.T) {
+	tenant := newTestTenant(t)
+	db, cfg := tenant.db, tenant.cfg
+	transactions := repositories.NewTransactionRepository(db, cfg)
+	vouchers := repositories.NewVoucherRepository(db)
+
+	product := models.Product{Name: "Parfum", Price: 20000, Stock: 100}
+	if err := repositories.NewProductRepository(db).Create(&product); err != nil {
+		t.Fatal(err)
+	}
+
+	// redeem pays for one product with code from n checkouts at once and
+	// returns how many went through.
+	redeem := func(method, code string, n int) int {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := transactions.Checkout(&models.CheckoutRequest{
+					Items:    []models.CheckoutItem{{ProductID: product.ID, Quantity: 1}},
+					Payments: []models.CheckoutPayment{{Method: method, Amount: 20000, Reference: code}},
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					succeeded++
+				} else if !strings.Contains(err.Error(), "already been used") && !strings.Contains(err.Error(), "insufficient") {
+					t.Errorf("unexpected checkout error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		return succeeded
+	}
+
+	once := 1
+	voucher := models.Voucher{Code: "ONCE", Type: models.VoucherTypeVoucher, InitialValue: 20000, MaxUses: &once}
+	giftCard := models.Voucher{Code: "GIFT", Type: models.VoucherTypeGiftCard, InitialValue: 50000}
+	for _, v := range []*models.Voucher{&voucher, &giftCard} {
+		if created, err := vouchers.Create(v); err != nil || !created {
+			t.Fatalf("failed to create %s: %v", v.Code, err)
+		}
+	}
+
+	t.Run("SingleUseVoucher", func(t *testing.T) {
+		if n := redeem(models.PaymentMethodVoucher, voucher.Code, 8); n != 1 {
+			t.Errorf("expected a single-use voucher to pay for exactly 1 of 8 checkouts, paid for %d", n)
+		}
+		v, err := vouchers.GetByCode(voucher.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Uses != 1 || len(v.Redemptions) != 1 {
+			t.Errorf("expected 1 use and 1 redemption, got %d and %d", v.Uses, len(v.Redemptions))
+		}
+	})
+
+	t.Run("GiftCardBalance", func(t *testing.T) {
+		if n := redeem(models.PaymentMethodGiftCard, giftCard.Code, 5); n != 2 {
+			t.Errorf("expected 50000 to pay for exactly 2 checkouts of 20000, paid for %d", n)
+		}
+		v, err := vouchers.GetByCode(giftCard.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Balance != 10000 {
+			t.Errorf("expected 10000 left on the gift card, got %d", v.Balance)
+		}
+	})
+}